@@ -0,0 +1,105 @@
+package main
+
+import (
+	"crypto/subtle"
+	"github.com/gin-gonic/gin"
+	"gouth/storage"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// adminAuth allows only requests with the admin token passed as a bearer token
+func adminAuth(app AppConfig) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+
+		if subtle.ConstantTimeCompare([]byte(token), []byte(app.Admin.Token)) != 1 {
+			c.AbortWithStatusJSON(
+				http.StatusUnauthorized,
+				gin.H{"error": "invalid admin token"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// hasherStatsHandler returns the state of the hashing pool and wait time metrics
+func hasherStatsHandler(app AppConfig) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, app.Hash.Hasher.Stats())
+	}
+}
+
+// relation represents relation between collections of the users storage in the admin api
+type relation struct {
+	From       string   `json:"from"`
+	To         string   `json:"to"`
+	Type       string   `json:"type"`
+	FromFields []string `json:"from_fields"`
+	ToFields   []string `json:"to_fields"`
+}
+
+// relationsHandler returns relations between collections of the users storage,
+// which abstract queries can traverse
+func relationsHandler(app AppConfig) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"relations": relationsOf(app.StorageByFeature["users"].RelInfo())})
+	}
+}
+
+// refreshRelationsHandler discovers relations of the users storage again, e.g. after the schema is changed
+func refreshRelationsHandler(app AppConfig) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		usersStorage := app.storageFor(c.Request.Context(), "users")
+
+		refresher, ok := usersStorage.(storage.RelInfoRefresher)
+		if !ok {
+			c.AbortWithStatusJSON(
+				http.StatusNotImplemented,
+				gin.H{"error": "users storage doesn't discover relations"})
+			return
+		}
+
+		if err := refresher.RefreshRelInfo(); err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusInternalServerError,
+				gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"relations": relationsOf(usersStorage.RelInfo())})
+	}
+}
+
+// relationsOf returns relations ordered by collection names
+func relationsOf(relInfo map[storage.CollPair]storage.RelInfo) []relation {
+	relations := make([]relation, 0, len(relInfo))
+	for pair, info := range relInfo {
+		rel := relation{
+			From:       pair.From,
+			To:         pair.To,
+			Type:       "many_to_one",
+			FromFields: info.FromFields,
+			ToFields:   info.ToFields,
+		}
+		if info.IsO2M {
+			rel.Type = "one_to_many"
+		}
+		relations = append(relations, rel)
+	}
+
+	sort.Slice(relations, func(i, j int) bool {
+		if relations[i].From != relations[j].From {
+			return relations[i].From < relations[j].From
+		}
+		return relations[i].To < relations[j].To
+	})
+	return relations
+}
+
+// isEnabled checks whether the administrative api is configured
+func (conf AdminConfig) isEnabled() bool {
+	return conf.Token != ""
+}
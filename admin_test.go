@@ -0,0 +1,76 @@
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gouth/storage"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_adminAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/admin", adminAuth(AppConfig{Admin: AdminConfig{Token: "secret"}}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{name: "valid token", header: "Bearer secret", want: http.StatusOK},
+		{name: "invalid token", header: "Bearer other", want: http.StatusUnauthorized},
+		{name: "without token", header: "", want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func Test_relationsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	usersStorage, err := storage.Open(storage.RawStorageConfig{"connection_url": "memory://" + t.Name()}, []string{"users"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer usersStorage.Close()
+
+	app := AppConfig{StorageByFeature: map[string]storage.ConnSession{"users": usersStorage}}
+	r := gin.New()
+	r.GET("/relations", relationsHandler(app))
+	r.POST("/relations/refresh", refreshRelationsHandler(app))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/relations", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"relations": []}`, w.Body.String())
+
+	// memory storage has no relations to discover
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/relations/refresh", nil))
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+}
+
+func Test_relationsOf(t *testing.T) {
+	relations := relationsOf(map[storage.CollPair]storage.RelInfo{
+		{From: "users", To: "posts"}: {IsO2M: true, FromFields: []string{"id"}, ToFields: []string{"user_id"}},
+		{From: "posts", To: "users"}: {IsO2M: false, FromFields: []string{"user_id"}, ToFields: []string{"id"}},
+	})
+
+	assert.Equal(t, []relation{
+		{From: "posts", To: "users", Type: "many_to_one", FromFields: []string{"user_id"}, ToFields: []string{"id"}},
+		{From: "users", To: "posts", Type: "one_to_many", FromFields: []string{"id"}, ToFields: []string{"user_id"}},
+	}, relations)
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+)
+
+// auditEvent writes security relevant event to the log in a form, that is easy to grep and parse
+func auditEvent(event string, fields map[string]interface{}) {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		log.Printf("audit: event=%s marshal fields: %v", event, err)
+		return
+	}
+
+	log.Printf("audit: event=%s %s", event, data)
+}
@@ -0,0 +1,39 @@
+// Command calibrate benchmarks the host and prints hasher settings,
+// which take the target time to hash one password:
+//
+//	go run ./cmd/calibrate -alg argon2 -target 500ms -memory 65536
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gouth/pwhash/calibrate"
+	"os"
+	"time"
+
+	_ "gouth/pwhash/adapters/argon2"
+	_ "gouth/pwhash/adapters/bcrypt"
+	_ "gouth/pwhash/adapters/pbkdf2"
+	_ "gouth/pwhash/adapters/scrypt"
+)
+
+func main() {
+	var opts calibrate.Options
+
+	flag.StringVar(&opts.Alg, "alg", "argon2", "hasher adapter: argon2, pbkdf2, bcrypt or scrypt")
+	flag.DurationVar(&opts.Target, "target", 500*time.Millisecond, "maximum time of hashing one password")
+	flag.IntVar(&opts.MemoryKiB, "memory", 64*1024, "memory budget of hashing one password in KiB (argon2, scrypt)")
+	flag.IntVar(&opts.Parallelism, "parallelism", 0, "number of threads (argon2, scrypt), defaults to min(CPUs, 4)")
+	flag.StringVar(&opts.Type, "type", "argon2id", "argon2 type: argon2i or argon2id")
+	flag.StringVar(&opts.Func, "func", "sha256", "pbkdf2 pseudorandom function")
+	samples := flag.Int("samples", 3, "number of measurements of each candidate")
+	flag.Parse()
+
+	res, err := calibrate.New(*samples).Run(opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	fmt.Print(res.YAML())
+}
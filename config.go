@@ -1,10 +1,21 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"gouth/pwhash"
+	"gouth/pwpolicy"
+	"gouth/ratelimit"
 	"gouth/storage"
+	"gouth/webauthn"
+	"io/ioutil"
 	"log"
+	"os"
+	"runtime"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -20,19 +31,97 @@ type AppConfig struct {
 	PathPrefix       string                              `yaml:"path_prefix"`
 	StorageByFeature map[string]storage.ConnSession      `yaml:"-"`
 	RawStorageConfs  map[string]storage.RawStorageConfig `yaml:"storages"`
+	WebAuthn         WebAuthnConfig                      `yaml:"webauthn"`
 	Main             MainConfig                          `yaml:"main"`
 	Hash             HashConfig                          `yaml:"hasher"`
+	Admin            AdminConfig                         `yaml:"admin"`
+	RateLimits       RateLimitConfig                     `yaml:"rate_limits"`
+	PasswordPolicy   PasswordPolicyConfig                `yaml:"password_policy"`
+}
+
+// storageFor returns session of the storage with the feature, which runs queries with the given context
+func (a AppConfig) storageFor(ctx context.Context, feature string) storage.ConnSession {
+	return a.StorageByFeature[feature].WithContext(ctx)
+}
+
+// hasherFor returns the app hasher, which stops waiting for a hashing slot when the context is done
+func (a AppConfig) hasherFor(ctx context.Context) *pwhash.Pooled {
+	return a.Hash.Hasher.WithContext(ctx)
+}
+
+// PasswordPolicyConfig represents rules for the passwords set by the users
+type PasswordPolicyConfig struct {
+	IsEnabled        bool             `yaml:"enabled"`
+	MinLength        int              `yaml:"min_length"`
+	MaxLength        int              `yaml:"max_length"`
+	RequireUpper     bool             `yaml:"require_upper"`
+	RequireLower     bool             `yaml:"require_lower"`
+	RequireDigit     bool             `yaml:"require_digit"`
+	RequireSymbol    bool             `yaml:"require_symbol"`
+	MinClasses       int              `yaml:"min_classes"`
+	MinScore         int              `yaml:"min_score"`
+	RejectSimilar    bool             `yaml:"reject_similar"`
+	BreachedFile     string           `yaml:"breached_file"`
+	BreachedMinCount int              `yaml:"breached_min_count"`
+	Policy           *pwpolicy.Policy `yaml:"-"`
+}
+
+// RateLimitConfig represents settings for rate limiting of the app routes.
+// Rules for the "*" route are applied to the routes without own rules
+type RateLimitConfig struct {
+	StorageName  string                     `yaml:"storage"`
+	Coll         *storage.CollConfig        `yaml:"collection"`
+	ClientHeader string                     `yaml:"client_header"`
+	Routes       map[string][]RateLimitRule `yaml:"routes"`
+	Backend      ratelimit.Backend          `yaml:"-"`
+}
+
+// RateLimitRule represents one token bucket applied to the route.
+// Key is one of ip, user_unique or client. Period is set in seconds
+type RateLimitRule struct {
+	Key    string `yaml:"key"`
+	Path   string `yaml:"path"`
+	Limit  int    `yaml:"limit"`
+	Period int    `yaml:"period"`
+	Burst  int    `yaml:"burst"`
+}
+
+// AdminConfig represents settings for the administrative api
+type AdminConfig struct {
+	Token string `yaml:"token"`
+}
+
+// WebAuthnConfig represents settings of the relying party for WebAuthn ceremonies
+type WebAuthnConfig struct {
+	StorageName      string              `yaml:"storage"`
+	RPID             string              `yaml:"rp_id"`
+	RPName           string              `yaml:"rp_name"`
+	Origins          []string            `yaml:"origins"`
+	Timeout          int                 `yaml:"timeout"`
+	UserVerification string              `yaml:"user_verification"`
+	CredColl         *storage.CollConfig `yaml:"credential_collection"`
+	ChallengeColl    *storage.CollConfig `yaml:"challenge_collection"`
+	Session          string              `yaml:"session"`
+	Credential       string              `yaml:"credential"`
+	RP               *webauthn.WebAuthn  `yaml:"-"`
 }
 
 // MainConfig represents settings for authentication
 type MainConfig struct {
 	UseExistColl bool                    `yaml:"use_existent_collection"`
 	UserColl     *storage.UserCollConfig `yaml:"user_collection"`
+	Migrations   MigrationsConfig        `yaml:"migrations"`
 	AuthN        AuthNConfig             `yaml:"authN"`
 	AuthZ        AuthZConfig             `yaml:"authZ"`
 	Register     RegisterConfig          `yaml:"register"`
 }
 
+// MigrationsConfig represents settings for the schema migrations of the user collection.
+// Applied versions are kept in the schema version collection
+type MigrationsConfig struct {
+	Coll *storage.CollConfig `yaml:"collection"`
+}
+
 type CookieAuthConfig struct {
 	StorageName string `yaml:"storage"`
 	Domain      string `yaml:"domain"`
@@ -45,6 +134,7 @@ type CookieAuthConfig struct {
 // AuthNConfig represents settings for authentication methods
 type AuthNConfig struct {
 	PasswdBased PasswordBasedConfig `yaml:"password_based"`
+	MFA         MFAConfig           `yaml:"mfa"`
 }
 
 // AuthZConfig represents settings for authorization methods
@@ -61,8 +151,47 @@ type RegisterConfig struct {
 }
 
 type PasswordBasedConfig struct {
-	UserUnique  string `yaml:"user_unique"`
-	UserConfirm string `yaml:"user_confirm"`
+	UserUnique  string        `yaml:"user_unique"`
+	UserConfirm string        `yaml:"user_confirm"`
+	Lockout     LockoutConfig `yaml:"lockout"`
+}
+
+// LockoutConfig represents settings for brute-force protection of password based authentication.
+// Durations are set in seconds
+type LockoutConfig struct {
+	StorageName   string              `yaml:"storage"`
+	Coll          *storage.CollConfig `yaml:"collection"`
+	MaxFailures   int                 `yaml:"max_failures"`
+	IPMaxFailures int                 `yaml:"ip_max_failures"`
+	Duration      int                 `yaml:"duration"`
+	Window        int                 `yaml:"window"`
+	Delays        []int               `yaml:"delays"`
+}
+
+// MFAConfig represents settings for the second authentication factor
+type MFAConfig struct {
+	StorageName    string               `yaml:"storage"`
+	RecoveryCodes  RecoveryCodesConfig  `yaml:"recovery_codes"`
+	TrustedDevices TrustedDevicesConfig `yaml:"trusted_devices"`
+}
+
+// RecoveryCodesConfig represents settings for single-use recovery codes
+type RecoveryCodesConfig struct {
+	Coll   *storage.CollConfig `yaml:"collection"`
+	Code   string              `yaml:"code"`
+	Count  int                 `yaml:"count"`
+	Length int                 `yaml:"length"`
+}
+
+// TrustedDevicesConfig represents settings for skipping MFA on remembered browsers
+type TrustedDevicesConfig struct {
+	IsEnabled  bool                `yaml:"enabled"`
+	Coll       *storage.CollConfig `yaml:"collection"`
+	Lifetime   int                 `yaml:"lifetime"`
+	CookieName string              `yaml:"cookie_name"`
+	Secret     string              `yaml:"secret"`
+	SecretEnv  string              `yaml:"secret_env"`
+	Remember   string              `yaml:"remember"`
 }
 
 type JWTConfig struct {
@@ -76,6 +205,34 @@ type JWTConfig struct {
 type HashConfig struct {
 	AlgName     string               `yaml:"alg"`
 	RawHashConf pwhash.RawHashConfig `yaml:"settings"`
+	Legacy      []HashConfig         `yaml:"legacy"`
+	Pepper      PepperConfig         `yaml:"pepper"`
+	Pool        HashPoolConfig       `yaml:"pool"`
+	Hasher      *pwhash.Pooled       `yaml:"-"`
+}
+
+// HashPoolConfig represents limits of concurrent hashing. The pool size is the smallest
+// of size and the number of hashes fitting the memory budget (in kibibytes).
+// If none is set, it's the number of CPUs. Queue timeout is set in milliseconds
+type HashPoolConfig struct {
+	Size         int `yaml:"size"`
+	MemoryBudget int `yaml:"memory_budget"`
+	QueueTimeout int `yaml:"queue_timeout"`
+}
+
+// PepperConfig represents secrets applied to passwords before hashing.
+// Current pepper is used for new hashes, the rest only verify old ones
+type PepperConfig struct {
+	Current string            `yaml:"current"`
+	Keys    []PepperKeyConfig `yaml:"keys"`
+	Peppers map[string][]byte `yaml:"-"`
+}
+
+// PepperKeyConfig represents pepper secret loaded from the file or environment variable
+type PepperKeyConfig struct {
+	ID   string `yaml:"id"`
+	File string `yaml:"file"`
+	Env  string `yaml:"env"`
 }
 
 // Init loads settings for whole project into global object conf
@@ -94,14 +251,93 @@ func (c *ProjectConfig) Init(data []byte) {
 	}
 }
 
+// Migrate applies migrations of the collections kept by the apps without starting the apps.
+// In dry run the statements are printed to opts.Out instead
+func (c *ProjectConfig) Migrate(data []byte, opts storage.MigrateOptions) error {
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("project config migrate: %v", err)
+	}
+
+	for name, app := range c.Apps {
+		app.setCollDefaults()
+		if err := storage.ValidatePkType(app.Main.UserColl.PkType); err != nil {
+			return fmt.Errorf("app %s user collection: %v", name, err)
+		}
+
+		if err := app.openStorages(app.isMigrated); err != nil {
+			return fmt.Errorf("app %s open session: %v", name, err)
+		}
+
+		err := app.migrateColls(opts, func(scope string, from, to int) {
+			if !opts.DryRun {
+				log.Printf("app %s: %s collection migrated from version %d to %d", name, scope, from, to)
+			}
+		})
+		app.closeStorages()
+		if err != nil {
+			return fmt.Errorf("app %s: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
 // init initializes app by creating table users
 func (a *AppConfig) init() {
+	a.setCollDefaults()
+
+	if err := a.openStorages(nil); err != nil {
+		log.Panicf("app open session: %v", err)
+	}
+
+	if err := a.initSecrets(); err != nil {
+		log.Panicf("app init: %v", err)
+	}
+
+	if err := a.initUserColl(); err != nil {
+		log.Panicf("app init: %v", err)
+	}
+
+	if err := a.initPepper(); err != nil {
+		log.Panicf("app init: %v", err)
+	}
+
+	if err := a.initHasher(); err != nil {
+		log.Panicf("app init: %v", err)
+	}
+
+	if err := a.initMFAColl(); err != nil {
+		log.Panicf("app init: %v", err)
+	}
+
+	if err := a.initWebAuthn(); err != nil {
+		log.Panicf("app init: %v", err)
+	}
+
+	if err := a.initLockout(); err != nil {
+		log.Panicf("app init: %v", err)
+	}
+
+	if err := a.initRateLimits(); err != nil {
+		log.Panicf("app init: %v", err)
+	}
+
+	if err := a.initPasswordPolicy(); err != nil {
+		log.Panicf("app init: %v", err)
+	}
+}
+
+// openStorages opens sessions of the storages with the features they are used for.
+// If isNeeded is set, only the storages of the needed features are opened
+func (a *AppConfig) openStorages(isNeeded func(feature string) bool) error {
 	storageFeatures := map[string][]string{}
 	a.StorageByFeature = map[string]storage.ConnSession{}
 
-	for storageName := range a.RawStorageConfs {
-		if _, ok := storageFeatures[storageName]; !ok {
-			storageFeatures[storageName] = []string{}
+	if isNeeded == nil {
+		for storageName := range a.RawStorageConfs {
+			if _, ok := storageFeatures[storageName]; !ok {
+				storageFeatures[storageName] = []string{}
+			}
 		}
 	}
 
@@ -111,10 +347,40 @@ func (a *AppConfig) init() {
 	sessionStorage := a.Main.AuthZ.CookieConf.StorageName
 	storageFeatures[sessionStorage] = append(storageFeatures[sessionStorage], "sessions")
 
+	if mfaStorage := a.Main.AuthN.MFA.StorageName; mfaStorage != "" {
+		storageFeatures[mfaStorage] = append(storageFeatures[mfaStorage], "mfa")
+	}
+
+	if lockoutStorage := a.Main.AuthN.PasswdBased.Lockout.StorageName; lockoutStorage != "" {
+		storageFeatures[lockoutStorage] = append(storageFeatures[lockoutStorage], "login_attempts")
+	}
+
+	if rateLimitStorage := a.RateLimits.StorageName; rateLimitStorage != "" {
+		storageFeatures[rateLimitStorage] = append(storageFeatures[rateLimitStorage], "rate_limits")
+	}
+
+	if webauthnStorage := a.WebAuthn.StorageName; webauthnStorage != "" {
+		storageFeatures[webauthnStorage] = append(storageFeatures[webauthnStorage], "webauthn")
+	}
+
 	for storageName, features := range storageFeatures {
+		if isNeeded != nil {
+			needed := []string{}
+			for _, f := range features {
+				if isNeeded(f) {
+					needed = append(needed, f)
+				}
+			}
+			if len(needed) == 0 {
+				continue
+			}
+			features = needed
+		}
+
 		connSess, err := storage.Open(a.RawStorageConfs[storageName], features)
 		if err != nil {
-			log.Panicf("app open session: %v", err)
+			a.closeStorages()
+			return err
 		}
 
 		for _, f := range features {
@@ -122,29 +388,592 @@ func (a *AppConfig) init() {
 		}
 	}
 
-	if err := a.initUserColl(); err != nil {
-		log.Panicf("app init: %v", err)
+	return nil
+}
+
+// closeStorages closes sessions of all storages opened by openStorages
+func (a *AppConfig) closeStorages() {
+	closed := map[storage.ConnSession]bool{}
+	for _, connSess := range a.StorageByFeature {
+		if !closed[connSess] {
+			connSess.Close()
+			closed[connSess] = true
+		}
 	}
 }
 
+// setCollDefaults sets names of the collections, which aren't configured.
+// It's separated from the init of the features, since the migrations need the names too
+func (a *AppConfig) setCollDefaults() {
+	if a.Main.UserColl == nil {
+		a.Main.UserColl = storage.NewUserCollConfig("users", "id", "username", "password")
+	}
+	if a.Main.Migrations.Coll == nil {
+		a.Main.Migrations.Coll = storage.NewCollConfig("schema_migrations", "scope")
+	} else if a.Main.Migrations.Coll.Pk == "" {
+		a.Main.Migrations.Coll.Pk = "scope"
+	}
+
+	mfaConf := &a.Main.AuthN.MFA
+	if mfaConf.RecoveryCodes.Coll == nil {
+		mfaConf.RecoveryCodes.Coll = storage.NewCollConfig(storage.RecoveryCodesColl, "id")
+	}
+	if mfaConf.TrustedDevices.Coll == nil {
+		mfaConf.TrustedDevices.Coll = storage.NewCollConfig(storage.TrustedDevicesColl, "id")
+	}
+
+	if a.WebAuthn.CredColl == nil {
+		a.WebAuthn.CredColl = storage.NewCollConfig(storage.WebAuthnCredsColl, "id")
+	}
+	if a.WebAuthn.ChallengeColl == nil {
+		a.WebAuthn.ChallengeColl = storage.NewCollConfig(storage.WebAuthnChallengesColl, "id")
+	}
+
+	if lockoutConf := &a.Main.AuthN.PasswdBased.Lockout; lockoutConf.Coll == nil {
+		lockoutConf.Coll = storage.NewCollConfig(storage.LoginAttemptsColl, "key")
+	}
+	if a.RateLimits.Coll == nil {
+		a.RateLimits.Coll = storage.NewCollConfig(storage.RateLimitsColl, "key")
+	}
+}
+
+// managedColl is the collection, which is created by the app for the feature
+type managedColl struct {
+	feature string
+	kind    string
+	conf    storage.CollConfig
+}
+
+// managedColls returns collections of the enabled features except the user collection
+func (a *AppConfig) managedColls() []managedColl {
+	var colls []managedColl
+
+	if mfaConf := a.Main.AuthN.MFA; mfaConf.isEnabled() {
+		colls = append(colls, managedColl{"mfa", storage.RecoveryCodesColl, *mfaConf.RecoveryCodes.Coll})
+		if mfaConf.TrustedDevices.IsEnabled {
+			colls = append(colls, managedColl{"mfa", storage.TrustedDevicesColl, *mfaConf.TrustedDevices.Coll})
+		}
+	}
+
+	if a.WebAuthn.isEnabled() {
+		colls = append(colls,
+			managedColl{"webauthn", storage.WebAuthnCredsColl, *a.WebAuthn.CredColl},
+			managedColl{"webauthn", storage.WebAuthnChallengesColl, *a.WebAuthn.ChallengeColl})
+	}
+
+	if lockoutConf := a.Main.AuthN.PasswdBased.Lockout; lockoutConf.isEnabled() {
+		colls = append(colls, managedColl{"login_attempts", storage.LoginAttemptsColl, *lockoutConf.Coll})
+	}
+
+	if a.RateLimits.isEnabled() && a.RateLimits.StorageName != "" {
+		colls = append(colls, managedColl{"rate_limits", storage.RateLimitsColl, *a.RateLimits.Coll})
+	}
+
+	return colls
+}
+
 func (a *AppConfig) initUserColl() error {
 	usersStorage := a.StorageByFeature["users"]
 
-	if a.Main.UserColl == nil {
-		a.Main.UserColl = storage.NewUserCollConfig("users", "id", "username", "password")
+	if err := storage.ValidatePkType(a.Main.UserColl.PkType); err != nil {
+		return fmt.Errorf("user collection: %v", err)
+	}
+
+	if !a.Main.UseExistColl {
+		if _, ok := usersStorage.(storage.Migrator); ok {
+			from, to, err := a.migrateUserColl(storage.MigrateOptions{Target: storage.LatestVersion})
+			if err != nil {
+				return err
+			}
+
+			// relations are discovered on open, before the migrations changed the schema
+			if refresher, ok := usersStorage.(storage.RelInfoRefresher); ok && from != to {
+				if err := refresher.RefreshRelInfo(); err != nil {
+					return err
+				}
+			}
+			return checkPkType(usersStorage, *a.Main.UserColl)
+		}
 	}
+
 	isExists, err := usersStorage.IsCollExists(a.Main.UserColl.ToCollConfig())
 	if err != nil {
 		return err
 	}
 
 	if !a.Main.UseExistColl && !isExists {
-		if err = usersStorage.CreateUserColl(*a.Main.UserColl); err != nil {
-			return err
-		}
+		return usersStorage.CreateUserColl(*a.Main.UserColl)
 	} else if a.Main.UseExistColl && !isExists {
 		return errors.New("user collection is not found")
 	}
 
+	return checkPkType(usersStorage, *a.Main.UserColl)
+}
+
+// checkPkType checks whether the existing user collection has the configured pk type, if the storage can tell it
+func checkPkType(usersStorage storage.ConnSession, collConf storage.UserCollConfig) error {
+	if checker, ok := usersStorage.(storage.PkTypeChecker); ok {
+		return checker.CheckPkType(collConf)
+	}
 	return nil
 }
+
+// migrateUserColl brings the user collection to the target version.
+// The collection name is the scope of the versions, so several apps can share the database
+func (a *AppConfig) migrateUserColl(opts storage.MigrateOptions) (int, int, error) {
+	m, ok := a.StorageByFeature["users"].(storage.Migrator)
+	if !ok {
+		return 0, 0, errors.New("users storage doesn't support migrations")
+	}
+
+	userColl := *a.Main.UserColl
+	return storage.Migrate(m, *a.Main.Migrations.Coll, userColl.Name, m.UserCollMigrations(userColl), opts)
+}
+
+// isMigrated checks whether collections of the feature are kept by migrations
+func (a *AppConfig) isMigrated(feature string) bool {
+	if feature == "users" {
+		return !a.Main.UseExistColl
+	}
+
+	for _, coll := range a.managedColls() {
+		if coll.feature == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// migrateColl brings the collection of the feature to the target version.
+// It returns false if the storage keeps the collection without migrations
+func (a *AppConfig) migrateColl(coll managedColl, opts storage.MigrateOptions) (int, int, bool, error) {
+	m, ok := a.StorageByFeature[coll.feature].(storage.Migrator)
+	if !ok {
+		return 0, 0, false, nil
+	}
+
+	migrations := m.CollMigrations(coll.kind, coll.conf)
+	if migrations == nil {
+		return 0, 0, false, nil
+	}
+
+	from, to, err := storage.Migrate(m, *a.Main.Migrations.Coll, coll.conf.Name, migrations, opts)
+	return from, to, true, err
+}
+
+// migrateColls brings all collections of the app to the target version
+// and reports each migrated collection
+func (a *AppConfig) migrateColls(opts storage.MigrateOptions, report func(scope string, from, to int)) error {
+	if !a.Main.UseExistColl {
+		from, to, err := a.migrateUserColl(opts)
+		if err != nil {
+			return err
+		}
+		report(a.Main.UserColl.Name, from, to)
+	}
+
+	for _, coll := range a.managedColls() {
+		from, to, ok, err := a.migrateColl(coll, opts)
+		if err != nil {
+			return err
+		}
+		if ok {
+			report(coll.conf.Name, from, to)
+		}
+	}
+
+	return nil
+}
+
+// initColl migrates the collection of the feature if the storage supports migrations
+// and creates it if it doesn't exist otherwise
+func (a *AppConfig) initColl(feature, kind string, conf storage.CollConfig, create func(storage.CollConfig) error) error {
+	coll := managedColl{feature, kind, conf}
+	_, _, ok, err := a.migrateColl(coll, storage.MigrateOptions{Target: storage.LatestVersion})
+	if ok || err != nil {
+		return err
+	}
+
+	isExists, err := a.StorageByFeature[feature].IsCollExists(conf)
+	if err != nil {
+		return err
+	}
+
+	if !isExists {
+		return create(conf)
+	}
+
+	return nil
+}
+
+func (a *AppConfig) initMFAColl() error {
+	if !a.Main.AuthN.MFA.isEnabled() {
+		return nil
+	}
+
+	recoveryConf := &a.Main.AuthN.MFA.RecoveryCodes
+	if recoveryConf.Code == "" {
+		recoveryConf.Code = "{$.recovery_code}"
+	}
+	if recoveryConf.Count == 0 {
+		recoveryConf.Count = 10
+	}
+	if recoveryConf.Length == 0 {
+		recoveryConf.Length = 10
+	}
+
+	mfaStorage, ok := a.StorageByFeature["mfa"].(storage.MFA)
+	if !ok {
+		return errors.New("mfa storage doesn't support recovery codes")
+	}
+
+	err := a.initColl("mfa", storage.RecoveryCodesColl, *recoveryConf.Coll, mfaStorage.CreateRecoveryCodesColl)
+	if err != nil {
+		return err
+	}
+
+	devicesConf := &a.Main.AuthN.MFA.TrustedDevices
+	if !devicesConf.IsEnabled {
+		return nil
+	}
+
+	if devicesConf.Lifetime == 0 {
+		devicesConf.Lifetime = 30
+	}
+	if devicesConf.CookieName == "" {
+		devicesConf.CookieName = "aureole_device"
+	}
+	if devicesConf.Remember == "" {
+		devicesConf.Remember = "{$.remember_device}"
+	}
+
+	return a.initColl("mfa", storage.TrustedDevicesColl, *devicesConf.Coll, mfaStorage.CreateTrustedDeviceColl)
+}
+
+// isEnabled checks whether the second authentication factor is configured
+func (conf MFAConfig) isEnabled() bool {
+	return conf.StorageName != ""
+}
+
+func (a *AppConfig) initWebAuthn() error {
+	conf := &a.WebAuthn
+	if !conf.isEnabled() {
+		return nil
+	}
+
+	rp, err := webauthn.New(webauthn.Config{
+		RPID:             conf.RPID,
+		RPName:           conf.RPName,
+		Origins:          conf.Origins,
+		Timeout:          time.Duration(conf.Timeout) * time.Second,
+		UserVerification: conf.UserVerification,
+	})
+	if err != nil {
+		return err
+	}
+	conf.RP = rp
+
+	if conf.Session == "" {
+		conf.Session = "{$.webauthn_session}"
+	}
+	if conf.Credential == "" {
+		conf.Credential = "{$.webauthn}"
+	}
+
+	webauthnStorage, ok := a.StorageByFeature["webauthn"].(storage.WebAuthn)
+	if !ok {
+		return errors.New("webauthn storage doesn't support public key credentials")
+	}
+
+	err = a.initColl("webauthn", storage.WebAuthnCredsColl, *conf.CredColl, webauthnStorage.CreateWebAuthnCredColl)
+	if err != nil {
+		return err
+	}
+
+	return a.initColl("webauthn", storage.WebAuthnChallengesColl, *conf.ChallengeColl, webauthnStorage.CreateWebAuthnChallengeColl)
+}
+
+// isEnabled checks whether WebAuthn ceremonies are configured
+func (conf WebAuthnConfig) isEnabled() bool {
+	return conf.StorageName != ""
+}
+
+func (a *AppConfig) initLockout() error {
+	conf := &a.Main.AuthN.PasswdBased.Lockout
+	if !conf.isEnabled() {
+		return nil
+	}
+
+	if conf.MaxFailures == 0 {
+		conf.MaxFailures = 5
+	}
+	if conf.IPMaxFailures == 0 {
+		conf.IPMaxFailures = 10 * conf.MaxFailures
+	}
+	if conf.Duration == 0 {
+		conf.Duration = 15 * 60
+	}
+	if conf.Window == 0 {
+		conf.Window = conf.Duration
+	}
+
+	attemptsStorage, ok := a.StorageByFeature["login_attempts"].(storage.LoginAttempts)
+	if !ok {
+		return errors.New("lockout storage doesn't support login attempts")
+	}
+
+	return a.initColl("login_attempts", storage.LoginAttemptsColl, *conf.Coll, attemptsStorage.CreateLoginAttemptColl)
+}
+
+// isEnabled checks whether brute-force protection is configured
+func (conf LockoutConfig) isEnabled() bool {
+	return conf.StorageName != ""
+}
+
+func (a *AppConfig) initRateLimits() error {
+	conf := &a.RateLimits
+	if !conf.isEnabled() {
+		return nil
+	}
+
+	for route, rules := range conf.Routes {
+		for _, rule := range rules {
+			switch rule.Key {
+			case "ip", "user_unique", "client":
+			default:
+				return fmt.Errorf("rate limits: unknown key %s for route %s", rule.Key, route)
+			}
+
+			if rule.Limit <= 0 || rule.Period <= 0 {
+				return fmt.Errorf("rate limits: limit and period must be positive for route %s", route)
+			}
+		}
+	}
+
+	if conf.ClientHeader == "" {
+		conf.ClientHeader = "X-Client-Id"
+	}
+
+	if conf.StorageName == "" {
+		conf.Backend = ratelimit.NewMemoryBackend()
+		return nil
+	}
+
+	rateLimitStorage, ok := a.StorageByFeature["rate_limits"].(storage.RateLimits)
+	if !ok {
+		return errors.New("rate limits storage doesn't support token buckets")
+	}
+
+	err := a.initColl("rate_limits", storage.RateLimitsColl, *conf.Coll, rateLimitStorage.CreateRateLimitColl)
+	if err != nil {
+		return err
+	}
+
+	conf.Backend = ratelimit.NewStorageBackend(a.StorageByFeature["rate_limits"], *conf.Coll)
+	return nil
+}
+
+// isEnabled checks whether any rate limiting rules are configured
+func (conf RateLimitConfig) isEnabled() bool {
+	return len(conf.Routes) != 0
+}
+
+// limit converts the rule into token bucket limit
+func (rule RateLimitRule) limit() ratelimit.Limit {
+	return ratelimit.Limit{
+		Count:  rule.Limit,
+		Period: time.Duration(rule.Period) * time.Second,
+		Burst:  rule.Burst,
+	}
+}
+
+func (a *AppConfig) initPasswordPolicy() error {
+	conf := &a.PasswordPolicy
+	if !conf.IsEnabled {
+		return nil
+	}
+
+	if conf.MinLength == 0 {
+		conf.MinLength = 8
+	}
+	if conf.MaxLength == 0 {
+		conf.MaxLength = 64
+	}
+
+	policy, err := pwpolicy.New(pwpolicy.Config{
+		MinLength:        conf.MinLength,
+		MaxLength:        conf.MaxLength,
+		RequireUpper:     conf.RequireUpper,
+		RequireLower:     conf.RequireLower,
+		RequireDigit:     conf.RequireDigit,
+		RequireSymbol:    conf.RequireSymbol,
+		MinClasses:       conf.MinClasses,
+		MinScore:         conf.MinScore,
+		RejectSimilar:    conf.RejectSimilar,
+		BreachedFile:     conf.BreachedFile,
+		BreachedMinCount: conf.BreachedMinCount,
+	})
+	if err != nil {
+		return err
+	}
+
+	conf.Policy = policy
+	return nil
+}
+
+// newPwHasher returns hasher of the primary algorithm.
+// If legacy algorithms are set, the hasher also verifies their hashes
+func (conf HashConfig) newPwHasher() (pwhash.PwHasher, error) {
+	primary, err := pwhash.New(conf.AlgName, &conf.RawHashConf)
+	if err != nil {
+		return nil, err
+	}
+	if pwhash.IsVerifyOnly(primary) {
+		return nil, fmt.Errorf("hasher %s only verifies imported hashes, it can be used as legacy hasher only", conf.AlgName)
+	}
+
+	h := primary
+	if len(conf.Legacy) != 0 {
+		legacy := make([]pwhash.PwHasher, len(conf.Legacy))
+		for i := range conf.Legacy {
+			legacyConf := conf.Legacy[i]
+
+			// legacy hashers only verify existing hashes, so security floors don't apply to them
+			rawConf := pwhash.RawHashConfig{pwhash.AllowWeakKey: true}
+			for k, v := range legacyConf.RawHashConf {
+				rawConf[k] = v
+			}
+
+			if legacy[i], err = pwhash.New(legacyConf.AlgName, &rawConf); err != nil {
+				return nil, err
+			}
+		}
+
+		h = pwhash.NewChain(primary, legacy...)
+	}
+
+	if conf.Pepper.isEnabled() {
+		return pwhash.NewPeppered(h, conf.Pepper.Current, conf.Pepper.Peppers)
+	}
+	return h, nil
+}
+
+// defaultQueueTimeout is used if the hashing queue timeout isn't set
+const defaultQueueTimeout = 2 * time.Second
+
+// initHasher builds the app hasher once and bounds the number of concurrently hashed passwords
+func (a *AppConfig) initHasher() error {
+	h, err := a.Hash.newPwHasher()
+	if err != nil {
+		return err
+	}
+
+	conf := a.Hash.Pool
+	size := runtime.NumCPU()
+	if conf.Size != 0 || conf.MemoryBudget != 0 {
+		size = conf.Size
+		if conf.MemoryBudget != 0 {
+			memSize := pwhash.PoolSize(conf.MemoryBudget, pwhash.MemoryCost(h))
+			if size == 0 || memSize < size {
+				size = memSize
+			}
+		}
+	}
+
+	timeout := defaultQueueTimeout
+	if conf.QueueTimeout != 0 {
+		timeout = time.Duration(conf.QueueTimeout) * time.Millisecond
+	}
+
+	a.Hash.Hasher = pwhash.NewPooled(h, pwhash.NewPool(size, timeout))
+	return nil
+}
+
+// placeholderSecret is the secret of the example config, which must be replaced
+const placeholderSecret = "change-me"
+
+// minSecretLen is the minimum length of the signing secrets in bytes
+const minSecretLen = 32
+
+// checkSecret returns error if the secret is still the placeholder or is too short to resist guessing
+func checkSecret(secret string) error {
+	if secret == placeholderSecret {
+		return errors.New("secret is the placeholder of the example config, replace it")
+	}
+	if len(secret) < minSecretLen {
+		return fmt.Errorf("secret must be at least %d bytes long", minSecretLen)
+	}
+	return nil
+}
+
+// initSecrets loads the signing secrets and checks them before anything is created in the storages
+func (a *AppConfig) initSecrets() error {
+	devicesConf := &a.Main.AuthN.MFA.TrustedDevices
+	if a.Main.AuthN.MFA.isEnabled() && devicesConf.IsEnabled {
+		if devicesConf.SecretEnv != "" {
+			if devicesConf.Secret != "" {
+				return errors.New("trusted devices: only one of secret and secret_env can be set")
+			}
+			devicesConf.Secret = strings.TrimSpace(os.Getenv(devicesConf.SecretEnv))
+		}
+
+		if err := checkSecret(devicesConf.Secret); err != nil {
+			return fmt.Errorf("trusted devices: %v", err)
+		}
+	}
+
+	// the administrative api is disabled without the token
+	if a.Admin.isEnabled() {
+		if err := checkSecret(a.Admin.Token); err != nil {
+			return fmt.Errorf("admin: token: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// minPepperLen is the minimum length of the pepper secret in bytes
+const minPepperLen = 32
+
+func (a *AppConfig) initPepper() error {
+	conf := &a.Hash.Pepper
+	if !conf.isEnabled() {
+		return nil
+	}
+
+	conf.Peppers = make(map[string][]byte, len(conf.Keys))
+	for _, key := range conf.Keys {
+		var secret []byte
+
+		switch {
+		case key.File != "" && key.Env != "":
+			return fmt.Errorf("pepper %s: only one of file and env can be set", key.ID)
+		case key.File != "":
+			data, err := ioutil.ReadFile(key.File)
+			if err != nil {
+				return fmt.Errorf("pepper %s: %v", key.ID, err)
+			}
+			secret = bytes.TrimSpace(data)
+		case key.Env != "":
+			secret = []byte(strings.TrimSpace(os.Getenv(key.Env)))
+		}
+
+		if len(secret) < minPepperLen {
+			return fmt.Errorf("pepper %s: secret must be at least %d bytes long", key.ID, minPepperLen)
+		}
+
+		if _, ok := conf.Peppers[key.ID]; ok {
+			return fmt.Errorf("pepper %s: duplicate id", key.ID)
+		}
+		conf.Peppers[key.ID] = secret
+	}
+
+	// checks the current pepper and ids
+	_, err := a.Hash.newPwHasher()
+	return err
+}
+
+// isEnabled checks whether passwords are peppered
+func (conf PepperConfig) isEnabled() bool {
+	return conf.Current != ""
+}
@@ -1,7 +1,14 @@
 package main
 
 import (
+	"bytes"
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+	"gouth/storage"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -47,3 +54,299 @@ func Test_ProjectConfig_Init(t *testing.T) {
 	usersStorage = conf.Apps["two"].StorageByFeature["users"]
 	assert.NoError(t, usersStorage.Ping())
 }
+
+func Test_ProjectConfig_Init_Memory(t *testing.T) {
+	conf := ProjectConfig{}
+
+	yamlContent := []byte(`
+        api_version: "0.1"
+        apps:
+          mem:
+            path_prefix: "/mem"
+            storages:
+              "main db":
+                connection_url: "memory://config_test"
+            main:
+              user_collection:
+                storage: "main db"
+                name: "users"
+                pk: "id"
+                user_unique: "username"
+                user_confirm: "password"
+              authN:
+                password_based:
+                  lockout:
+                    storage: "main db"
+                    collection:
+                      name: "login_attempts"
+                      pk: "key"
+                mfa:
+                  storage: "main db"
+                  recovery_codes:
+                    collection:
+                      name: "recovery_codes"
+                      pk: "id"
+              authZ:
+                cookie:
+                  storage: "main db"
+            rate_limits:
+              storage: "main db"
+            hasher:
+              alg: "pbkdf2"
+              settings:
+                iterations: 1
+                salt_length: 16
+                key_length: 32
+                func: "sha256"
+                allow_weak: true`)
+	conf.Init(yamlContent)
+	usersStorage := conf.Apps["mem"].StorageByFeature["users"]
+	assert.NoError(t, usersStorage.Ping())
+	defer usersStorage.Close()
+
+	isExist, err := usersStorage.IsCollExists(conf.Apps["mem"].Main.UserColl.ToCollConfig())
+	assert.NoError(t, err)
+	assert.True(t, isExist)
+}
+
+func Test_ProjectConfig_Migrate(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	yamlContent := []byte(`
+        api_version: "0.1"
+        apps:
+          one:
+            storages:
+              "main db":
+                connection_url: "sqlite://` + dbPath + `"
+            main:
+              user_collection:
+                storage: "main db"
+                name: "users"
+                pk: "id"
+                user_unique: "username"
+                user_confirm: "password"`)
+
+	out := &bytes.Buffer{}
+	conf := ProjectConfig{}
+	assert.NoError(t, conf.Migrate(yamlContent, storage.MigrateOptions{Target: storage.LatestVersion, DryRun: true, Out: out}))
+	assert.Contains(t, out.String(), "-- users: 0 -> 1")
+	assert.Contains(t, out.String(), `create table if not exists "users"`)
+
+	conf = ProjectConfig{}
+	assert.NoError(t, conf.Migrate(yamlContent, storage.MigrateOptions{Target: storage.LatestVersion}))
+
+	// the app starts with the migrated collection
+	conf = ProjectConfig{}
+	conf.Init(append(yamlContent, []byte(`
+              authZ:
+                cookie:
+                  storage: "main db"
+            hasher:
+              alg: "pbkdf2"
+              settings:
+                iterations: 1
+                salt_length: 16
+                key_length: 32
+                func: "sha256"
+                allow_weak: true`)...))
+	usersStorage := conf.Apps["one"].StorageByFeature["users"]
+	defer usersStorage.Close()
+
+	_, err := usersStorage.InsertUser(*conf.Apps["one"].Main.UserColl, *storage.NewInsertUserData("john", "hash"))
+	assert.NoError(t, err)
+
+	out.Reset()
+	conf = ProjectConfig{}
+	err = conf.Migrate(yamlContent, storage.MigrateOptions{Target: 0, DryRun: true, Out: out})
+	assert.EqualError(t, err, "app one: migrations: 1 create_user_collection of users can't be reverted")
+	assert.Empty(t, out.String())
+}
+
+func Test_AppConfig_initPepper(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pepper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	keyFile := filepath.Join(dir, "pepper.key")
+	if err := ioutil.WriteFile(keyFile, []byte("0123456789abcdef0123456789abcdef\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("TEST_PEPPER_OLD", "fedcba9876543210fedcba9876543210")
+	defer os.Unsetenv("TEST_PEPPER_OLD")
+
+	app := AppConfig{Hash: HashConfig{
+		AlgName:     "pbkdf2",
+		RawHashConf: map[string]interface{}{"iterations": 1, "salt_length": 16, "key_length": 32, "func": "sha256", "allow_weak": true},
+		Pepper: PepperConfig{
+			Current: "new",
+			Keys: []PepperKeyConfig{
+				{ID: "new", File: keyFile},
+				{ID: "old", Env: "TEST_PEPPER_OLD"},
+			},
+		},
+	}}
+	assert.NoError(t, app.initPepper())
+	assert.Equal(t, []byte("0123456789abcdef0123456789abcdef"), app.Hash.Pepper.Peppers["new"])
+
+	h, err := app.Hash.newPwHasher()
+	assert.NoError(t, err)
+
+	hash, err := h.HashPw("qwerty")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$pepper$new$pbkdf2_sha256$"))
+
+	app.Hash.Pepper.Keys[1].Env = "TEST_PEPPER_MISSING"
+	assert.Error(t, app.initPepper())
+
+	app.Hash.Pepper.Keys[1].Env = "TEST_PEPPER_OLD"
+	app.Hash.Pepper.Current = "other"
+	assert.Error(t, app.initPepper())
+}
+
+func Test_AppConfig_initHasher(t *testing.T) {
+	rawConf := map[string]interface{}{
+		"type": "argon2id", "iterations": 2, "parallelism": 1,
+		"salt_length": 16, "key_length": 32, "memory": 19456,
+	}
+
+	tests := []struct {
+		name string
+		pool HashPoolConfig
+		want int
+	}{
+		{name: "memory budget", pool: HashPoolConfig{MemoryBudget: 65536}, want: 3},
+		{name: "size below budget", pool: HashPoolConfig{Size: 2, MemoryBudget: 65536}, want: 2},
+		{name: "size", pool: HashPoolConfig{Size: 5}, want: 5},
+		{name: "budget below one hash", pool: HashPoolConfig{MemoryBudget: 1024}, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := AppConfig{Hash: HashConfig{AlgName: "argon2", RawHashConf: rawConf, Pool: tt.pool}}
+			if assert.NoError(t, app.initHasher()) {
+				assert.Equal(t, tt.want, app.Hash.Hasher.Stats().Size)
+			}
+		})
+	}
+}
+
+func Test_HashConfig_newPwHasher_VerifyOnly(t *testing.T) {
+	for _, alg := range []string{"django", "werkzeug", "aspnet_identity", "phc"} {
+		conf := HashConfig{AlgName: alg}
+		_, err := conf.newPwHasher()
+		assert.EqualError(t, err, "hasher "+alg+" only verifies imported hashes, it can be used as legacy hasher only")
+
+		conf = HashConfig{
+			AlgName:     "pbkdf2",
+			RawHashConf: map[string]interface{}{"iterations": 1, "salt_length": 16, "key_length": 32, "func": "sha256", "allow_weak": true},
+			Legacy:      []HashConfig{{AlgName: alg}},
+		}
+		_, err = conf.newPwHasher()
+		assert.NoError(t, err, alg)
+	}
+}
+
+func Test_ProjectConfig_Migrate_PkType(t *testing.T) {
+	yamlContent := func(pkType string) []byte {
+		return []byte(`
+        api_version: "0.1"
+        apps:
+          one:
+            storages:
+              "main db":
+                connection_url: "sqlite://` + filepath.Join(t.TempDir(), "test.db") + `"
+            main:
+              user_collection:
+                storage: "main db"
+                name: "users"
+                pk: "id"
+                pk_type: "` + pkType + `"
+                user_unique: "username"
+                user_confirm: "password"`)
+	}
+
+	out := &bytes.Buffer{}
+	conf := ProjectConfig{}
+	assert.NoError(t, conf.Migrate(yamlContent(storage.PkULID), storage.MigrateOptions{Target: storage.LatestVersion, DryRun: true, Out: out}))
+	assert.Contains(t, out.String(), `"id" text primary key`)
+
+	conf = ProjectConfig{}
+	err := conf.Migrate(yamlContent("uuid"), storage.MigrateOptions{Target: storage.LatestVersion, DryRun: true, Out: out})
+	assert.EqualError(t, err, `app one user collection: unknown pk type "uuid"`)
+}
+
+func Test_AppConfig_initUserColl_PkType(t *testing.T) {
+	usersStorage, err := storage.Open(storage.RawStorageConfig{
+		"connection_url": "sqlite://" + filepath.Join(t.TempDir(), "test.db"),
+	}, []string{"users"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer usersStorage.Close()
+
+	app := AppConfig{StorageByFeature: map[string]storage.ConnSession{"users": usersStorage}}
+	app.setCollDefaults()
+	assert.NoError(t, app.initUserColl())
+
+	// the existing collection isn't changed by the migrations, so the changed pk type is rejected
+	app.Main.UserColl.PkType = storage.PkULID
+	assert.EqualError(t, app.initUserColl(), "user collection users: pk column is integer, which doesn't fit pk type ulid")
+}
+
+func Test_ProjectConfig_Example(t *testing.T) {
+	data, err := ioutil.ReadFile("config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := ProjectConfig{}
+	assert.NoError(t, yaml.Unmarshal(data, &conf))
+	assert.Contains(t, conf.Apps, "two")
+	assert.Equal(t, "pbkdf2", conf.Apps["two"].Hash.AlgName)
+
+	// the storages of the example aren't available in tests, so only the secrets are checked
+	os.Setenv("AUREOLE_TRUSTED_DEVICES_SECRET", "0123456789abcdef0123456789abcdef")
+	defer os.Unsetenv("AUREOLE_TRUSTED_DEVICES_SECRET")
+
+	for name, app := range conf.Apps {
+		assert.NoError(t, app.initSecrets(), name)
+	}
+}
+
+func Test_checkSecret(t *testing.T) {
+	assert.EqualError(t, checkSecret("change-me"), "secret is the placeholder of the example config, replace it")
+	assert.EqualError(t, checkSecret(""), "secret must be at least 32 bytes long")
+	assert.EqualError(t, checkSecret("short secret"), "secret must be at least 32 bytes long")
+	assert.NoError(t, checkSecret("0123456789abcdef0123456789abcdef"))
+}
+
+func Test_AppConfig_initSecrets(t *testing.T) {
+	app := &AppConfig{}
+	app.Main.AuthN.MFA.StorageName = "main db"
+	app.Main.AuthN.MFA.TrustedDevices.IsEnabled = true
+
+	app.Main.AuthN.MFA.TrustedDevices.Secret = "change-me"
+	assert.EqualError(t, app.initSecrets(), "trusted devices: secret is the placeholder of the example config, replace it")
+
+	app.Main.AuthN.MFA.TrustedDevices.SecretEnv = "TEST_TRUSTED_DEVICES_SECRET"
+	assert.EqualError(t, app.initSecrets(), "trusted devices: only one of secret and secret_env can be set")
+
+	app.Main.AuthN.MFA.TrustedDevices.Secret = ""
+	assert.EqualError(t, app.initSecrets(), "trusted devices: secret must be at least 32 bytes long")
+
+	os.Setenv("TEST_TRUSTED_DEVICES_SECRET", "0123456789abcdef0123456789abcdef")
+	defer os.Unsetenv("TEST_TRUSTED_DEVICES_SECRET")
+	assert.NoError(t, app.initSecrets())
+	assert.Equal(t, "0123456789abcdef0123456789abcdef", app.Main.AuthN.MFA.TrustedDevices.Secret)
+
+	app = &AppConfig{}
+	app.Admin.Token = "change-me"
+	assert.EqualError(t, app.initSecrets(), "admin: token: secret is the placeholder of the example config, replace it")
+
+	app.Admin.Token = "short token"
+	assert.EqualError(t, app.initSecrets(), "admin: token: secret must be at least 32 bytes long")
+
+	app.Admin.Token = "0123456789abcdef0123456789abcdef"
+	assert.NoError(t, app.initSecrets())
+}
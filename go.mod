@@ -3,10 +3,17 @@ module gouth
 go 1.15
 
 require (
+	github.com/alicebob/miniredis/v2 v2.14.3
+	github.com/dolthub/go-mysql-server v0.9.0
+	github.com/fxamacker/cbor/v2 v2.2.0
 	github.com/gin-gonic/gin v1.6.3
+	github.com/go-redis/redis/v8 v8.11.4
+	github.com/go-sql-driver/mysql v1.5.0
 	github.com/jackc/pgx/v4 v4.10.1
 	github.com/kr/pretty v0.1.0
 	github.com/lestrrat-go/jwx v1.1.1
+	github.com/mattn/go-sqlite3 v1.14.6
+	github.com/nbutton23/zxcvbn-go v0.0.0-20210217022336-fa2cb2858354
 	github.com/pkg/errors v0.9.1
 	github.com/sherifabdlnaby/configuro v0.0.2 // indirect
 	github.com/stretchr/testify v1.6.1
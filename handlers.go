@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"gouth/jwt"
 	"gouth/pwhash"
 	"gouth/storage"
+	"log"
 	"net/http"
 	"strings"
 )
@@ -58,25 +61,21 @@ func registerHandler(app AppConfig) func(c *gin.Context) {
 			return
 		}
 
-		// TODO: add a user existence check
-
-		h, err := pwhash.New(app.Hash.AlgName, &app.Hash.RawHashConf)
-		if err != nil {
-			c.AbortWithStatusJSON(
-				http.StatusInternalServerError,
-				gin.H{"error": err.Error()})
+		if !checkPasswordPolicy(c, app, userConfirm, userUnique) {
 			return
 		}
 
+		// TODO: add a user existence check
+
+		h := app.hasherFor(c.Request.Context())
+
 		pwHash, err := h.HashPw(userConfirm)
 		if err != nil {
-			c.AbortWithStatusJSON(
-				http.StatusInternalServerError,
-				gin.H{"error": err.Error()})
+			abortHashError(c, err)
 			return
 		}
 
-		usersStorage := app.StorageByFeature["users"]
+		usersStorage := app.storageFor(c.Request.Context(), "users")
 		res, err := usersStorage.InsertUser(
 			*app.Main.UserColl,
 			*storage.NewInsertUserData(userUnique, pwHash),
@@ -89,9 +88,13 @@ func registerHandler(app AppConfig) func(c *gin.Context) {
 		}
 
 		if regConfig.LoginAfter {
-			token := jwt.IssueToken()
+			user := map[string]interface{}{
+				app.Main.UserColl.Pk:         res,
+				app.Main.UserColl.UserUnique: userUnique,
+			}
+			token := issueToken(tokenClaims(app.Main.AuthZ.Jwt.Payload, user))
 
-			c.JSON(http.StatusOK, gin.H{"token": token})
+			c.JSON(http.StatusOK, gin.H{"id": res, "token": token})
 			return
 		}
 
@@ -99,6 +102,21 @@ func registerHandler(app AppConfig) func(c *gin.Context) {
 	}
 }
 
+// issueToken issues the token with the given claims, it's replaced in tests to check the claims
+var issueToken = jwt.IssueToken
+
+// tokenClaims maps fields of the user to the token claims by the payload config,
+// e.g. "$.userID": "id" sets the userID claim to the user pk. Unknown fields are skipped
+func tokenClaims(payload map[string]string, user map[string]interface{}) map[string]interface{} {
+	claims := map[string]interface{}{}
+	for path, field := range payload {
+		if v, ok := user[field]; ok {
+			claims[strings.TrimPrefix(path, "$.")] = v
+		}
+	}
+	return claims
+}
+
 func loginHandler(app AppConfig) func(c *gin.Context) {
 	return func(c *gin.Context) {
 		var authData interface{}
@@ -110,78 +128,169 @@ func loginHandler(app AppConfig) func(c *gin.Context) {
 			return
 		}
 
-		authConf := app.Main.AuthN
-
-		userUnique, err := GetJSONPath(authConf.PasswdBased.UserUnique, authData)
-		if err != nil {
-			c.AbortWithStatusJSON(
-				http.StatusBadRequest,
-				gin.H{"error": "user_unique didn't passed"},
-			)
+		if _, ok := authenticate(c, app, authData); !ok {
 			return
 		}
-		if userUnique, ok := userUnique.(string); ok {
-			if strings.TrimSpace(userUnique) == "" {
-				c.AbortWithStatusJSON(
-					http.StatusBadRequest,
-					gin.H{"error": "user_unique can't be blank"},
-				)
-				return
-			}
-		}
 
-		rawUserConfirm, err := GetJSONPath(authConf.PasswdBased.UserConfirm, authData)
-		if err != nil {
-			c.AbortWithStatusJSON(
-				http.StatusBadRequest,
-				gin.H{"error": "user_confirm didn't passed"},
-			)
-			return
-		}
-		userConfirm := rawUserConfirm.(string)
-		if strings.TrimSpace(userConfirm) == "" {
+		token := issueToken(nil)
+		c.JSON(http.StatusOK, gin.H{"token": token})
+	}
+}
+
+// mfaCheck tells authenticateWith what to do with the second factor
+type mfaCheck int
+
+const (
+	// mfaVerify requires the second factor, the recovery code is used up
+	mfaVerify mfaCheck = iota
+	// mfaKeepCode requires the second factor, the recovery code is left unused,
+	// e.g. it's replaced right away or the request only manages the second factors
+	mfaKeepCode
+	// mfaSkip checks the primary credential only
+	mfaSkip
+)
+
+// authenticate checks user credentials and the second factor if it's enabled.
+// It aborts the request and returns false if authentication fails
+func authenticate(c *gin.Context, app AppConfig, authData interface{}) (interface{}, bool) {
+	return authenticateWith(c, app, authData, mfaVerify)
+}
+
+// authenticateWith checks user credentials and handles the second factor as the given check says.
+// It aborts the request and returns false if authentication fails
+func authenticateWith(c *gin.Context, app AppConfig, authData interface{}, check mfaCheck) (interface{}, bool) {
+	authConf := app.Main.AuthN
+
+	userUnique, err := GetJSONPath(authConf.PasswdBased.UserUnique, authData)
+	if err != nil {
+		c.AbortWithStatusJSON(
+			http.StatusBadRequest,
+			gin.H{"error": "user_unique didn't passed"},
+		)
+		return nil, false
+	}
+	if userUnique, ok := userUnique.(string); ok {
+		if strings.TrimSpace(userUnique) == "" {
 			c.AbortWithStatusJSON(
 				http.StatusBadRequest,
-				gin.H{"error": "user_confirm can't be blank"},
+				gin.H{"error": "user_unique can't be blank"},
 			)
-			return
+			return nil, false
 		}
+	}
 
-		// TODO: add a user existence check
+	rawUserConfirm, err := GetJSONPath(authConf.PasswdBased.UserConfirm, authData)
+	if err != nil {
+		c.AbortWithStatusJSON(
+			http.StatusBadRequest,
+			gin.H{"error": "user_confirm didn't passed"},
+		)
+		return nil, false
+	}
+	userConfirm := rawUserConfirm.(string)
+	if strings.TrimSpace(userConfirm) == "" {
+		c.AbortWithStatusJSON(
+			http.StatusBadRequest,
+			gin.H{"error": "user_confirm can't be blank"},
+		)
+		return nil, false
+	}
 
-		h, err := pwhash.New(app.Hash.AlgName, &app.Hash.RawHashConf)
-		if err != nil {
-			c.AbortWithStatusJSON(
-				http.StatusInternalServerError,
-				gin.H{"error": err.Error()})
-			return
-		}
+	failures, ok := reserveLoginAttempt(c, app, userUnique)
+	if !ok {
+		return nil, false
+	}
 
-		usersStorage := app.StorageByFeature["users"]
-		pw, err := usersStorage.GetUserPassword(*app.Main.UserColl, userUnique)
-		if err != nil {
-			c.AbortWithStatusJSON(
-				http.StatusInternalServerError,
-				gin.H{"error": err.Error()})
-			return
+	h := app.hasherFor(c.Request.Context())
+
+	usersStorage := app.storageFor(c.Request.Context(), "users")
+	pw, err := usersStorage.GetUserPassword(*app.Main.UserColl, userUnique)
+	if err == storage.ErrUserNotFound {
+		// the password is hashed anyway, so the response time doesn't tell whether the user exists
+		if _, err := h.HashPw(userConfirm); err != nil {
+			abortHashError(c, err)
+			return nil, false
 		}
 
-		isMatch, err := h.ComparePw(userConfirm, pw.(string))
-		if err != nil {
+		abortLoginFailure(c, app, userUnique, failures)
+		return nil, false
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(
+			http.StatusInternalServerError,
+			gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	isMatch, err := h.ComparePw(userConfirm, pw.(string))
+	if err != nil {
+		abortHashError(c, err)
+		return nil, false
+	}
+
+	if !isMatch {
+		abortLoginFailure(c, app, userUnique, failures)
+		return nil, false
+	}
+
+	if check == mfaSkip {
+		// the second factor isn't checked, so the failures of the account are kept
+		if err := releaseLoginAttempt(c, app, userUnique); err != nil {
 			c.AbortWithStatusJSON(
 				http.StatusInternalServerError,
 				gin.H{"error": err.Error()})
-			return
+			return nil, false
 		}
+		return userUnique, true
+	}
 
-		if isMatch {
-			token := jwt.IssueToken()
-			c.JSON(http.StatusOK, gin.H{"token": token})
-		} else {
-			c.AbortWithStatusJSON(
-				http.StatusUnauthorized,
-				gin.H{"error": "invalid data"})
-			return
-		}
+	if !verifyMFA(c, app, h, userUnique, authData, failures, check == mfaVerify) {
+		return nil, false
 	}
+
+	if err := resetLoginAttempts(c, app, userUnique); err != nil {
+		c.AbortWithStatusJSON(
+			http.StatusInternalServerError,
+			gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	// the user is already authenticated, so failed rehash mustn't break the login
+	if err := rehashPassword(c.Request.Context(), app, h, userUnique, userConfirm, pw.(string)); err != nil {
+		log.Printf("rehash password: %v", err)
+	}
+
+	return userUnique, true
+}
+
+// abortHashError aborts the request with 503 if all hashing slots are busy and with 500 otherwise
+func abortHashError(c *gin.Context, err error) {
+	if errors.Is(err, pwhash.ErrBusy) {
+		c.Header("Retry-After", "1")
+		c.AbortWithStatusJSON(
+			http.StatusServiceUnavailable,
+			gin.H{"error": err.Error()})
+		return
+	}
+
+	c.AbortWithStatusJSON(
+		http.StatusInternalServerError,
+		gin.H{"error": err.Error()})
+}
+
+// rehashPassword hashes the password again and updates it in the storage
+// if the stored hash was created with outdated hasher settings
+func rehashPassword(ctx context.Context, app AppConfig, h pwhash.PwHasher, userUnique interface{}, pw, hash string) error {
+	needsRehash, err := h.NeedsRehash(hash)
+	if err != nil || !needsRehash {
+		return err
+	}
+
+	newHash, err := h.HashPw(pw)
+	if err != nil {
+		return err
+	}
+
+	usersStorage := app.storageFor(ctx, "users")
+	return usersStorage.UpdateUserPassword(*app.Main.UserColl, userUnique, newHash)
 }
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_tokenClaims(t *testing.T) {
+	payload := map[string]string{
+		"$.userID":    "id",
+		"$.username":  "username",
+		"$.userPosts": "posts",
+	}
+	user := map[string]interface{}{
+		"id":       "01ARYZ6S41TSV4RRFFQ69G5FAV",
+		"username": "john",
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"userID":   "01ARYZ6S41TSV4RRFFQ69G5FAV",
+		"username": "john",
+	}, tokenClaims(payload, user))
+}
+
+func Test_registerHandler_GeneratedPk(t *testing.T) {
+	conf := ProjectConfig{}
+	conf.Init([]byte(`
+        api_version: "0.1"
+        apps:
+          mem:
+            path_prefix: "/mem"
+            storages:
+              "main db":
+                connection_url: "memory://` + t.Name() + `"
+            main:
+              user_collection:
+                storage: "main db"
+                name: "users"
+                pk: "id"
+                pk_type: "ulid"
+                user_unique: "username"
+                user_confirm: "password"
+              authN:
+                password_based:
+                  user_unique: "{$.name}"
+                  user_confirm: "{$.passwd}"
+              authZ:
+                cookie:
+                  storage: "main db"
+                jwt:
+                  payload:
+                    "$.userID": "id"
+              register:
+                login_after: true
+                fields:
+                  user_unique: "{$.name}"
+                  user_confirm: "{$.passwd}"
+            hasher:
+              alg: "pbkdf2"
+              settings:
+                iterations: 1
+                salt_length: 16
+                key_length: 32
+                func: "sha256"
+                allow_weak: true`))
+
+	app := conf.Apps["mem"]
+	t.Cleanup(func() { app.StorageByFeature["users"].Close() })
+
+	var claims map[string]interface{}
+	defer func(f func(map[string]interface{}) string) { issueToken = f }(issueToken)
+	issueToken = func(c map[string]interface{}) string {
+		claims = c
+		return "token"
+	}
+
+	r := gin.New()
+	r.POST("/register", registerHandler(app))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(`{"name": "john", "passwd": "secret"}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var body struct {
+		ID    string `json:"id"`
+		Token string `json:"token"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Len(t, body.ID, 26)
+	assert.Equal(t, "token", body.Token)
+	assert.Equal(t, map[string]interface{}{"userID": body.ID}, claims)
+}
@@ -11,8 +11,9 @@ package jwt
 //	"github.com/lestrrat-go/jwx/jwt"
 //)
 
+// IssueToken issues the token with the given claims
 // examples: https://github.com/lestrrat-go/jwx/blob/main/examples/jwt_example_test.go
-func IssueToken() string {
+func IssueToken(claims map[string]interface{}) string {
 	return ""
 }
 
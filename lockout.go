@@ -0,0 +1,296 @@
+package main
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gouth/storage"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// unlockHandler removes the lock and failed login attempts of the account or the source ip
+func unlockHandler(app AppConfig) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var unlockData struct {
+			UserUnique interface{} `json:"user_unique"`
+			IP         string      `json:"ip"`
+		}
+
+		if err := c.BindJSON(&unlockData); err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusBadRequest,
+				gin.H{"error": "invalid json"})
+			return
+		}
+
+		var key string
+		if unlockData.UserUnique != nil {
+			key = userAttemptKey(unlockData.UserUnique)
+		} else if unlockData.IP != "" {
+			key = ipAttemptKey(unlockData.IP)
+		} else {
+			c.AbortWithStatusJSON(
+				http.StatusBadRequest,
+				gin.H{"error": "user_unique or ip is required"})
+			return
+		}
+
+		conf := app.Main.AuthN.PasswdBased.Lockout
+		attemptsStorage := app.storageFor(c.Request.Context(), "login_attempts").(storage.LoginAttempts)
+		if err := attemptsStorage.ResetLoginAttempts(*conf.Coll, key); err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusInternalServerError,
+				gin.H{"error": err.Error()})
+			return
+		}
+
+		auditEvent("login_unlocked", map[string]interface{}{"key": key, "by": "admin", "ip": c.ClientIP()})
+		c.JSON(http.StatusOK, gin.H{"unlocked": key})
+	}
+}
+
+// reserveLoginAttempt counts the login attempt as failure for the account and the source ip
+// before the credentials are checked, so parallel guesses can't pass the lock or the progressive delay together.
+// It aborts the request if the account or the source ip is locked, or the delay after the last failure
+// hasn't passed yet. Nothing is counted then, so a locked source ip can't lock the account and vice versa.
+// Expired locks are removed. Failures by key including this attempt are returned
+func reserveLoginAttempt(c *gin.Context, app AppConfig, userUnique interface{}) (map[string]int, bool) {
+	conf := app.Main.AuthN.PasswdBased.Lockout
+	if !conf.isEnabled() {
+		return nil, true
+	}
+
+	attemptsStorage := app.storageFor(c.Request.Context(), "login_attempts").(storage.LoginAttempts)
+	now := time.Now()
+	limits := attemptLimits(c, conf, userUnique)
+	prevs := make([]*storage.LoginAttempt, len(limits))
+
+	for i, limit := range limits {
+		prev, err := attemptsStorage.GetLoginAttempt(*conf.Coll, limit.key)
+		if err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusInternalServerError,
+				gin.H{"error": err.Error()})
+			return nil, false
+		}
+
+		if prev != nil {
+			if wait := retryAfter(conf, *prev, now); wait > 0 {
+				abortLoginAttempt(c, now.Before(prev.LockedUntil), wait)
+				return nil, false
+			}
+		}
+		prevs[i] = prev
+	}
+
+	failures := map[string]int{}
+	for i, limit := range limits {
+		prev := prevs[i]
+		if prev != nil && !prev.LockedUntil.IsZero() {
+			if err := attemptsStorage.ResetLoginAttempts(*conf.Coll, limit.key); err != nil {
+				c.AbortWithStatusJSON(
+					http.StatusInternalServerError,
+					gin.H{"error": err.Error()})
+				return nil, false
+			}
+			auditEvent("login_unlocked", map[string]interface{}{"key": limit.key, "by": "cooldown"})
+			prev = nil
+		}
+
+		attempt, err := attemptsStorage.RegisterLoginFailure(*conf.Coll, limit.key, time.Duration(conf.Window)*time.Second)
+		if err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusInternalServerError,
+				gin.H{"error": err.Error()})
+			return nil, false
+		}
+		failures[limit.key] = attempt.Failures
+
+		if now.Before(attempt.LockedUntil) {
+			abortLoginAttempt(c, true, attempt.LockedUntil.Sub(now))
+			return nil, false
+		}
+
+		// the parallel attempts have used up the failures left before the lock
+		if attempt.Failures > limit.maxFailures {
+			lockedUntil, err := lockLoginAttempt(attemptsStorage, conf, limit.key, attempt.Failures)
+			if err != nil {
+				c.AbortWithStatusJSON(
+					http.StatusInternalServerError,
+					gin.H{"error": err.Error()})
+				return nil, false
+			}
+			abortLoginAttempt(c, true, lockedUntil.Sub(now))
+			return nil, false
+		}
+
+		// the attempts reserved after the check have just failed, so this one has to wait the whole delay
+		expected := 1
+		if prev != nil {
+			expected = prev.Failures + 1
+		}
+		if delay := failureDelay(conf, attempt.Failures-1); attempt.Failures > expected && delay > 0 {
+			abortLoginAttempt(c, false, delay)
+			return nil, false
+		}
+	}
+
+	return failures, true
+}
+
+// registerLoginFailure records that the reserved login attempt has failed
+// and locks the account and the source ip, if there are too many failures
+func registerLoginFailure(c *gin.Context, app AppConfig, userUnique interface{}, failures map[string]int) error {
+	conf := app.Main.AuthN.PasswdBased.Lockout
+	if !conf.isEnabled() {
+		return nil
+	}
+
+	auditEvent("login_failed", map[string]interface{}{"user_unique": userUnique, "ip": c.ClientIP()})
+
+	attemptsStorage := app.storageFor(c.Request.Context(), "login_attempts").(storage.LoginAttempts)
+	for _, limit := range attemptLimits(c, conf, userUnique) {
+		if failures[limit.key] >= limit.maxFailures {
+			if _, err := lockLoginAttempt(attemptsStorage, conf, limit.key, failures[limit.key]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// abortLoginFailure registers failure of the reserved login attempt and aborts the request with 401
+func abortLoginFailure(c *gin.Context, app AppConfig, userUnique interface{}, failures map[string]int) {
+	if err := registerLoginFailure(c, app, userUnique, failures); err != nil {
+		c.AbortWithStatusJSON(
+			http.StatusInternalServerError,
+			gin.H{"error": err.Error()})
+		return
+	}
+
+	c.AbortWithStatusJSON(
+		http.StatusUnauthorized,
+		gin.H{"error": "invalid data"})
+}
+
+// releaseLoginAttempt forgets the reserved login attempt, if the credentials are right,
+// but the login isn't finished yet, e.g. the second factor is required
+func releaseLoginAttempt(c *gin.Context, app AppConfig, userUnique interface{}) error {
+	conf := app.Main.AuthN.PasswdBased.Lockout
+	if !conf.isEnabled() {
+		return nil
+	}
+
+	attemptsStorage := app.storageFor(c.Request.Context(), "login_attempts").(storage.LoginAttempts)
+	for _, limit := range attemptLimits(c, conf, userUnique) {
+		if err := attemptsStorage.ForgetLoginFailure(*conf.Coll, limit.key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resetLoginAttempts forgets failed login attempts of the account after successful login.
+// Failures of the source ip are shared with other accounts, so only the reserved attempt is forgotten
+func resetLoginAttempts(c *gin.Context, app AppConfig, userUnique interface{}) error {
+	conf := app.Main.AuthN.PasswdBased.Lockout
+	if !conf.isEnabled() {
+		return nil
+	}
+
+	attemptsStorage := app.storageFor(c.Request.Context(), "login_attempts").(storage.LoginAttempts)
+	if err := attemptsStorage.ResetLoginAttempts(*conf.Coll, userAttemptKey(userUnique)); err != nil {
+		return err
+	}
+	return attemptsStorage.ForgetLoginFailure(*conf.Coll, ipAttemptKey(c.ClientIP()))
+}
+
+// lockLoginAttempt locks the key for the configured duration
+func lockLoginAttempt(attemptsStorage storage.LoginAttempts, conf LockoutConfig, key string, failures int) (time.Time, error) {
+	lockedUntil := time.Now().Add(time.Duration(conf.Duration) * time.Second)
+	if err := attemptsStorage.LockLogin(*conf.Coll, key, lockedUntil); err != nil {
+		return time.Time{}, err
+	}
+
+	auditEvent("login_locked", map[string]interface{}{
+		"key":          key,
+		"failures":     failures,
+		"locked_until": lockedUntil,
+	})
+	return lockedUntil, nil
+}
+
+// attemptLimit is max failures of the account or the source ip
+type attemptLimit struct {
+	key         string
+	maxFailures int
+}
+
+// attemptLimits returns max failures of the account and the source ip, the account goes first.
+// The account is unknown, if the passkey is discoverable, so only the source ip is limited then
+func attemptLimits(c *gin.Context, conf LockoutConfig, userUnique interface{}) []attemptLimit {
+	limits := []attemptLimit{}
+	if userUnique != nil {
+		limits = append(limits, attemptLimit{key: userAttemptKey(userUnique), maxFailures: conf.MaxFailures})
+	}
+	return append(limits, attemptLimit{key: ipAttemptKey(c.ClientIP()), maxFailures: conf.IPMaxFailures})
+}
+
+// abortLoginAttempt aborts the request with 423 if the login is locked and with 429 otherwise
+func abortLoginAttempt(c *gin.Context, isLocked bool, wait time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+
+	if isLocked {
+		c.AbortWithStatusJSON(
+			http.StatusLocked,
+			gin.H{"error": "too many failed attempts, login is locked"})
+		return
+	}
+
+	c.AbortWithStatusJSON(
+		http.StatusTooManyRequests,
+		gin.H{"error": "too many failed attempts, try again later"})
+}
+
+// retryAfter returns how long the next login attempt has to wait.
+// It's either the rest of the lock, or the progressive delay after the last failure
+func retryAfter(conf LockoutConfig, attempt storage.LoginAttempt, now time.Time) time.Duration {
+	if now.Before(attempt.LockedUntil) {
+		return attempt.LockedUntil.Sub(now)
+	}
+
+	if !attempt.LockedUntil.IsZero() || len(conf.Delays) == 0 || attempt.Failures == 0 {
+		return 0
+	}
+
+	next := attempt.LastFailure.Add(failureDelay(conf, attempt.Failures))
+	if now.Before(next) {
+		return next.Sub(now)
+	}
+	return 0
+}
+
+// failureDelay returns the progressive delay after the given number of failures
+func failureDelay(conf LockoutConfig, failures int) time.Duration {
+	if len(conf.Delays) == 0 || failures <= 0 {
+		return 0
+	}
+
+	i := failures - 1
+	if i >= len(conf.Delays) {
+		i = len(conf.Delays) - 1
+	}
+	return time.Duration(conf.Delays[i]) * time.Second
+}
+
+func userAttemptKey(userUnique interface{}) string {
+	return "user:" + fmt.Sprint(userUnique)
+}
+
+func ipAttemptKey(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gouth/storage"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_retryAfter(t *testing.T) {
+	now := time.Now()
+	conf := LockoutConfig{Delays: []int{0, 2, 10}}
+
+	tests := []struct {
+		name    string
+		conf    LockoutConfig
+		attempt storage.LoginAttempt
+		want    time.Duration
+	}{
+		{
+			name:    "locked",
+			conf:    conf,
+			attempt: storage.LoginAttempt{Failures: 5, LastFailure: now, LockedUntil: now.Add(time.Minute)},
+			want:    time.Minute,
+		},
+		{
+			name:    "lock has expired",
+			conf:    conf,
+			attempt: storage.LoginAttempt{Failures: 5, LastFailure: now, LockedUntil: now.Add(-time.Second)},
+			want:    0,
+		},
+		{
+			name:    "first failure without delay",
+			conf:    conf,
+			attempt: storage.LoginAttempt{Failures: 1, LastFailure: now},
+			want:    0,
+		},
+		{
+			name:    "second failure",
+			conf:    conf,
+			attempt: storage.LoginAttempt{Failures: 2, LastFailure: now.Add(-time.Second)},
+			want:    time.Second,
+		},
+		{
+			name:    "delay has passed",
+			conf:    conf,
+			attempt: storage.LoginAttempt{Failures: 2, LastFailure: now.Add(-3 * time.Second)},
+			want:    0,
+		},
+		{
+			name:    "failures exceed delays",
+			conf:    conf,
+			attempt: storage.LoginAttempt{Failures: 4, LastFailure: now},
+			want:    10 * time.Second,
+		},
+		{
+			name:    "without delays",
+			conf:    LockoutConfig{},
+			attempt: storage.LoginAttempt{Failures: 4, LastFailure: now},
+			want:    0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, retryAfter(tt.conf, tt.attempt, now))
+		})
+	}
+}
+
+// newLockoutTestApp returns the app with the lockout and recovery codes in the memory storage
+func newLockoutTestApp(t *testing.T) AppConfig {
+	conf := ProjectConfig{}
+	conf.Init([]byte(`
+        api_version: "0.1"
+        apps:
+          mem:
+            path_prefix: "/mem"
+            storages:
+              "main db":
+                connection_url: "memory://` + t.Name() + `"
+            main:
+              user_collection:
+                storage: "main db"
+                name: "users"
+                pk: "id"
+                user_unique: "username"
+                user_confirm: "password"
+              authN:
+                password_based:
+                  user_unique: "{$.name}"
+                  user_confirm: "{$.passwd}"
+                  lockout:
+                    storage: "main db"
+                    max_failures: 3
+                mfa:
+                  storage: "main db"
+                  recovery_codes:
+                    code: "{$.recovery_code}"
+              authZ:
+                cookie:
+                  storage: "main db"
+            webauthn:
+              storage: "main db"
+              rp_id: "localhost"
+              origins:
+                - "http://localhost:8080"
+            hasher:
+              alg: "pbkdf2"
+              settings:
+                iterations: 1
+                salt_length: 16
+                key_length: 32
+                func: "sha256"
+                allow_weak: true`))
+
+	app := conf.Apps["mem"]
+	t.Cleanup(func() { app.StorageByFeature["users"].Close() })
+
+	for _, name := range []string{"john", "jane"} {
+		pwHash, err := app.Hash.Hasher.HashPw("secret")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = app.StorageByFeature["users"].InsertUser(*app.Main.UserColl, *storage.NewInsertUserData(name, pwHash))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return app
+}
+
+func login(r http.Handler, body string) int {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body)))
+	return w.Code
+}
+
+func userFailures(t *testing.T, app AppConfig, userUnique string) int {
+	return keyFailures(t, app, userAttemptKey(userUnique))
+}
+
+func keyFailures(t *testing.T, app AppConfig, key string) int {
+	conf := app.Main.AuthN.PasswdBased.Lockout
+	attempt, err := app.StorageByFeature["login_attempts"].(storage.LoginAttempts).GetLoginAttempt(*conf.Coll, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempt == nil {
+		return 0
+	}
+	return attempt.Failures
+}
+
+func Test_authenticate_Lockout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := newLockoutTestApp(t)
+
+	r := gin.New()
+	r.POST("/login", loginHandler(app))
+
+	// unknown users are counted like wrong passwords
+	assert.Equal(t, http.StatusUnauthorized, login(r, `{"name": "nobody", "passwd": "secret"}`))
+	assert.Equal(t, 1, userFailures(t, app, "nobody"))
+
+	// parallel guesses can't check more passwords than the lock allows
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		codes = map[int]int{}
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			code := login(r, `{"name": "john", "passwd": "wrong"}`)
+
+			mu.Lock()
+			codes[code]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 3, codes[http.StatusUnauthorized])
+	assert.Equal(t, 7, codes[http.StatusLocked])
+	assert.Equal(t, http.StatusLocked, login(r, `{"name": "john", "passwd": "secret"}`))
+
+	// successful login forgets the failures
+	assert.Equal(t, http.StatusOK, login(r, `{"name": "jane", "passwd": "secret"}`))
+	assert.Equal(t, 0, userFailures(t, app, "jane"))
+}
+
+func Test_authenticate_LockoutIsolation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := newLockoutTestApp(t)
+
+	r := gin.New()
+	r.POST("/login", loginHandler(app))
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusUnauthorized, login(r, `{"name": "john", "passwd": "wrong"}`))
+	}
+	ipKey := ipAttemptKey("192.0.2.1")
+	assert.Equal(t, 3, keyFailures(t, app, ipKey))
+
+	// attempts against the locked account don't use up the budget of the source ip
+	assert.Equal(t, http.StatusLocked, login(r, `{"name": "john", "passwd": "wrong"}`))
+	assert.Equal(t, 3, keyFailures(t, app, ipKey))
+
+	// attempts from the locked source ip don't count against the account
+	conf := app.Main.AuthN.PasswdBased.Lockout
+	attemptsStorage := app.StorageByFeature["login_attempts"].(storage.LoginAttempts)
+	assert.NoError(t, attemptsStorage.LockLogin(*conf.Coll, ipKey, time.Now().Add(time.Minute)))
+
+	assert.Equal(t, http.StatusLocked, login(r, `{"name": "jane", "passwd": "wrong"}`))
+	assert.Equal(t, 0, userFailures(t, app, "jane"))
+}
+
+func Test_authenticate_LockoutMFA(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := newLockoutTestApp(t)
+
+	r := gin.New()
+	r.POST("/login", loginHandler(app))
+
+	_, err := issueRecoveryCodes(context.Background(), app, app.Hash.Hasher, "jane")
+	assert.NoError(t, err)
+
+	// the right password without the second factor isn't a failure
+	assert.Equal(t, http.StatusUnauthorized, login(r, `{"name": "jane", "passwd": "secret"}`))
+	assert.Equal(t, 0, userFailures(t, app, "jane"))
+
+	assert.Equal(t, http.StatusUnauthorized, login(r, `{"name": "jane", "passwd": "secret", "recovery_code": "wrong"}`))
+	assert.Equal(t, 1, userFailures(t, app, "jane"))
+}
+
+func Test_webauthnLogin_Lockout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := newLockoutTestApp(t)
+
+	r := gin.New()
+	r.POST("/webauthn/login/begin", webauthnLoginBeginHandler(app))
+	r.POST("/webauthn/login/finish", webauthnLoginFinishHandler(app))
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return w
+	}
+
+	// the passkey is the only factor, so user verification is requested
+	w := post("/webauthn/login/begin", `{}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"userVerification":"required"`)
+
+	// unknown ceremony is counted as failure of the source ip
+	w = post("/webauthn/login/finish", `{"webauthn_session": "unknown", "webauthn": {}}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, 1, keyFailures(t, app, ipAttemptKey("192.0.2.1")))
+
+	// invalid assertion of the ceremony started for the account is counted for the account too
+	w = post("/webauthn/login/begin", `{"name": "john"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var begin struct {
+		Session string `json:"session"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &begin))
+
+	w = post("/webauthn/login/finish", `{"webauthn_session": "`+begin.Session+`", "webauthn": {"type": "public-key"}}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, 1, userFailures(t, app, "john"))
+	assert.Equal(t, 2, keyFailures(t, app, ipAttemptKey("192.0.2.1")))
+}
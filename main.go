@@ -1,18 +1,35 @@
 package main
 
 import (
+	"flag"
+	"gouth/storage"
 	"io/ioutil"
 	"log"
+	"os"
 )
 
 // conf is global object that holds all project level settings variables
 var conf ProjectConfig
 
 func main() {
+	migrate := flag.Bool("migrate", false, "apply migrations of the user collections and exit")
+	target := flag.Int("target", storage.LatestVersion, "version to migrate to, -1 means the latest one")
+	dryRun := flag.Bool("dry-run", false, "print statements of the migrations instead of applying them")
+	flag.Parse()
+
 	data, err := ioutil.ReadFile("config.yaml")
 	if err != nil {
 		panic(err)
 	}
+
+	if *migrate || *dryRun {
+		opts := storage.MigrateOptions{Target: *target, DryRun: *dryRun, Out: os.Stdout}
+		if err := conf.Migrate(data, opts); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	conf.Init(data)
 	log.Fatal(initRouter().Run())
 }
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gouth/pwhash"
+	"gouth/storage"
+	"math/big"
+	"net/http"
+	"strings"
+)
+
+// recoveryCodeAlphabet contains characters used in recovery codes.
+// Ambiguous characters like 0/o and 1/l/i are excluded
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// recoveryCodeGroupLen is the number of characters between dashes in recovery code.
+// The first group is the lookup of the code, it's added to the configured length
+const recoveryCodeGroupLen = 5
+
+// regenerateRecoveryCodesHandler issues a new set of recovery codes,
+// invalidating all previous ones. The recovery code passed as the second factor isn't used up,
+// since it's replaced anyway
+func regenerateRecoveryCodesHandler(app AppConfig) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var authData interface{}
+
+		if err := c.BindJSON(&authData); err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusBadRequest,
+				gin.H{"error": "invalid json"})
+			return
+		}
+
+		userUnique, ok := authenticateWith(c, app, authData, mfaKeepCode)
+		if !ok {
+			return
+		}
+
+		h := app.hasherFor(c.Request.Context())
+
+		codes, err := issueRecoveryCodes(c.Request.Context(), app, h, userUnique)
+		if err != nil {
+			abortHashError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+	}
+}
+
+// remainingRecoveryCodesHandler returns the number of unused recovery codes.
+// Only the primary credential is checked, so the recovery codes aren't used up by the check
+func remainingRecoveryCodesHandler(app AppConfig) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var authData interface{}
+
+		if err := c.BindJSON(&authData); err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusBadRequest,
+				gin.H{"error": "invalid json"})
+			return
+		}
+
+		userUnique, ok := authenticateWith(c, app, authData, mfaSkip)
+		if !ok {
+			return
+		}
+
+		recoveryConf := app.Main.AuthN.MFA.RecoveryCodes
+		mfaStorage := app.storageFor(c.Request.Context(), "mfa").(storage.MFA)
+		codes, err := mfaStorage.GetRecoveryCodes(*recoveryConf.Coll, userUnique)
+		if err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusInternalServerError,
+				gin.H{"error": err.Error()})
+			return
+		}
+
+		remaining := 0
+		for _, code := range codes {
+			if !code.Used {
+				remaining++
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"remaining": remaining})
+	}
+}
+
+// verifyMFA checks the second factor passed with the authentication data, if user has enrolled in MFA.
+// Either recovery code or WebAuthn assertion is accepted, the matched recovery code is used up if useCode is set.
+// Wrong second factor is counted as failure of the reserved login attempt.
+// It aborts the request and returns false if verification fails
+func verifyMFA(c *gin.Context, app AppConfig, h pwhash.PwHasher, userUnique interface{}, authData interface{}, failures map[string]int, useCode bool) bool {
+	mfaConf := app.Main.AuthN.MFA
+	if !mfaConf.isEnabled() {
+		return true
+	}
+
+	mfaStorage := app.storageFor(c.Request.Context(), "mfa").(storage.MFA)
+	codes, err := mfaStorage.GetRecoveryCodes(*mfaConf.RecoveryCodes.Coll, userUnique)
+	if err != nil {
+		c.AbortWithStatusJSON(
+			http.StatusInternalServerError,
+			gin.H{"error": err.Error()})
+		return false
+	}
+
+	creds, err := getWebAuthnCreds(c.Request.Context(), app, userUnique)
+	if err != nil {
+		c.AbortWithStatusJSON(
+			http.StatusInternalServerError,
+			gin.H{"error": err.Error()})
+		return false
+	}
+
+	if len(codes) == 0 && len(creds) == 0 {
+		return true
+	}
+
+	isTrusted, err := isDeviceTrusted(c, app, userUnique)
+	if err != nil {
+		c.AbortWithStatusJSON(
+			http.StatusInternalServerError,
+			gin.H{"error": err.Error()})
+		return false
+	}
+	if isTrusted {
+		return true
+	}
+
+	var isValid bool
+	if len(creds) != 0 && isWebAuthnPassed(app, authData) {
+		var cred *storage.WebAuthnCredential
+		cred, isValid, err = finishWebAuthnLogin(c.Request.Context(), app, authData)
+		if err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusInternalServerError,
+				gin.H{"error": err.Error()})
+			return false
+		}
+		isValid = isValid && fmt.Sprint(cred.UserUnique) == fmt.Sprint(userUnique)
+	} else if rawCode, err := GetJSONPath(mfaConf.RecoveryCodes.Code, authData); err == nil {
+		code, ok := rawCode.(string)
+		if !ok || strings.TrimSpace(code) == "" {
+			c.AbortWithStatusJSON(
+				http.StatusBadRequest,
+				gin.H{"error": "recovery code can't be blank"})
+			return false
+		}
+
+		if useCode {
+			isValid, err = useRecoveryCode(c.Request.Context(), app, h, codes, code)
+		} else {
+			var found *storage.RecoveryCode
+			found, err = findRecoveryCode(h, codes, code)
+			isValid = found != nil
+		}
+		if err != nil {
+			abortHashError(c, err)
+			return false
+		}
+	} else {
+		// the password is right, so asking for the second factor isn't a failure
+		if err := releaseLoginAttempt(c, app, userUnique); err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusInternalServerError,
+				gin.H{"error": err.Error()})
+			return false
+		}
+
+		methods := []string{"recovery_code"}
+		if len(creds) != 0 {
+			methods = append(methods, "webauthn")
+		}
+
+		c.AbortWithStatusJSON(
+			http.StatusUnauthorized,
+			gin.H{"error": "mfa required", "methods": methods})
+		return false
+	}
+
+	if !isValid {
+		abortLoginFailure(c, app, userUnique, failures)
+		return false
+	}
+
+	if err := rememberDevice(c, app, userUnique, authData); err != nil {
+		c.AbortWithStatusJSON(
+			http.StatusInternalServerError,
+			gin.H{"error": err.Error()})
+		return false
+	}
+
+	return true
+}
+
+// isMFAEnrolled checks whether user has any second factor
+func isMFAEnrolled(ctx context.Context, app AppConfig, userUnique interface{}) (bool, error) {
+	mfaStorage := app.storageFor(ctx, "mfa").(storage.MFA)
+	codes, err := mfaStorage.GetRecoveryCodes(*app.Main.AuthN.MFA.RecoveryCodes.Coll, userUnique)
+	if err != nil {
+		return false, err
+	}
+
+	creds, err := getWebAuthnCreds(ctx, app, userUnique)
+	if err != nil {
+		return false, err
+	}
+
+	return len(codes) != 0 || len(creds) != 0, nil
+}
+
+// issueRecoveryCodes generates new recovery codes for the user and stores their lookups and hashes.
+// It's called on MFA enrollment and on recovery codes regeneration
+func issueRecoveryCodes(ctx context.Context, app AppConfig, h pwhash.PwHasher, userUnique interface{}) ([]string, error) {
+	recoveryConf := app.Main.AuthN.MFA.RecoveryCodes
+
+	codes, err := generateRecoveryCodes(recoveryConf.Count, recoveryCodeGroupLen+recoveryConf.Length)
+	if err != nil {
+		return nil, err
+	}
+
+	hashed := make([]storage.RecoveryCode, len(codes))
+	for i, code := range codes {
+		code = normalizeRecoveryCode(code)
+		hashed[i].Lookup = recoveryCodeLookup(code)
+		if hashed[i].Hash, err = h.HashPw(code); err != nil {
+			return nil, err
+		}
+	}
+
+	mfaStorage := app.storageFor(ctx, "mfa").(storage.MFA)
+	if err := mfaStorage.SetRecoveryCodes(*recoveryConf.Coll, userUnique, hashed); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// useRecoveryCode looks for the unused recovery code matching the given one and marks it as used
+func useRecoveryCode(ctx context.Context, app AppConfig, h pwhash.PwHasher, codes []storage.RecoveryCode, code string) (bool, error) {
+	found, err := findRecoveryCode(h, codes, code)
+	if err != nil || found == nil {
+		return false, err
+	}
+
+	mfaStorage := app.storageFor(ctx, "mfa").(storage.MFA)
+	return mfaStorage.UseRecoveryCode(*app.Main.AuthN.MFA.RecoveryCodes.Coll, found.Id)
+}
+
+// findRecoveryCode returns the unused recovery code matching the given one, or nil if there is none.
+// Only the codes with the same lookup are compared, so the login costs one hash comparison
+func findRecoveryCode(h pwhash.PwHasher, codes []storage.RecoveryCode, code string) (*storage.RecoveryCode, error) {
+	code = normalizeRecoveryCode(code)
+	lookup := recoveryCodeLookup(code)
+
+	for i, c := range codes {
+		// codes issued before lookups have the empty one
+		if c.Used || (c.Lookup != "" && c.Lookup != lookup) {
+			continue
+		}
+
+		isMatch, err := h.ComparePw(code, c.Hash)
+		if err != nil {
+			return nil, err
+		}
+
+		if isMatch {
+			return &codes[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// generateRecoveryCodes returns count random recovery codes with the given length,
+// split into dash-separated groups for readability
+func generateRecoveryCodes(count, length int) ([]string, error) {
+	max := big.NewInt(int64(len(recoveryCodeAlphabet)))
+	codes := make([]string, count)
+
+	for i := range codes {
+		var b strings.Builder
+		for j := 0; j < length; j++ {
+			if j != 0 && j%recoveryCodeGroupLen == 0 {
+				b.WriteByte('-')
+			}
+
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return nil, err
+			}
+			b.WriteByte(recoveryCodeAlphabet[n.Int64()])
+		}
+		codes[i] = b.String()
+	}
+
+	return codes, nil
+}
+
+// recoveryCodeLookup returns the lookup of the normalized recovery code
+func recoveryCodeLookup(code string) string {
+	if len(code) < recoveryCodeGroupLen {
+		return code
+	}
+	return code[:recoveryCodeGroupLen]
+}
+
+// normalizeRecoveryCode brings the user input to the form which was used for hashing
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, code)
+}
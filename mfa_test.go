@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gouth/pwhash"
+	"gouth/storage"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_generateRecoveryCodes(t *testing.T) {
+	codes, err := generateRecoveryCodes(10, 10)
+	assert.NoError(t, err)
+	assert.Len(t, codes, 10)
+
+	seen := map[string]bool{}
+	for _, code := range codes {
+		assert.Len(t, code, 11)
+		assert.Equal(t, "-", code[5:6])
+		for _, r := range normalizeRecoveryCode(code) {
+			assert.True(t, strings.ContainsRune(recoveryCodeAlphabet, r))
+		}
+		assert.False(t, seen[code])
+		seen[code] = true
+	}
+
+	codes, err = generateRecoveryCodes(3, 4)
+	assert.NoError(t, err)
+	for _, code := range codes {
+		assert.Len(t, code, 4)
+	}
+}
+
+func Test_normalizeRecoveryCode(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want string
+	}{
+		{name: "as issued", code: "abcde-fghjk", want: "abcdefghjk"},
+		{name: "upper case", code: "ABCDE-FGHJK", want: "abcdefghjk"},
+		{name: "without dash", code: "abcdefghjk", want: "abcdefghjk"},
+		{name: "with spaces", code: "abcde fghjk ", want: "abcdefghjk"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, normalizeRecoveryCode(tt.code))
+		})
+	}
+}
+
+func Test_recoveryCodesHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := newLockoutTestApp(t)
+
+	r := gin.New()
+	r.POST("/mfa/recovery_codes", regenerateRecoveryCodesHandler(app))
+	r.POST("/mfa/recovery_codes/remaining", remainingRecoveryCodesHandler(app))
+
+	codes, err := issueRecoveryCodes(context.Background(), app, app.Hash.Hasher, "jane")
+	assert.NoError(t, err)
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return w
+	}
+
+	// the count needs the password only and doesn't use up the passed code
+	for _, body := range []string{
+		`{"name": "jane", "passwd": "secret"}`,
+		`{"name": "jane", "passwd": "secret", "recovery_code": "` + codes[0] + `"}`,
+	} {
+		w := post("/mfa/recovery_codes/remaining", body)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"remaining": 10}`, w.Body.String())
+	}
+
+	w := post("/mfa/recovery_codes", `{"name": "jane", "passwd": "secret"}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = post("/mfa/recovery_codes", `{"name": "jane", "passwd": "secret", "recovery_code": "wrong"}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = post("/mfa/recovery_codes", `{"name": "jane", "passwd": "secret", "recovery_code": "`+codes[0]+`"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), codes[0])
+
+	// the replaced codes don't work anymore
+	w = post("/mfa/recovery_codes", `{"name": "jane", "passwd": "secret", "recovery_code": "`+codes[1]+`"}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// countingHasher counts the hash comparisons
+type countingHasher struct {
+	pwhash.PwHasher
+	compared int
+}
+
+func (h *countingHasher) ComparePw(pw string, hash string) (bool, error) {
+	h.compared++
+	return h.PwHasher.ComparePw(pw, hash)
+}
+
+func Test_findRecoveryCode(t *testing.T) {
+	h := &countingHasher{PwHasher: newLockoutTestApp(t).Hash.Hasher}
+
+	hash, err := h.HashPw(normalizeRecoveryCode("abcde-fghjk-mnpqr"))
+	assert.NoError(t, err)
+	otherHash, err := h.HashPw(normalizeRecoveryCode("stuvw-xyz23-45678"))
+	assert.NoError(t, err)
+
+	codes := []storage.RecoveryCode{
+		{Id: 1, Lookup: "abcde", Hash: hash, Used: true},
+		{Id: 2, Lookup: "stuvw", Hash: otherHash},
+		{Id: 3, Lookup: "abcde", Hash: hash},
+	}
+	found, err := findRecoveryCode(h, codes, "ABCDE FGHJK MNPQR")
+	if assert.NoError(t, err) && assert.NotNil(t, found) {
+		assert.EqualValues(t, 3, found.Id)
+	}
+	assert.False(t, codes[2].Used)
+	// the used code and the code with other lookup aren't compared
+	assert.Equal(t, 1, h.compared)
+
+	found, err = findRecoveryCode(h, codes, "other")
+	assert.NoError(t, err)
+	assert.Nil(t, found)
+
+	// codes issued before lookups are compared with every passed code
+	h.compared = 0
+	codes = []storage.RecoveryCode{{Id: 4, Hash: otherHash}, {Id: 5, Hash: hash}}
+	found, err = findRecoveryCode(h, codes, "abcde-fghjk-mnpqr")
+	if assert.NoError(t, err) && assert.NotNil(t, found) {
+		assert.EqualValues(t, 5, found.Id)
+	}
+	assert.Equal(t, 2, h.compared)
+}
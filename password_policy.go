@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// checkPasswordPolicy checks the password against the app password policy
+// and aborts the request with the list of violated rules if it isn't satisfied
+func checkPasswordPolicy(c *gin.Context, app AppConfig, password string, userUnique interface{}) bool {
+	policy := app.PasswordPolicy.Policy
+	if policy == nil {
+		return true
+	}
+
+	violations, err := policy.Check(password, fmt.Sprint(userUnique))
+	if err != nil {
+		c.AbortWithStatusJSON(
+			http.StatusInternalServerError,
+			gin.H{"error": err.Error()})
+		return false
+	}
+
+	if len(violations) != 0 {
+		c.AbortWithStatusJSON(
+			http.StatusBadRequest,
+			gin.H{"error": "password doesn't satisfy the policy", "violations": violations})
+		return false
+	}
+
+	return true
+}
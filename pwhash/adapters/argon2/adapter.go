@@ -3,6 +3,7 @@ package argon2
 import (
 	"fmt"
 	"gouth/pwhash"
+	"math"
 )
 
 // AdapterName is the internal name of the adapter
@@ -27,24 +28,62 @@ func (a argon2Adapter) GetPwHasher(rawConf *pwhash.RawHashConfig) (pwhash.PwHash
 	return Argon2{conf: config}, nil
 }
 
-// newConfig creates new HashConfig struct from the raw data, parsed from the config file
+// newConfig creates new HashConfig struct from the raw data, parsed from the config file.
+// Parameters below MinConfig are refused unless weak parameters are allowed, the floors of the settings are always checked
 func newConfig(rawConf *pwhash.RawHashConfig) (*HashConfig, error) {
 	requiredKeys := []string{"type", "iterations", "parallelism", "salt_length", "key_length", "memory"}
+	if err := pwhash.CheckKeys(rawConf, requiredKeys); err != nil {
+		return nil, err
+	}
+
+	conf := &HashConfig{}
+	var err error
+
+	if conf.Type, err = pwhash.GetString(rawConf, "type"); err != nil {
+		return nil, err
+	}
+
+	switch conf.Type {
+	case "argon2i", "argon2id":
+	default:
+		return nil, fmt.Errorf("pwhash config: type must be argon2i or argon2id, got %s", conf.Type)
+	}
+
+	values := map[string]int{}
+	for _, key := range requiredKeys[1:] {
+		if values[key], err = pwhash.GetInt(rawConf, key); err != nil {
+			return nil, err
+		}
 
-	for _, key := range requiredKeys {
-		if _, ok := (*rawConf)[key]; !ok {
-			return &HashConfig{}, fmt.Errorf("pwhash config: missing %s statement", key)
+		if values[key] <= 0 {
+			return nil, fmt.Errorf("pwhash config: %s must be positive", key)
 		}
 	}
 
-	// TODO: add rawConf validation
+	if values["parallelism"] > math.MaxUint8 {
+		return nil, fmt.Errorf("pwhash config: parallelism must be at most %d", math.MaxUint8)
+	}
+
+	conf.Iterations = uint32(values["iterations"])
+	conf.Parallelism = uint8(values["parallelism"])
+	conf.SaltLen = uint32(values["salt_length"])
+	conf.KeyLen = uint32(values["key_length"])
+	conf.Memory = uint32(values["memory"])
+
+	// argon2 requires at least 8 KiB of memory per lane
+	if conf.Memory < 8*uint32(conf.Parallelism) {
+		return nil, fmt.Errorf("pwhash config: memory must be at least 8*parallelism")
+	}
+
+	floors := []pwhash.Floor{
+		{Key: "iterations", Value: int(conf.Iterations), Min: int(MinConfig.Iterations)},
+		{Key: "salt_length", Value: int(conf.SaltLen), Min: int(MinConfig.SaltLen)},
+		{Key: "key_length", Value: int(conf.KeyLen), Min: int(MinConfig.KeyLen)},
+		{Key: "memory", Value: int(conf.Memory), Min: int(MinConfig.Memory)},
+	}
+	if err := pwhash.CheckFloors(rawConf, floors); err != nil {
+		return nil, err
+	}
 
-	return &HashConfig{
-		Type:        (*rawConf)["type"].(string),
-		Iterations:  uint32((*rawConf)["iterations"].(int)),
-		Parallelism: uint8((*rawConf)["parallelism"].(int)),
-		SaltLen:     uint32((*rawConf)["salt_length"].(int)),
-		KeyLen:      uint32((*rawConf)["key_length"].(int)),
-		Memory:      uint32((*rawConf)["memory"].(int)),
-	}, nil
+	return conf, nil
 }
@@ -22,8 +22,8 @@ type HashConfig struct {
 	Memory uint32
 }
 
-// TODO: figure out best default settings
-// DefaultConfig provides some sane default settings for hashing passwords
+// DefaultConfig provides some sane default settings for hashing passwords.
+// Host-specific settings can be picked with the cmd/calibrate command
 var DefaultConfig = &HashConfig{
 	Type:        "argon2i",
 	Iterations:  3,
@@ -32,3 +32,13 @@ var DefaultConfig = &HashConfig{
 	KeyLen:      32,
 	Memory:      32 * 1024,
 }
+
+// MinConfig provides security floors for the settings from the config file.
+// Memory and iterations follow the OWASP recommendation for argon2id (19 MiB, 2 iterations)
+var MinConfig = &HashConfig{
+	Iterations:  2,
+	Parallelism: 1,
+	SaltLen:     16,
+	KeyLen:      16,
+	Memory:      19 * 1024,
+}
@@ -18,6 +18,7 @@ type Argon2 struct {
 var (
 	ErrInvalidHash         = errors.New("argon2: the encoded pwhash is not in the correct format")
 	ErrIncompatibleVersion = errors.New("argon2: incompatible version of argon2")
+	ErrUnknownType         = errors.New("argon2: type must be argon2i or argon2id")
 )
 
 // HashPw returns a Argon2 pwhash of a plain-text password using the provided algorithm
@@ -40,6 +41,8 @@ func (a Argon2) HashPw(pw string) (string, error) {
 		key = argon2.Key([]byte(pw), salt, a.conf.Iterations, a.conf.Memory, a.conf.Parallelism, a.conf.KeyLen)
 	case "argon2id":
 		key = argon2.IDKey([]byte(pw), salt, a.conf.Iterations, a.conf.Memory, a.conf.Parallelism, a.conf.KeyLen)
+	default:
+		return "", ErrUnknownType
 	}
 
 	hash := fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
@@ -80,6 +83,27 @@ func (a Argon2) ComparePw(pw string, hash string) (bool, error) {
 	return false, nil
 }
 
+// NeedsRehash checks whether the hash parameters differ from the hasher settings,
+// so the password should be hashed again
+func (a Argon2) NeedsRehash(hash string) (bool, error) {
+	conf, _, _, err := decodePwHash(hash)
+	if err != nil {
+		return false, err
+	}
+
+	return *conf != *a.conf, nil
+}
+
+// Recognizes checks whether the hash is argon2i or argon2id hash
+func (a Argon2) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2i$") || strings.HasPrefix(hash, "$argon2id$")
+}
+
+// MemoryCost returns the amount of memory used by hashing one password (in kibibytes)
+func (a Argon2) MemoryCost() int {
+	return int(a.conf.Memory)
+}
+
 // decodePwHash expects a pwhash created from this package, and parses it to return the config
 // used to create it, as well as the salt and key
 func decodePwHash(hash string) (*HashConfig, []byte, []byte, error) {
@@ -100,6 +124,9 @@ func decodePwHash(hash string) (*HashConfig, []byte, []byte, error) {
 
 	conf := &HashConfig{}
 	conf.Type = vals[1]
+	if conf.Type != "argon2i" && conf.Type != "argon2id" {
+		return nil, nil, nil, ErrUnknownType
+	}
 	_, err = fmt.Sscanf(vals[3], "m=%d,t=%d,p=%d", &conf.Memory, &conf.Iterations, &conf.Parallelism)
 	if err != nil {
 		return nil, nil, nil, err
@@ -218,3 +218,133 @@ func TestArgon2_Compare(t *testing.T) {
 		})
 	}
 }
+
+func TestArgon2_NeedsRehash(t *testing.T) {
+	const hash = "$argon2i$v=19$m=32768,t=3,p=2$VDkrfTNOys4cBijO2rNTBw$2NP3RaDtHrXrMU+kKlcyTvxjyZOfHYoSAxmUjxS4w1Q"
+
+	tests := []struct {
+		name    string
+		conf    *HashConfig
+		hash    string
+		want    bool
+		wantErr bool
+	}{
+		{
+			name: "same parameters",
+			conf: DefaultConfig,
+			hash: hash,
+			want: false,
+		},
+		{
+			name: "more memory",
+			conf: &HashConfig{Type: "argon2i", Iterations: 3, Parallelism: 2, SaltLen: 16, KeyLen: 32, Memory: 64 * 1024},
+			hash: hash,
+			want: true,
+		},
+		{
+			name: "other type",
+			conf: &HashConfig{Type: "argon2id", Iterations: 3, Parallelism: 2, SaltLen: 16, KeyLen: 32, Memory: 32 * 1024},
+			hash: hash,
+			want: true,
+		},
+		{
+			name:    "invalid pwhash",
+			conf:    DefaultConfig,
+			hash:    "$argon2i$v=19$m=32768,t=3,p=2",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Argon2{conf: tt.conf}
+			got, err := a.NeedsRehash(tt.hash)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NeedsRehash() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestArgon2_Recognizes(t *testing.T) {
+	a := Argon2{conf: DefaultConfig}
+	assert.True(t, a.Recognizes("$argon2i$v=19$m=32768,t=3,p=2$VDkrfTNOys4cBijO2rNTBw$2NP3RaDtHrXrMU+kKlcyTvxjyZOfHYoSAxmUjxS4w1Q"))
+	assert.True(t, a.Recognizes("$argon2id$v=19$m=32768,t=3,p=2$7Jr8EtPeJsqJ1RxoxHC4eQ$XfSHQ28xgqc2/2LyE4YEkAI2CIilixOAvjh2Ds2s0+Y"))
+	assert.False(t, a.Recognizes("$argon2d$v=19$m=32768,t=3,p=2$7Jr8EtPeJsqJ1RxoxHC4eQ$XfSHQ28xgqc2"))
+	assert.False(t, a.Recognizes("pbkdf2_sha1$4096$c9Bp0I0FRcXSBmuOPrcD2w$dTCHD12APSrk1gToimJV5Qiz2jactN6vMgDF64tuALg"))
+}
+
+func Test_newConfig(t *testing.T) {
+	rawConf := func(update map[string]interface{}) *map[string]interface{} {
+		conf := map[string]interface{}{
+			"type":        "argon2id",
+			"iterations":  2,
+			"parallelism": 1,
+			"salt_length": 16,
+			"key_length":  32,
+			"memory":      19 * 1024,
+		}
+		for k, v := range update {
+			conf[k] = v
+		}
+		return &conf
+	}
+
+	tests := []struct {
+		name    string
+		update  map[string]interface{}
+		want    *HashConfig
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			want: &HashConfig{Type: "argon2id", Iterations: 2, Parallelism: 1, SaltLen: 16, KeyLen: 32, Memory: 19 * 1024},
+		},
+		{
+			name:    "unknown type",
+			update:  map[string]interface{}{"type": "argon2d"},
+			wantErr: true,
+		},
+		{
+			name:    "mistyped value",
+			update:  map[string]interface{}{"memory": "64M"},
+			wantErr: true,
+		},
+		{
+			name:    "negative value",
+			update:  map[string]interface{}{"parallelism": -1},
+			wantErr: true,
+		},
+		{
+			name:    "too many lanes",
+			update:  map[string]interface{}{"parallelism": 256},
+			wantErr: true,
+		},
+		{
+			name:    "unknown key",
+			update:  map[string]interface{}{"lanes": 2},
+			wantErr: true,
+		},
+		{
+			name:    "weak memory",
+			update:  map[string]interface{}{"memory": 16384},
+			wantErr: true,
+		},
+		{
+			name:   "weak memory allowed",
+			update: map[string]interface{}{"memory": 16384, "iterations": 1, "allow_weak": true},
+			want:   &HashConfig{Type: "argon2id", Iterations: 1, Parallelism: 1, SaltLen: 16, KeyLen: 32, Memory: 16384},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newConfig(rawConf(tt.update))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newConfig() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package aspnet
+
+import "gouth/pwhash"
+
+// AdapterName is the internal name of the adapter
+const AdapterName = "aspnet_identity"
+
+// init initializes package by register adapter
+func init() {
+	pwhash.RegisterAdapter(AdapterName, aspnetAdapter{})
+}
+
+// aspnetAdapter represents adapter for hashes produced by ASP.NET Identity PasswordHasher
+type aspnetAdapter struct {
+}
+
+// GetPwHasher returns verify-only ASP.NET Identity hasher. It has no settings,
+// since all parameters are contained in the hashes
+func (a aspnetAdapter) GetPwHasher(*pwhash.RawHashConfig) (pwhash.PwHasher, error) {
+	return Identity{}, nil
+}
@@ -0,0 +1,143 @@
+package aspnet
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"golang.org/x/crypto/pbkdf2"
+	"gouth/pwhash"
+	"hash"
+)
+
+// Identity represents verify-only hasher of the hashes produced by ASP.NET Identity.
+// Hashes are base64 encoded blobs of one of the formats:
+//
+//	v2: 0x00 | salt (16 bytes) | key (32 bytes), PBKDF2-HMAC-SHA1 with 1000 iterations
+//	v3: 0x01 | prf (uint32) | iterations (uint32) | salt length (uint32) | salt | key
+//
+// Integers are big-endian, prf is 0 for HMAC-SHA1, 1 for HMAC-SHA256 and 2 for HMAC-SHA512
+type Identity struct {
+}
+
+var ErrInvalidHash = errors.New("aspnet: the encoded pwhash is not in the correct format")
+
+const (
+	v2SaltLen = 16
+	v2KeyLen  = 32
+	v2Iter    = 1000
+
+	// v3HeaderLen is the length of the format marker, prf, iterations and salt length
+	v3HeaderLen = 13
+
+	// v3MinLen is the minimum length of salt and key
+	v3MinLen = 16
+)
+
+// blob represents decoded ASP.NET Identity pwhash
+type blob struct {
+	f    func() hash.Hash
+	iter int
+	salt []byte
+	key  []byte
+}
+
+// HashPw returns ErrVerifyOnly, since ASP.NET Identity hasher only verifies imported hashes
+func (i Identity) HashPw(string) (string, error) {
+	return "", pwhash.ErrVerifyOnly
+}
+
+// VerifyOnly marks the hasher as verify-only, so it's accepted as legacy hasher only
+func (i Identity) VerifyOnly() {}
+
+// ComparePw performs a constant-time comparison between a plain-text password and
+// ASP.NET Identity pwhash, using the parameters and salt contained in the pwhash.
+// It returns true if they match, otherwise it returns false.
+func (i Identity) ComparePw(pw string, hash string) (bool, error) {
+	b, err := decodePwHash(hash)
+	if err != nil {
+		return false, err
+	}
+
+	otherKey := pbkdf2.Key([]byte(pw), b.salt, b.iter, len(b.key), b.f)
+
+	if subtle.ConstantTimeCompare(b.key, otherKey) == 1 {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// NeedsRehash always returns true for recognized hashes, so users are moved to the primary hasher
+func (i Identity) NeedsRehash(hash string) (bool, error) {
+	if _, err := decodePwHash(hash); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Recognizes checks whether the hash is ASP.NET Identity v2 or v3 blob
+func (i Identity) Recognizes(hash string) bool {
+	_, err := decodePwHash(hash)
+	return err == nil
+}
+
+// decodePwHash decodes base64 blob and parses it depending on the format marker
+func decodePwHash(pwHash string) (*blob, error) {
+	data, err := base64.StdEncoding.DecodeString(pwHash)
+	if err != nil || len(data) == 0 {
+		return nil, ErrInvalidHash
+	}
+
+	switch data[0] {
+	case 0x00:
+		if len(data) != 1+v2SaltLen+v2KeyLen {
+			return nil, ErrInvalidHash
+		}
+
+		return &blob{
+			f:    sha1.New,
+			iter: v2Iter,
+			salt: data[1 : 1+v2SaltLen],
+			key:  data[1+v2SaltLen:],
+		}, nil
+
+	case 0x01:
+		if len(data) < v3HeaderLen {
+			return nil, ErrInvalidHash
+		}
+
+		b := &blob{}
+		switch binary.BigEndian.Uint32(data[1:5]) {
+		case 0:
+			b.f = sha1.New
+		case 1:
+			b.f = sha256.New
+		case 2:
+			b.f = sha512.New
+		default:
+			return nil, ErrInvalidHash
+		}
+
+		iter := binary.BigEndian.Uint32(data[5:9])
+		saltLen := binary.BigEndian.Uint32(data[9:13])
+		if iter == 0 || iter > 1<<31-1 || saltLen < v3MinLen {
+			return nil, ErrInvalidHash
+		}
+
+		rest := data[v3HeaderLen:]
+		if uint64(len(rest)) < uint64(saltLen)+v3MinLen {
+			return nil, ErrInvalidHash
+		}
+
+		b.iter = int(iter)
+		b.salt = rest[:saltLen]
+		b.key = rest[saltLen:]
+		return b, nil
+	}
+
+	return nil, ErrInvalidHash
+}
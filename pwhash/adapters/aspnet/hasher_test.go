@@ -0,0 +1,103 @@
+package aspnet
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gouth/pwhash"
+	"testing"
+)
+
+func TestIdentity_Hash(t *testing.T) {
+	_, err := Identity{}.HashPw("qwerty")
+	assert.Equal(t, pwhash.ErrVerifyOnly, err)
+}
+
+func TestIdentity_Compare(t *testing.T) {
+	type args struct {
+		data string
+		hash string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    bool
+		wantErr bool
+	}{
+		{
+			name: "v2 valid data",
+			args: args{
+				data: "qwerty",
+				hash: "AAABAgMEBQYHCAkKCwwNDg970DP5k2bU1i+jTjLIJVGm1oG04ADaZ8rv1z2I3BeEkQ==",
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "v2 invalid data",
+			args: args{
+				data: "123456",
+				hash: "AAABAgMEBQYHCAkKCwwNDg970DP5k2bU1i+jTjLIJVGm1oG04ADaZ8rv1z2I3BeEkQ==",
+			},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name: "v3 sha256 valid data",
+			args: args{
+				data: "qwerty",
+				hash: "AQAAAAEAACcQAAAAEAABAgMEBQYHCAkKCwwNDg/YbWgPcH5md4Mt50LVUhKQCG9XTx/zRNOHNTtmxJnRtA==",
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "v3 sha512 valid data",
+			args: args{
+				data: "qwerty",
+				hash: "AQAAAAIAAYagAAAAEAABAgMEBQYHCAkKCwwNDg/Oq9sEC0e1jxpvEvWvoaVAFI7i5AgT4WaDzcEZRABdLw==",
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "v3 unknown prf",
+			args: args{
+				data: "qwerty",
+				hash: "AQAAAAMAACcQAAAAEAABAgMEBQYHCAkKCwwNDg/YbWgPcH5md4Mt50LVUhKQCG9XTx/zRNOHNTtmxJnRtA==",
+			},
+			want:    false,
+			wantErr: true,
+		},
+		{
+			name: "v2 truncated",
+			args: args{
+				data: "qwerty",
+				hash: "AAABAgMEBQYHCAkKCwwNDg970DP5k2bU1i+jTjLIJVGm1oG04A==",
+			},
+			want:    false,
+			wantErr: true,
+		},
+		{
+			name: "not base64",
+			args: args{
+				data: "qwerty",
+				hash: "$argon2i$v=19$m=32768,t=3,p=2$VDkrfTNOys4cBijO2rNTBw$2NP3RaDtHrXrMU+kKlcyTvxjyZOfHYoSAxmUjxS4w1Q",
+			},
+			want:    false,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Identity{}.ComparePw(tt.args.data, tt.args.hash)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ComparePw() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ComparePw() got = %v, want %v", got, tt.want)
+			}
+
+			assert.Equal(t, !tt.wantErr, Identity{}.Recognizes(tt.args.hash))
+		})
+	}
+}
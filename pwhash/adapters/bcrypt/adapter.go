@@ -0,0 +1,58 @@
+package bcrypt
+
+import (
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+	"gouth/pwhash"
+)
+
+// AdapterName is the internal name of the adapter
+const AdapterName = "bcrypt"
+
+// init initializes package by register adapter
+func init() {
+	pwhash.RegisterAdapter(AdapterName, bcryptAdapter{})
+}
+
+// bcryptAdapter represents adapter for bcrypt pwhash algorithm
+type bcryptAdapter struct {
+}
+
+// GetHasher returns Bcrypt hasher with the given settings
+func (a bcryptAdapter) GetPwHasher(rawConf *pwhash.RawHashConfig) (pwhash.PwHasher, error) {
+	config, err := newConfig(rawConf)
+	if err != nil {
+		return nil, err
+	}
+
+	return Bcrypt{conf: config}, nil
+}
+
+// newConfig creates new HashConfig struct from the raw data, parsed from the config file.
+// Cost below MinConfig is refused unless weak parameters are allowed, the floor of the settings is always checked
+func newConfig(rawConf *pwhash.RawHashConfig) (*HashConfig, error) {
+	if err := pwhash.CheckKeys(rawConf, []string{"cost"}, "prehash"); err != nil {
+		return nil, err
+	}
+
+	cost, err := pwhash.GetInt(rawConf, "cost")
+	if err != nil {
+		return nil, err
+	}
+
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("pwhash config: cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	floors := []pwhash.Floor{{Key: "cost", Value: cost, Min: MinConfig.Cost}}
+	if err := pwhash.CheckFloors(rawConf, floors); err != nil {
+		return nil, err
+	}
+
+	prehash, err := pwhash.GetBool(rawConf, "prehash", false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HashConfig{Cost: cost, Prehash: prehash}, nil
+}
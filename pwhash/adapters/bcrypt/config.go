@@ -0,0 +1,22 @@
+package bcrypt
+
+// DefaultConfig provides some sane default settings for hashing passwords
+var DefaultConfig = &HashConfig{
+	Cost:    12,
+	Prehash: false,
+}
+
+// MinConfig provides security floor for the cost from the config file
+var MinConfig = &HashConfig{
+	Cost: 10,
+}
+
+// HashConfig represents parsed pwhash config from the config file
+type HashConfig struct {
+	// Base-2 logarithm of the number of key expansion rounds, from 4 to 31
+	Cost int
+
+	// Whether the password is hashed with SHA-256 before bcrypt,
+	// so passwords longer than 72 bytes aren't truncated
+	Prehash bool
+}
@@ -0,0 +1,128 @@
+package bcrypt
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+)
+
+// Bcrypt represents bcrypt hasher
+type Bcrypt struct {
+	conf *HashConfig
+}
+
+// prehashPrefix marks hashes of the pre-hashed passwords
+const prehashPrefix = "$bcrypt-sha256"
+
+// maxPwLen is the maximum password length in bytes, which bcrypt takes into account
+const maxPwLen = 72
+
+var (
+	ErrInvalidHash = errors.New("bcrypt: the encoded pwhash is not in the correct format")
+	ErrPwTooLong   = errors.New("bcrypt: password is longer than 72 bytes, enable prehash to support it")
+)
+
+// HashPw returns a Bcrypt pwhash of a plain-text password using the provided cost.
+// The returned pwhash follows the modular crypt format and looks like this:
+//
+//	$2a$12$R9h/cIPz0gi.URNNX3kh2OPST9/PgBkqquzi.Ss7KIUgO2t0jWMUW
+//
+// If prehash is enabled, the password is hashed with SHA-256 first and
+// the pwhash is prefixed with "$bcrypt-sha256":
+//
+//	$bcrypt-sha256$2a$12$R9h/cIPz0gi.URNNX3kh2OPST9/PgBkqquzi.Ss7KIUgO2t0jWMUW
+func (b Bcrypt) HashPw(pw string) (string, error) {
+	secret := []byte(pw)
+	if b.conf.Prehash {
+		secret = prehash(pw)
+	} else if len(secret) > maxPwLen {
+		return "", ErrPwTooLong
+	}
+
+	hash, err := bcrypt.GenerateFromPassword(secret, b.conf.Cost)
+	if err != nil {
+		return "", err
+	}
+
+	if b.conf.Prehash {
+		return prehashPrefix + string(hash), nil
+	}
+	return string(hash), nil
+}
+
+// ComparePw performs a constant-time comparison between a plain-text password and
+// Bcrypt pwhash, using the cost and salt contained in the pwhash.
+// It returns true if they match, otherwise it returns false.
+func (b Bcrypt) ComparePw(pw string, hash string) (bool, error) {
+	bcryptHash, isPrehashed, err := decodePwHash(hash)
+	if err != nil {
+		return false, err
+	}
+
+	// passwords longer than 72 bytes are truncated by bcrypt
+	// the same way as by other implementations, so imported hashes still match
+	secret := []byte(pw)
+	if isPrehashed {
+		secret = prehash(pw)
+	}
+
+	err = bcrypt.CompareHashAndPassword(bcryptHash, secret)
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// NeedsRehash checks whether the hash cost or pre-hashing differ from the hasher settings,
+// so the password should be hashed again
+func (b Bcrypt) NeedsRehash(hash string) (bool, error) {
+	bcryptHash, isPrehashed, err := decodePwHash(hash)
+	if err != nil {
+		return false, err
+	}
+
+	cost, err := bcrypt.Cost(bcryptHash)
+	if err != nil {
+		return false, err
+	}
+
+	return cost != b.conf.Cost || isPrehashed != b.conf.Prehash, nil
+}
+
+// Recognizes checks whether the hash is $2a$, $2b$, $2y$ or pre-hashed bcrypt hash
+func (b Bcrypt) Recognizes(hash string) bool {
+	_, _, err := decodePwHash(hash)
+	return err == nil
+}
+
+// decodePwHash strips the pre-hashing prefix of the pwhash and checks its version.
+// It returns bcrypt hash and whether the password was pre-hashed
+func decodePwHash(hash string) ([]byte, bool, error) {
+	isPrehashed := strings.HasPrefix(hash, prehashPrefix+"$")
+	hash = strings.TrimPrefix(hash, prehashPrefix)
+
+	if len(hash) < 4 {
+		return nil, false, ErrInvalidHash
+	}
+
+	switch hash[:4] {
+	case "$2a$", "$2b$", "$2y$":
+	default:
+		return nil, false, ErrInvalidHash
+	}
+
+	return []byte(hash), isPrehashed, nil
+}
+
+// prehash returns base64 encoded SHA-256 digest of the password.
+// Encoding prevents NUL bytes, which terminate the bcrypt input in some implementations
+func prehash(pw string) []byte {
+	sum := sha256.Sum256([]byte(pw))
+	return []byte(base64.StdEncoding.EncodeToString(sum[:]))
+}
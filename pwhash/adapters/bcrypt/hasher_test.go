@@ -0,0 +1,283 @@
+package bcrypt
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestBcrypt_Hash(t *testing.T) {
+	type fields struct {
+		conf *HashConfig
+	}
+	type args struct {
+		data string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr bool
+	}{
+		{
+			name:    "bcrypt default",
+			fields:  fields{conf: DefaultConfig},
+			args:    args{data: "qwerty"},
+			wantErr: false,
+		},
+		{
+			name:    "bcrypt min cost",
+			fields:  fields{conf: &HashConfig{Cost: 4}},
+			args:    args{data: "hdg36*/*12bd6"},
+			wantErr: false,
+		},
+		{
+			name:    "bcrypt invalid cost",
+			fields:  fields{conf: &HashConfig{Cost: 32}},
+			args:    args{data: "hdg36*/*12bd6"},
+			wantErr: true,
+		},
+		{
+			name:    "bcrypt too long password",
+			fields:  fields{conf: &HashConfig{Cost: 4}},
+			args:    args{data: strings.Repeat("a", 73)},
+			wantErr: true,
+		},
+		{
+			name:    "bcrypt-sha256 long password",
+			fields:  fields{conf: &HashConfig{Cost: 4, Prehash: true}},
+			args:    args{data: strings.Repeat("a", 73)},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := Bcrypt{
+				conf: tt.fields.conf,
+			}
+			got, err := b.HashPw(tt.args.data)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("HashPw() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			isMatch, err := b.ComparePw(tt.args.data, got)
+			assert.NoError(t, err)
+			assert.True(t, isMatch)
+		})
+	}
+}
+
+func TestBcrypt_Compare(t *testing.T) {
+	type fields struct {
+		conf *HashConfig
+	}
+	type args struct {
+		data string
+		hash string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:   "2a valid data",
+			fields: fields{conf: DefaultConfig},
+			args: args{
+				data: "qwerty",
+				hash: "$2a$04$ypwzgnJhsuVjUztfAp9fSuRXgx6dr5JQ/qPJvnRG9tp7N48I7SnA6",
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name:   "2a invalid data",
+			fields: fields{conf: DefaultConfig},
+			args: args{
+				data: "123456",
+				hash: "$2a$04$ypwzgnJhsuVjUztfAp9fSuRXgx6dr5JQ/qPJvnRG9tp7N48I7SnA6",
+			},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name:   "2y valid data",
+			fields: fields{conf: DefaultConfig},
+			args: args{
+				data: "rasmuslerdorf",
+				hash: "$2y$10$.vGA1O9wmRjrwAVXD98HNOgsNpDczlqm3Jq7KnEd1rVAGv3Fykk1a",
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name:   "2b valid data",
+			fields: fields{conf: DefaultConfig},
+			args: args{
+				data: "hdg36*/*12bd6",
+				hash: "$2b$04$aXkWYvc.ZcZFaPof/VOcXOTZKtLhraTaz1NOxh3fTw5v.CJeYTSHe",
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name:   "bcrypt-sha256 valid data",
+			fields: fields{conf: DefaultConfig},
+			args: args{
+				data: "qwerty",
+				hash: "$bcrypt-sha256$2a$04$1ZVxn6DV5mEP2LU0wEvTLOM2haHpYbw2LZwy6I/ZyO566DoBn8vne",
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name:   "bcrypt-sha256 invalid data",
+			fields: fields{conf: DefaultConfig},
+			args: args{
+				data: "123456",
+				hash: "$bcrypt-sha256$2a$04$1ZVxn6DV5mEP2LU0wEvTLOM2haHpYbw2LZwy6I/ZyO566DoBn8vne",
+			},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name:   "bcrypt invalid pwhash",
+			fields: fields{conf: DefaultConfig},
+			args: args{
+				data: "qwerty",
+				hash: "$2a$04$ypwzgnJhsuVjUztfAp9fSuRXgx6",
+			},
+			want:    false,
+			wantErr: true,
+		},
+		{
+			name:   "bcrypt unknown version",
+			fields: fields{conf: DefaultConfig},
+			args: args{
+				data: "qwerty",
+				hash: "$2x$04$ypwzgnJhsuVjUztfAp9fSuRXgx6dr5JQ/qPJvnRG9tp7N48I7SnA6",
+			},
+			want:    false,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := Bcrypt{
+				conf: tt.fields.conf,
+			}
+			got, err := b.ComparePw(tt.args.data, tt.args.hash)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ComparePw() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ComparePw() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBcrypt_CompareTruncated(t *testing.T) {
+	b := Bcrypt{conf: &HashConfig{Cost: 4}}
+	pw := strings.Repeat("a", 72)
+
+	hash, err := b.HashPw(pw)
+	assert.NoError(t, err)
+
+	// imported hashes of longer passwords were created from their first 72 bytes
+	isMatch, err := b.ComparePw(pw+"tail", hash)
+	assert.NoError(t, err)
+	assert.True(t, isMatch)
+}
+
+func TestBcrypt_NeedsRehash(t *testing.T) {
+	const hash = "$2a$04$ypwzgnJhsuVjUztfAp9fSuRXgx6dr5JQ/qPJvnRG9tp7N48I7SnA6"
+
+	tests := []struct {
+		name    string
+		conf    *HashConfig
+		hash    string
+		want    bool
+		wantErr bool
+	}{
+		{
+			name: "same parameters",
+			conf: &HashConfig{Cost: 4},
+			hash: hash,
+			want: false,
+		},
+		{
+			name: "higher cost",
+			conf: DefaultConfig,
+			hash: hash,
+			want: true,
+		},
+		{
+			name: "prehash enabled",
+			conf: &HashConfig{Cost: 4, Prehash: true},
+			hash: hash,
+			want: true,
+		},
+		{
+			name:    "invalid pwhash",
+			conf:    DefaultConfig,
+			hash:    "$argon2i$v=19$m=32768,t=3,p=2",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := Bcrypt{conf: tt.conf}
+			got, err := b.NeedsRehash(tt.hash)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NeedsRehash() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestBcrypt_Recognizes(t *testing.T) {
+	b := Bcrypt{conf: DefaultConfig}
+	assert.True(t, b.Recognizes("$2a$04$ypwzgnJhsuVjUztfAp9fSuRXgx6dr5JQ/qPJvnRG9tp7N48I7SnA6"))
+	assert.True(t, b.Recognizes("$2y$10$.vGA1O9wmRjrwAVXD98HNOgsNpDczlqm3Jq7KnEd1rVAGv3Fykk1a"))
+	assert.True(t, b.Recognizes("$bcrypt-sha256$2a$04$1ZVxn6DV5mEP2LU0wEvTLOM2haHpYbw2LZwy6I/ZyO566DoBn8vne"))
+	assert.False(t, b.Recognizes("$argon2i$v=19$m=32768,t=3,p=2$VDkrfTNOys4cBijO2rNTBw$2NP3RaDtHrXrMU+kKlcyTvxjyZOfHYoSAxmUjxS4w1Q"))
+	assert.False(t, b.Recognizes("pbkdf2_sha1$4096$c9Bp0I0FRcXSBmuOPrcD2w$dTCHD12APSrk1gToimJV5Qiz2jactN6vMgDF64tuALg"))
+}
+
+func Test_newConfig(t *testing.T) {
+	conf, err := newConfig(&map[string]interface{}{"cost": 10, "prehash": true})
+	assert.NoError(t, err)
+	assert.Equal(t, &HashConfig{Cost: 10, Prehash: true}, conf)
+
+	_, err = newConfig(&map[string]interface{}{})
+	assert.Error(t, err)
+
+	_, err = newConfig(&map[string]interface{}{"cost": 3})
+	assert.Error(t, err)
+
+	_, err = newConfig(&map[string]interface{}{"cost": 8})
+	assert.Error(t, err)
+
+	conf, err = newConfig(&map[string]interface{}{"cost": 8, "allow_weak": true})
+	assert.NoError(t, err)
+	assert.Equal(t, &HashConfig{Cost: 8}, conf)
+
+	_, err = newConfig(&map[string]interface{}{"cost": 10, "floors": map[string]interface{}{"cost": 12}})
+	assert.Error(t, err)
+
+	_, err = newConfig(&map[string]interface{}{"cost": "10"})
+	assert.Error(t, err)
+
+	_, err = newConfig(&map[string]interface{}{"cost": 10, "prehash": "yes"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,21 @@
+package django
+
+import "gouth/pwhash"
+
+// AdapterName is the internal name of the adapter
+const AdapterName = "django"
+
+// init initializes package by register adapter
+func init() {
+	pwhash.RegisterAdapter(AdapterName, djangoAdapter{})
+}
+
+// djangoAdapter represents adapter for hashes produced by Django password hashers
+type djangoAdapter struct {
+}
+
+// GetPwHasher returns verify-only Django hasher. It has no settings,
+// since all parameters are contained in the hashes
+func (a djangoAdapter) GetPwHasher(*pwhash.RawHashConfig) (pwhash.PwHasher, error) {
+	return Django{}, nil
+}
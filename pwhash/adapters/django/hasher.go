@@ -0,0 +1,206 @@
+package django
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+	"gouth/pwhash"
+	"gouth/pwhash/adapters/phc"
+	"strings"
+)
+
+// Django represents verify-only hasher of the hashes produced by Django.
+// Supported algorithms are pbkdf2_sha256, pbkdf2_sha1, argon2, bcrypt_sha256, bcrypt and scrypt:
+//
+//	pbkdf2_sha256$260000$c29tZXNhbHQ$<base64 encoded key>
+//	argon2$argon2id$v=19$m=102400,t=2,p=8$c29tZXNhbHQ$<base64 encoded key>
+//	bcrypt_sha256$$2b$12$R9h/cIPz0gi.URNNX3kh2OPST9/PgBkqquzi.Ss7KIUgO2t0jWMUW
+//	scrypt$16384$c29tZXNhbHQ$8$1$<base64 encoded key>
+//
+// Unlike our pbkdf2 dialect, Django uses the salt as is and pads the base64 encoded key
+type Django struct {
+}
+
+var ErrInvalidHash = errors.New("django: the encoded pwhash is not in the correct format")
+
+// scryptKeyLen is the length of the key derived by Django with scrypt
+const scryptKeyLen = 64
+
+// HashPw returns ErrVerifyOnly, since Django hasher only verifies imported hashes
+func (d Django) HashPw(string) (string, error) {
+	return "", pwhash.ErrVerifyOnly
+}
+
+// VerifyOnly marks the hasher as verify-only, so it's accepted as legacy hasher only
+func (d Django) VerifyOnly() {}
+
+// ComparePw performs a constant-time comparison between a plain-text password and
+// Django pwhash, using the algorithm, parameters and salt contained in the pwhash.
+// It returns true if they match, otherwise it returns false.
+func (d Django) ComparePw(pw string, hash string) (bool, error) {
+	algorithm := strings.SplitN(hash, "$", 2)[0]
+
+	switch algorithm {
+	case "pbkdf2_sha256", "pbkdf2_sha1":
+		iter, salt, key, err := decodePbkdf2(hash)
+		if err != nil {
+			return false, err
+		}
+
+		f := sha256.New
+		if algorithm == "pbkdf2_sha1" {
+			f = sha1.New
+		}
+
+		otherKey := pbkdf2.Key([]byte(pw), salt, iter, len(key), f)
+		return subtle.ConstantTimeCompare(key, otherKey) == 1, nil
+
+	case "argon2":
+		return phc.Phc{}.ComparePw(pw, strings.TrimPrefix(hash, algorithm))
+
+	case "bcrypt_sha256", "bcrypt":
+		bcryptHash, err := decodeBcrypt(hash)
+		if err != nil {
+			return false, err
+		}
+
+		secret := []byte(pw)
+		if algorithm == "bcrypt_sha256" {
+			sum := sha256.Sum256(secret)
+			secret = []byte(hex.EncodeToString(sum[:]))
+		}
+
+		err = bcrypt.CompareHashAndPassword(bcryptHash, secret)
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return false, nil
+		}
+		return err == nil, err
+
+	case "scrypt":
+		var n, r, p int
+		salt, key, err := decodeScrypt(hash, &n, &r, &p)
+		if err != nil {
+			return false, err
+		}
+
+		otherKey, err := scrypt.Key([]byte(pw), salt, n, r, p, len(key))
+		if err != nil {
+			return false, err
+		}
+		return subtle.ConstantTimeCompare(key, otherKey) == 1, nil
+	}
+
+	return false, ErrInvalidHash
+}
+
+// NeedsRehash always returns true for recognized hashes, so users are moved to the primary hasher
+func (d Django) NeedsRehash(hash string) (bool, error) {
+	if !d.Recognizes(hash) {
+		return false, ErrInvalidHash
+	}
+	return true, nil
+}
+
+// Recognizes checks whether the hash has the format of one of the supported Django hashers
+func (d Django) Recognizes(hash string) bool {
+	algorithm := strings.SplitN(hash, "$", 2)[0]
+
+	switch algorithm {
+	case "pbkdf2_sha256", "pbkdf2_sha1":
+		_, _, _, err := decodePbkdf2(hash)
+		return err == nil
+	case "argon2":
+		return phc.Phc{}.Recognizes(strings.TrimPrefix(hash, algorithm))
+	case "bcrypt_sha256", "bcrypt":
+		_, err := decodeBcrypt(hash)
+		return err == nil
+	case "scrypt":
+		var n, r, p int
+		_, _, err := decodeScrypt(hash, &n, &r, &p)
+		return err == nil
+	}
+
+	return false
+}
+
+// decodePbkdf2 parses pbkdf2 pwhash and returns the number of iterations, salt and key.
+// The key must be padded base64 of the digest size, which distinguishes it from our pbkdf2 dialect
+func decodePbkdf2(hash string) (int, []byte, []byte, error) {
+	vals := strings.Split(hash, "$")
+	if len(vals) != 4 || vals[2] == "" {
+		return 0, nil, nil, ErrInvalidHash
+	}
+
+	var iter int
+	if _, err := fmt.Sscanf(vals[1], "%d", &iter); err != nil || iter <= 0 {
+		return 0, nil, nil, ErrInvalidHash
+	}
+
+	key, err := base64.StdEncoding.DecodeString(vals[3])
+	if err != nil {
+		return 0, nil, nil, ErrInvalidHash
+	}
+
+	digestSize := sha256.Size
+	if vals[0] == "pbkdf2_sha1" {
+		digestSize = sha1.Size
+	}
+	if len(key) != digestSize {
+		return 0, nil, nil, ErrInvalidHash
+	}
+
+	return iter, []byte(vals[2]), key, nil
+}
+
+// decodeBcrypt strips the algorithm name and returns bcrypt hash
+func decodeBcrypt(hash string) ([]byte, error) {
+	vals := strings.SplitN(hash, "$", 2)
+	if len(vals) != 2 {
+		return nil, ErrInvalidHash
+	}
+
+	bcryptHash := vals[1]
+	if len(bcryptHash) < 4 {
+		return nil, ErrInvalidHash
+	}
+
+	switch bcryptHash[:4] {
+	case "$2a$", "$2b$", "$2y$":
+		return []byte(bcryptHash), nil
+	}
+	return nil, ErrInvalidHash
+}
+
+// decodeScrypt parses scrypt pwhash, stores its parameters in n, r and p,
+// and returns the salt and key
+func decodeScrypt(hash string, n, r, p *int) ([]byte, []byte, error) {
+	vals := strings.Split(hash, "$")
+	if len(vals) != 6 || vals[2] == "" {
+		return nil, nil, ErrInvalidHash
+	}
+
+	params := []struct {
+		raw string
+		dst *int
+	}{{vals[1], n}, {vals[3], r}, {vals[4], p}}
+
+	for _, param := range params {
+		if _, err := fmt.Sscanf(param.raw, "%d", param.dst); err != nil || *param.dst <= 0 {
+			return nil, nil, ErrInvalidHash
+		}
+	}
+
+	key, err := base64.StdEncoding.DecodeString(vals[5])
+	if err != nil || len(key) != scryptKeyLen {
+		return nil, nil, ErrInvalidHash
+	}
+
+	return []byte(vals[2]), key, nil
+}
@@ -0,0 +1,125 @@
+package django
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gouth/pwhash"
+	"testing"
+)
+
+func TestDjango_Hash(t *testing.T) {
+	_, err := Django{}.HashPw("qwerty")
+	assert.Equal(t, pwhash.ErrVerifyOnly, err)
+}
+
+func TestDjango_Compare(t *testing.T) {
+	type args struct {
+		data string
+		hash string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    bool
+		wantErr bool
+	}{
+		{
+			name: "pbkdf2_sha256 valid data",
+			args: args{
+				data: "qwerty",
+				hash: "pbkdf2_sha256$260000$seasalt$Lm/Jc/XyS1circpZcvY65vDCFxDD4kvL4qr0wdZtQ/8=",
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "pbkdf2_sha256 invalid data",
+			args: args{
+				data: "123456",
+				hash: "pbkdf2_sha256$260000$seasalt$Lm/Jc/XyS1circpZcvY65vDCFxDD4kvL4qr0wdZtQ/8=",
+			},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name: "pbkdf2_sha1 valid data",
+			args: args{
+				data: "qwerty",
+				hash: "pbkdf2_sha1$260000$seasalt$p/hp8mvSr/XkdpJNYM+iTmC7Gz8=",
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "argon2 valid data",
+			args: args{
+				data: "qwerty",
+				hash: "argon2$argon2id$v=19$m=32768,t=3,p=2$7Jr8EtPeJsqJ1RxoxHC4eQ$XfSHQ28xgqc2/2LyE4YEkAI2CIilixOAvjh2Ds2s0+Y",
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "bcrypt_sha256 valid data",
+			args: args{
+				data: "qwerty",
+				hash: "bcrypt_sha256$$2b$04$mf2611fowDzuMYmMkKEUPeYAlhO3yPzO8BAqKM9EGZiPF0rrvDCKC",
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "bcrypt_sha256 invalid data",
+			args: args{
+				data: "123456",
+				hash: "bcrypt_sha256$$2b$04$mf2611fowDzuMYmMkKEUPeYAlhO3yPzO8BAqKM9EGZiPF0rrvDCKC",
+			},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name: "scrypt valid data",
+			args: args{
+				data: "qwerty",
+				hash: "scrypt$16384$seasalt$8$1$0ie7ieR0ir0WkZTsQM+XI6rOsr/KOzrwPMHOHesgP8cPljXKoadgt6DqNxxeXn6YZpn/qgiGce41ODKYknkVWA==",
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "our pbkdf2 dialect",
+			args: args{
+				data: "qwerty",
+				hash: "pbkdf2_sha256$4096$jy6BcRAh36wA20njEWNw6g$pyGrYuJ+bGP2r8DnXkdFZ8hwBuBfQyKF7/OdAQ/dv1U",
+			},
+			want:    false,
+			wantErr: true,
+		},
+		{
+			name: "unsupported algorithm",
+			args: args{
+				data: "qwerty",
+				hash: "md5$seasalt$5cdb0d8e4dc9dbb0f4a5bfab4ef3d46d",
+			},
+			want:    false,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Django{}.ComparePw(tt.args.data, tt.args.hash)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ComparePw() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ComparePw() got = %v, want %v", got, tt.want)
+			}
+
+			assert.Equal(t, !tt.wantErr, Django{}.Recognizes(tt.args.hash))
+
+			needsRehash, err := Django{}.NeedsRehash(tt.args.hash)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, !tt.wantErr, needsRehash)
+		})
+	}
+}
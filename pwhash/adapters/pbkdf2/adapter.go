@@ -30,25 +30,36 @@ func (a pbkdf2Adapter) GetPwHasher(rawConf *pwhash.RawHashConfig) (pwhash.PwHash
 	return Pbkdf2{conf: config}, nil
 }
 
-// newConfig creates new HashConfig struct from the raw data, parsed from the config file
+// newConfig creates new HashConfig struct from the raw data, parsed from the config file.
+// Parameters below MinIterations and MinConfig are refused unless weak parameters are allowed,
+// the floors of the settings are always checked
 func newConfig(rawConf *pwhash.RawHashConfig) (*HashConfig, error) {
 	requiredKeys := []string{"iterations", "salt_length", "key_length", "func"}
-
-	for _, key := range requiredKeys {
-		if _, ok := (*rawConf)[key]; !ok {
-			return &HashConfig{}, fmt.Errorf("pwhash config: missing %s statement", key)
-		}
+	if err := pwhash.CheckKeys(rawConf, requiredKeys); err != nil {
+		return nil, err
 	}
 
-	// TODO: add rawConf validation
+	conf := &HashConfig{}
+	var err error
 
-	conf := &HashConfig{
-		Iterations: (*rawConf)["iterations"].(int),
-		SaltLen:    (*rawConf)["salt_length"].(int),
-		KeyLen:     (*rawConf)["key_length"].(int),
+	for key, dst := range map[string]*int{
+		"iterations":  &conf.Iterations,
+		"salt_length": &conf.SaltLen,
+		"key_length":  &conf.KeyLen,
+	} {
+		if *dst, err = pwhash.GetInt(rawConf, key); err != nil {
+			return nil, err
+		}
+
+		if *dst <= 0 {
+			return nil, fmt.Errorf("pwhash config: %s must be positive", key)
+		}
 	}
 
-	funcName := (*rawConf)["func"].(string)
+	funcName, err := pwhash.GetString(rawConf, "func")
+	if err != nil {
+		return nil, err
+	}
 
 	switch funcName {
 	case "sha1":
@@ -67,5 +78,14 @@ func newConfig(rawConf *pwhash.RawHashConfig) (*HashConfig, error) {
 
 	conf.FuncName = funcName
 
+	floors := []pwhash.Floor{
+		{Key: "iterations", Value: conf.Iterations, Min: MinIterations[funcName]},
+		{Key: "salt_length", Value: conf.SaltLen, Min: MinConfig.SaltLen},
+		{Key: "key_length", Value: conf.KeyLen, Min: MinConfig.KeyLen},
+	}
+	if err := pwhash.CheckFloors(rawConf, floors); err != nil {
+		return nil, err
+	}
+
 	return conf, nil
 }
@@ -1,18 +1,34 @@
 package pbkdf2
 
 import (
-	"crypto/sha1"
+	"crypto/sha256"
 	"hash"
 )
 
-// TODO: figure out best default settings
-// DefaultConfig provides some sane default settings for hashing passwords
+// DefaultConfig provides some sane default settings for hashing passwords, it meets the floors of MinIterations.
+// Host-specific settings can be picked with the cmd/calibrate command
 var DefaultConfig = &HashConfig{
-	Iterations: 4096,
+	Iterations: 600000,
 	SaltLen:    16,
 	KeyLen:     32,
-	FuncName:   "sha1",
-	Func:       sha1.New,
+	FuncName:   "sha256",
+	Func:       sha256.New,
+}
+
+// MinConfig provides security floors for the salt and key length from the config file
+var MinConfig = &HashConfig{
+	SaltLen: 16,
+	KeyLen:  16,
+}
+
+// MinIterations provides security floors for the number of iterations of each function.
+// Values follow the OWASP recommendations
+var MinIterations = map[string]int{
+	"sha1":   1300000,
+	"sha224": 600000,
+	"sha256": 600000,
+	"sha384": 210000,
+	"sha512": 210000,
 }
 
 // HashConfig represents parsed pwhash config from the config file
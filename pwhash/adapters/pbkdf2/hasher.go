@@ -63,6 +63,27 @@ func (p Pbkdf2) ComparePw(pw string, hash string) (bool, error) {
 	return false, nil
 }
 
+// NeedsRehash checks whether the hash parameters differ from the hasher settings,
+// so the password should be hashed again
+func (p Pbkdf2) NeedsRehash(hash string) (bool, error) {
+	conf, _, _, err := decodePwHash(hash)
+	if err != nil {
+		return false, err
+	}
+
+	return conf.FuncName != p.conf.FuncName ||
+		conf.Iterations != p.conf.Iterations ||
+		conf.SaltLen != p.conf.SaltLen ||
+		conf.KeyLen != p.conf.KeyLen, nil
+}
+
+// Recognizes checks whether the hash is pbkdf2 hash with supported pseudorandom function.
+// Django hashes with the same prefix aren't recognized, since their key is padded
+func (p Pbkdf2) Recognizes(hash string) bool {
+	_, _, _, err := decodePwHash(hash)
+	return err == nil
+}
+
 // decodePwHash expects a pwhash created from this package, and parses it to return the config
 // used to create it, as well as the salt and key
 func decodePwHash(hash string) (*HashConfig, []byte, []byte, error) {
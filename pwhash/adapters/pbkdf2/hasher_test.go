@@ -1,6 +1,7 @@
 package pbkdf2
 
 import (
+	"crypto/sha1"
 	"crypto/sha256"
 	"crypto/sha512"
 	"github.com/stretchr/testify/assert"
@@ -21,11 +22,23 @@ func TestPbkdf2_HashPw(t *testing.T) {
 		wantErr bool
 	}{
 		{
-			name:    "pbkdf2 sha1",
+			name:    "default config",
 			fields:  fields{conf: DefaultConfig},
 			args:    args{pw: "qwerty"},
 			wantErr: false,
 		},
+		{
+			name: "pbkdf2 sha1",
+			fields: fields{conf: &HashConfig{
+				Iterations: 4096,
+				SaltLen:    16,
+				KeyLen:     32,
+				FuncName:   "sha1",
+				Func:       sha1.New,
+			}},
+			args:    args{pw: "qwerty"},
+			wantErr: false,
+		},
 		{
 			name: "pbkdf2 sha224",
 			fields: fields{conf: &HashConfig{
@@ -197,3 +210,140 @@ func TestPbkdf2_ComparePw(t *testing.T) {
 		})
 	}
 }
+
+func TestPbkdf2_NeedsRehash(t *testing.T) {
+	const hash = "pbkdf2_sha1$4096$c9Bp0I0FRcXSBmuOPrcD2w$dTCHD12APSrk1gToimJV5Qiz2jactN6vMgDF64tuALg"
+
+	tests := []struct {
+		name    string
+		conf    *HashConfig
+		hash    string
+		want    bool
+		wantErr bool
+	}{
+		{
+			name: "same parameters",
+			conf: &HashConfig{Iterations: 4096, SaltLen: 16, KeyLen: 32, FuncName: "sha1", Func: sha1.New},
+			hash: hash,
+			want: false,
+		},
+		{
+			name: "more iterations",
+			conf: &HashConfig{Iterations: 10000, SaltLen: 16, KeyLen: 32, FuncName: "sha1", Func: sha1.New},
+			hash: hash,
+			want: true,
+		},
+		{
+			name: "other function",
+			conf: &HashConfig{Iterations: 4096, SaltLen: 16, KeyLen: 32, FuncName: "sha256", Func: sha256.New},
+			hash: hash,
+			want: true,
+		},
+		{
+			name:    "invalid pwhash",
+			conf:    DefaultConfig,
+			hash:    "pbkdf2_sha1$4096",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Pbkdf2{conf: tt.conf}
+			got, err := p.NeedsRehash(tt.hash)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NeedsRehash() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPbkdf2_Recognizes(t *testing.T) {
+	p := Pbkdf2{conf: DefaultConfig}
+	assert.True(t, p.Recognizes("pbkdf2_sha1$4096$c9Bp0I0FRcXSBmuOPrcD2w$dTCHD12APSrk1gToimJV5Qiz2jactN6vMgDF64tuALg"))
+	assert.True(t, p.Recognizes("pbkdf2_sha256$4096$jy6BcRAh36wA20njEWNw6g$pyGrYuJ+bGP2r8DnXkdFZ8hwBuBfQyKF7/OdAQ/dv1U"))
+	assert.False(t, p.Recognizes("pbkdf2_sha256$260000$seasalt$Lm/Jc/XyS1circpZcvY65vDCFxDD4kvL4qr0wdZtQ/8="))
+	assert.False(t, p.Recognizes("pbkdf2_md5$4096$jy6BcRAh36wA20njEWNw6g$pyGrYuJ+bGP2r8DnXkdFZ8hwBuBfQyKF7"))
+	assert.False(t, p.Recognizes("$argon2i$v=19$m=32768,t=3,p=2$VDkrfTNOys4cBijO2rNTBw$2NP3RaDtHrXrMU+kKlcyTvxjyZOfHYoSAxmUjxS4w1Q"))
+}
+
+func Test_newConfig(t *testing.T) {
+	rawConf := func(update map[string]interface{}) *map[string]interface{} {
+		conf := map[string]interface{}{
+			"iterations":  600000,
+			"salt_length": 16,
+			"key_length":  32,
+			"func":        "sha256",
+		}
+		for k, v := range update {
+			conf[k] = v
+		}
+		return &conf
+	}
+
+	tests := []struct {
+		name    string
+		update  map[string]interface{}
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			want: 600000,
+		},
+		{
+			name:    "unknown function",
+			update:  map[string]interface{}{"func": "md5"},
+			wantErr: true,
+		},
+		{
+			name:    "mistyped value",
+			update:  map[string]interface{}{"iterations": "600000"},
+			wantErr: true,
+		},
+		{
+			name:    "missing key",
+			update:  map[string]interface{}{"func": nil},
+			wantErr: true,
+		},
+		{
+			name:    "weak iterations",
+			update:  map[string]interface{}{"iterations": 1},
+			wantErr: true,
+		},
+		{
+			name:    "weak iterations of sha1",
+			update:  map[string]interface{}{"func": "sha1"},
+			wantErr: true,
+		},
+		{
+			name:   "weak iterations allowed",
+			update: map[string]interface{}{"iterations": 1, "allow_weak": true},
+			want:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newConfig(rawConf(tt.update))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newConfig() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr {
+				assert.Equal(t, tt.want, got.Iterations)
+			}
+		})
+	}
+}
+
+func Test_DefaultConfig(t *testing.T) {
+	conf, err := newConfig(&map[string]interface{}{
+		"iterations":  DefaultConfig.Iterations,
+		"salt_length": DefaultConfig.SaltLen,
+		"key_length":  DefaultConfig.KeyLen,
+		"func":        DefaultConfig.FuncName,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultConfig.Iterations, conf.Iterations)
+}
@@ -0,0 +1,21 @@
+package phc
+
+import "gouth/pwhash"
+
+// AdapterName is the internal name of the adapter
+const AdapterName = "phc"
+
+// init initializes package by register adapter
+func init() {
+	pwhash.RegisterAdapter(AdapterName, phcAdapter{})
+}
+
+// phcAdapter represents adapter for hashes in PHC string format
+type phcAdapter struct {
+}
+
+// GetPwHasher returns verify-only PHC hasher. It has no settings,
+// since all parameters are contained in the hashes
+func (a phcAdapter) GetPwHasher(*pwhash.RawHashConfig) (pwhash.PwHasher, error) {
+	return Phc{}, nil
+}
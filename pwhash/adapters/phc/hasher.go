@@ -0,0 +1,246 @@
+package phc
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+	"gouth/pwhash"
+	"hash"
+	"strconv"
+	"strings"
+)
+
+// Phc represents verify-only hasher of the hashes in PHC string format:
+//
+//	$<id>[$v=<version>][$<param>=<value>(,<param>=<value>)*][$<salt>[$<hash>]]
+//
+// Supported functions are argon2i, argon2id, scrypt, pbkdf2-sha1, pbkdf2-sha256 and pbkdf2-sha512
+type Phc struct {
+}
+
+var ErrInvalidHash = errors.New("phc: the encoded pwhash is not in the correct format")
+
+// Hash represents parsed PHC string
+type Hash struct {
+	// Function identifier
+	ID string
+
+	// Version of the function, it's zero if absent
+	Version int
+
+	// Function parameters
+	Params map[string]string
+
+	Salt []byte
+	Key  []byte
+}
+
+// HashPw returns ErrVerifyOnly, since PHC hasher only verifies imported hashes
+func (p Phc) HashPw(string) (string, error) {
+	return "", pwhash.ErrVerifyOnly
+}
+
+// VerifyOnly marks the hasher as verify-only, so it's accepted as legacy hasher only
+func (p Phc) VerifyOnly() {}
+
+// ComparePw performs a constant-time comparison between a plain-text password and
+// PHC string, using the function, parameters and salt contained in it.
+// It returns true if they match, otherwise it returns false.
+func (p Phc) ComparePw(pw string, hash string) (bool, error) {
+	h, err := Parse(hash)
+	if err != nil {
+		return false, err
+	}
+
+	otherKey, err := h.derive(pw)
+	if err != nil {
+		return false, err
+	}
+
+	if subtle.ConstantTimeCompare(h.Key, otherKey) == 1 {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// NeedsRehash always returns true for recognized hashes, so users are moved to the primary hasher
+func (p Phc) NeedsRehash(hash string) (bool, error) {
+	if _, err := Parse(hash); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Recognizes checks whether the hash is PHC string of the supported function
+func (p Phc) Recognizes(hash string) bool {
+	_, err := Parse(hash)
+	return err == nil
+}
+
+// Parse parses PHC string of the supported function.
+// Salt and hash are decoded from base64 without padding, as the format requires
+func Parse(hash string) (*Hash, error) {
+	vals := strings.Split(hash, "$")
+	if len(vals) < 4 || vals[0] != "" {
+		return nil, ErrInvalidHash
+	}
+
+	h := &Hash{ID: vals[1], Params: map[string]string{}}
+	vals = vals[2:]
+
+	if strings.HasPrefix(vals[0], "v=") {
+		v, err := strconv.Atoi(vals[0][2:])
+		if err != nil {
+			return nil, ErrInvalidHash
+		}
+		h.Version = v
+		vals = vals[1:]
+	}
+
+	if len(vals) != 3 {
+		return nil, ErrInvalidHash
+	}
+
+	for _, param := range strings.Split(vals[0], ",") {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, ErrInvalidHash
+		}
+		h.Params[kv[0]] = kv[1]
+	}
+
+	var err error
+	if h.Salt, err = base64.RawStdEncoding.DecodeString(vals[1]); err != nil {
+		return nil, err
+	}
+	if h.Key, err = base64.RawStdEncoding.DecodeString(vals[2]); err != nil {
+		return nil, err
+	}
+
+	if len(h.Key) == 0 {
+		return nil, ErrInvalidHash
+	}
+
+	if _, err := h.kdf(); err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// derive derives key of the same length as the hash key from the password
+func (h *Hash) derive(pw string) ([]byte, error) {
+	kdf, err := h.kdf()
+	if err != nil {
+		return nil, err
+	}
+	return kdf(pw)
+}
+
+// kdf validates function parameters and returns key derivation function
+func (h *Hash) kdf() (func(pw string) ([]byte, error), error) {
+	keyLen := len(h.Key)
+
+	switch h.ID {
+	case "argon2i", "argon2id":
+		if h.Version != argon2.Version {
+			return nil, fmt.Errorf("phc: incompatible version of argon2")
+		}
+
+		params, err := h.intParams("m", "t", "p")
+		if err != nil {
+			return nil, err
+		}
+		m, t, p := params[0], params[1], params[2]
+
+		if p > 255 {
+			return nil, fmt.Errorf("phc: invalid p parameter")
+		}
+
+		if h.ID == "argon2i" {
+			return func(pw string) ([]byte, error) {
+				return argon2.Key([]byte(pw), h.Salt, uint32(t), uint32(m), uint8(p), uint32(keyLen)), nil
+			}, nil
+		}
+		return func(pw string) ([]byte, error) {
+			return argon2.IDKey([]byte(pw), h.Salt, uint32(t), uint32(m), uint8(p), uint32(keyLen)), nil
+		}, nil
+
+	case "scrypt":
+		params, err := h.intParams("r", "p")
+		if err != nil {
+			return nil, err
+		}
+		r, p := params[0], params[1]
+
+		var n int
+		if _, ok := h.Params["ln"]; ok {
+			ln, err := h.intParams("ln")
+			if err != nil {
+				return nil, err
+			}
+			if ln[0] <= 0 || ln[0] >= 63 {
+				return nil, ErrInvalidHash
+			}
+			n = 1 << uint(ln[0])
+		} else {
+			nParam, err := h.intParams("n")
+			if err != nil {
+				return nil, err
+			}
+			n = nParam[0]
+		}
+
+		return func(pw string) ([]byte, error) {
+			return scrypt.Key([]byte(pw), h.Salt, n, r, p, keyLen)
+		}, nil
+
+	case "pbkdf2-sha1", "pbkdf2-sha256", "pbkdf2-sha512":
+		iter, err := h.intParams("i")
+		if err != nil {
+			return nil, err
+		}
+
+		var f func() hash.Hash
+		switch h.ID {
+		case "pbkdf2-sha1":
+			f = sha1.New
+		case "pbkdf2-sha256":
+			f = sha256.New
+		default:
+			f = sha512.New
+		}
+
+		return func(pw string) ([]byte, error) {
+			return pbkdf2.Key([]byte(pw), h.Salt, iter[0], keyLen, f), nil
+		}, nil
+	}
+
+	return nil, fmt.Errorf("phc: function '%s' don't supported", h.ID)
+}
+
+// intParams returns positive integer values of the required parameters
+func (h *Hash) intParams(names ...string) (values []int, err error) {
+	values = make([]int, len(names))
+
+	for i, name := range names {
+		raw, ok := h.Params[name]
+		if !ok {
+			return nil, fmt.Errorf("phc: missing %s parameter", name)
+		}
+
+		if values[i], err = strconv.Atoi(raw); err != nil || values[i] <= 0 {
+			return nil, fmt.Errorf("phc: invalid %s parameter", name)
+		}
+	}
+
+	return values, nil
+}
@@ -0,0 +1,113 @@
+package phc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gouth/pwhash"
+	"testing"
+)
+
+func TestPhc_Hash(t *testing.T) {
+	_, err := Phc{}.HashPw("qwerty")
+	assert.Equal(t, pwhash.ErrVerifyOnly, err)
+}
+
+func TestPhc_Compare(t *testing.T) {
+	type args struct {
+		data string
+		hash string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    bool
+		wantErr bool
+	}{
+		{
+			name: "argon2id valid data",
+			args: args{
+				data: "qwerty",
+				hash: "$argon2id$v=19$m=32768,t=3,p=2$7Jr8EtPeJsqJ1RxoxHC4eQ$XfSHQ28xgqc2/2LyE4YEkAI2CIilixOAvjh2Ds2s0+Y",
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "argon2i invalid data",
+			args: args{
+				data: "123456",
+				hash: "$argon2i$v=19$m=32768,t=3,p=2$VDkrfTNOys4cBijO2rNTBw$2NP3RaDtHrXrMU+kKlcyTvxjyZOfHYoSAxmUjxS4w1Q",
+			},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name: "pbkdf2-sha256 valid data",
+			args: args{
+				data: "qwerty",
+				hash: "$pbkdf2-sha256$i=10000$c2FsdHNhbHRzYWx0c2FsdA$y/SjxLMCukWQXQc5oGCUkbocYVBF6vezgeXXtSGzZTo",
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "scrypt valid data",
+			args: args{
+				data: "qwerty",
+				hash: "$scrypt$ln=14,r=8,p=1$c2FsdHNhbHRzYWx0c2FsdA$SR9+7RbyE4hHR+3BH+n/BjXp2n6ZZaoTmgH2kB/J/rQ",
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "argon2 incompatible version",
+			args: args{
+				data: "qwerty",
+				hash: "$argon2id$v=16$m=32768,t=3,p=2$7Jr8EtPeJsqJ1RxoxHC4eQ$XfSHQ28xgqc2/2LyE4YEkAI2CIilixOAvjh2Ds2s0+Y",
+			},
+			want:    false,
+			wantErr: true,
+		},
+		{
+			name: "missing parameter",
+			args: args{
+				data: "qwerty",
+				hash: "$argon2id$v=19$m=32768,t=3$7Jr8EtPeJsqJ1RxoxHC4eQ$XfSHQ28xgqc2/2LyE4YEkAI2CIilixOAvjh2Ds2s0+Y",
+			},
+			want:    false,
+			wantErr: true,
+		},
+		{
+			name: "unsupported function",
+			args: args{
+				data: "qwerty",
+				hash: "$argon2d$v=19$m=32768,t=3,p=2$7Jr8EtPeJsqJ1RxoxHC4eQ$XfSHQ28xgqc2/2LyE4YEkAI2CIilixOAvjh2Ds2s0+Y",
+			},
+			want:    false,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Phc{}.ComparePw(tt.args.data, tt.args.hash)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ComparePw() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ComparePw() got = %v, want %v", got, tt.want)
+			}
+
+			assert.Equal(t, !tt.wantErr, Phc{}.Recognizes(tt.args.hash))
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	h, err := Parse("$pbkdf2-sha256$i=10000$c2FsdHNhbHRzYWx0c2FsdA$y/SjxLMCukWQXQc5oGCUkbocYVBF6vezgeXXtSGzZTo")
+	assert.NoError(t, err)
+	assert.Equal(t, "pbkdf2-sha256", h.ID)
+	assert.Equal(t, 0, h.Version)
+	assert.Equal(t, map[string]string{"i": "10000"}, h.Params)
+	assert.Equal(t, []byte("saltsaltsaltsalt"), h.Salt)
+	assert.Len(t, h.Key, 32)
+}
@@ -0,0 +1,148 @@
+package scrypt
+
+import (
+	"encoding/base64"
+	"fmt"
+	"gouth/pwhash"
+)
+
+const (
+	// AdapterName is the internal name of the adapter
+	AdapterName = "scrypt"
+
+	// FirebaseAdapterName is the internal name of the Firebase modified scrypt adapter
+	FirebaseAdapterName = "firebase_scrypt"
+)
+
+// init initializes package by register adapters
+func init() {
+	pwhash.RegisterAdapter(AdapterName, scryptAdapter{})
+	pwhash.RegisterAdapter(FirebaseAdapterName, firebaseAdapter{})
+}
+
+// scryptAdapter represents adapter for scrypt pwhash algorithm
+type scryptAdapter struct {
+}
+
+// firebaseAdapter represents adapter for Firebase modified scrypt pwhash algorithm
+type firebaseAdapter struct {
+}
+
+// GetHasher returns Scrypt hasher with the given settings
+func (a scryptAdapter) GetPwHasher(rawConf *pwhash.RawHashConfig) (pwhash.PwHasher, error) {
+	config, err := newConfig(rawConf)
+	if err != nil {
+		return nil, err
+	}
+
+	return Scrypt{conf: config}, nil
+}
+
+// GetHasher returns Firebase hasher with the given settings
+func (a firebaseAdapter) GetPwHasher(rawConf *pwhash.RawHashConfig) (pwhash.PwHasher, error) {
+	config, err := newFirebaseConfig(rawConf)
+	if err != nil {
+		return nil, err
+	}
+
+	return Firebase{conf: config}, nil
+}
+
+// newConfig creates new HashConfig struct from the raw data, parsed from the config file
+func newConfig(rawConf *pwhash.RawHashConfig) (*HashConfig, error) {
+	requiredKeys := []string{"n", "r", "p", "salt_length", "key_length"}
+	if err := pwhash.CheckKeys(rawConf, requiredKeys); err != nil {
+		return nil, err
+	}
+
+	values, err := getInts(rawConf, requiredKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	conf := &HashConfig{
+		N:       values["n"],
+		R:       values["r"],
+		P:       values["p"],
+		SaltLen: values["salt_length"],
+		KeyLen:  values["key_length"],
+	}
+
+	if conf.N <= 1 || conf.N&(conf.N-1) != 0 {
+		return nil, fmt.Errorf("pwhash config: n must be a power of two greater than 1")
+	}
+
+	if conf.R <= 0 || conf.P <= 0 || conf.SaltLen <= 0 || conf.KeyLen <= 0 {
+		return nil, fmt.Errorf("pwhash config: r, p, salt_length and key_length must be positive")
+	}
+
+	if uint64(conf.R)*uint64(conf.P) >= 1<<30 {
+		return nil, fmt.Errorf("pwhash config: r*p must be less than 2^30")
+	}
+
+	floors := []pwhash.Floor{
+		{Key: "n*r*p", Value: conf.N * conf.R * conf.P, Min: MinConfig.N * MinConfig.R * MinConfig.P},
+		{Key: "salt_length", Value: conf.SaltLen, Min: MinConfig.SaltLen},
+		{Key: "key_length", Value: conf.KeyLen, Min: MinConfig.KeyLen},
+	}
+	if err := pwhash.CheckFloors(rawConf, floors); err != nil {
+		return nil, err
+	}
+
+	return conf, nil
+}
+
+// newFirebaseConfig creates new FirebaseConfig struct from the raw data, parsed from the config file.
+// Values are taken from the password hash parameters of the Firebase project
+func newFirebaseConfig(rawConf *pwhash.RawHashConfig) (*FirebaseConfig, error) {
+	requiredKeys := []string{"signer_key", "salt_separator", "rounds", "mem_cost"}
+	if err := pwhash.CheckKeys(rawConf, requiredKeys); err != nil {
+		return nil, err
+	}
+
+	values, err := getInts(rawConf, requiredKeys[2:])
+	if err != nil {
+		return nil, err
+	}
+
+	conf := &FirebaseConfig{
+		Rounds:  values["rounds"],
+		MemCost: values["mem_cost"],
+	}
+
+	if conf.Rounds <= 0 || conf.MemCost <= 0 || conf.MemCost >= 32 {
+		return nil, fmt.Errorf("pwhash config: rounds must be positive and mem_cost must be between 1 and 31")
+	}
+
+	for key, dst := range map[string]*[]byte{"signer_key": &conf.SignerKey, "salt_separator": &conf.SaltSeparator} {
+		value, err := pwhash.GetString(rawConf, key)
+		if err != nil {
+			return nil, err
+		}
+
+		if *dst, err = base64.StdEncoding.DecodeString(value); err != nil {
+			return nil, fmt.Errorf("pwhash config: %s must be a base64 string", key)
+		}
+	}
+
+	if len(conf.SignerKey) == 0 {
+		return nil, fmt.Errorf("pwhash config: signer_key can't be empty")
+	}
+
+	return conf, nil
+}
+
+// getInts returns integer values of the required keys
+func getInts(rawConf *pwhash.RawHashConfig, keys []string) (map[string]int, error) {
+	values := make(map[string]int, len(keys))
+
+	for _, key := range keys {
+		value, err := pwhash.GetInt(rawConf, key)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = value
+	}
+
+	return values, nil
+}
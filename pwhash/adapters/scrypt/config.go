@@ -0,0 +1,54 @@
+package scrypt
+
+// DefaultConfig provides some sane default settings for hashing passwords
+var DefaultConfig = &HashConfig{
+	N:       1 << 15,
+	R:       8,
+	P:       3,
+	SaltLen: 16,
+	KeyLen:  32,
+}
+
+// MinConfig provides security floors for the settings from the config file.
+// The product n*r*p is compared, so lower N can be compensated by higher parallelization.
+// It's the lowest of the OWASP recommended options (N=2^13, r=8, p=10)
+var MinConfig = &HashConfig{
+	N:       1 << 13,
+	R:       8,
+	P:       10,
+	SaltLen: 16,
+	KeyLen:  16,
+}
+
+// HashConfig represents parsed pwhash config from the config file
+type HashConfig struct {
+	// CPU/memory cost parameter. Must be a power of two greater than 1
+	N int
+
+	// Block size parameter
+	R int
+
+	// Parallelization parameter
+	P int
+
+	// Length of the random salt. 16 bytes is recommended for password hashing
+	SaltLen int
+
+	// Length of the generated key. 16 bytes or more is recommended
+	KeyLen int
+}
+
+// FirebaseConfig represents password hash parameters of the Firebase project
+type FirebaseConfig struct {
+	// Key, which is encrypted with the derived key to get the pwhash
+	SignerKey []byte
+
+	// Bytes appended to the salt of every user
+	SaltSeparator []byte
+
+	// Block size parameter of scrypt
+	Rounds int
+
+	// Base-2 logarithm of the CPU/memory cost parameter of scrypt
+	MemCost int
+}
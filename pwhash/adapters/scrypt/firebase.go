@@ -0,0 +1,147 @@
+package scrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"golang.org/x/crypto/scrypt"
+	"strings"
+)
+
+// Firebase represents hasher of the Firebase Auth modified scrypt algorithm.
+// It's used for verifying passwords of the users imported from Firebase
+type Firebase struct {
+	conf *FirebaseConfig
+}
+
+// firebaseSaltLen is the length of the salt generated by Firebase
+const firebaseSaltLen = 12
+
+// firebaseKeyLen is the length of the derived key, which is used as AES-256 key
+const firebaseKeyLen = 32
+
+// HashPw returns a Firebase pwhash of a plain-text password using the project parameters.
+// The returned pwhash contains the base64-encoded salt and password hash from the
+// Firebase users export, prefixed by the scrypt parameters. It looks like this:
+//
+//	$firebase-scrypt$r=8,m=14$42xEC+ixf3L2lw==$lSrfV15cpx95/sZS2W9c9Kp6i/LVgQNDNC/qzrCnh1SAyZvqmZqAjTdn3aoItz+VHjoZilo78198JAdRuid5lQ==
+//
+// Base64 values are padded, as in the export, so they can be copied as is
+func (f Firebase) HashPw(pw string) (string, error) {
+	salt := make([]byte, firebaseSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key, err := f.deriveKey(pw, salt, f.conf.Rounds, f.conf.MemCost)
+	if err != nil {
+		return "", err
+	}
+
+	hash := fmt.Sprintf("$firebase-scrypt$r=%d,m=%d$%s$%s",
+		f.conf.Rounds,
+		f.conf.MemCost,
+		base64.StdEncoding.EncodeToString(salt),
+		base64.StdEncoding.EncodeToString(key),
+	)
+
+	return hash, nil
+}
+
+// ComparePw performs a constant-time comparison between a plain-text password and
+// Firebase pwhash, using the parameters and salt contained in the pwhash.
+// It returns true if they match, otherwise it returns false.
+func (f Firebase) ComparePw(pw string, hash string) (bool, error) {
+	rounds, memCost, salt, key, err := decodeFirebasePwHash(hash)
+	if err != nil {
+		return false, err
+	}
+
+	otherKey, err := f.deriveKey(pw, salt, rounds, memCost)
+	if err != nil {
+		return false, err
+	}
+
+	if subtle.ConstantTimeCompare(key, otherKey) == 1 {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// NeedsRehash checks whether the hash parameters differ from the project parameters.
+// Firebase hashes are usually verified as legacy ones, so the chain rehashes them anyway
+func (f Firebase) NeedsRehash(hash string) (bool, error) {
+	rounds, memCost, _, _, err := decodeFirebasePwHash(hash)
+	if err != nil {
+		return false, err
+	}
+
+	return rounds != f.conf.Rounds || memCost != f.conf.MemCost, nil
+}
+
+// Recognizes checks whether the hash is Firebase scrypt hash
+func (f Firebase) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, "$firebase-scrypt$")
+}
+
+// MemoryCost returns the amount of memory used by verifying one password (in kibibytes)
+func (f Firebase) MemoryCost() int {
+	return 128 * f.conf.Rounds * ((1 << uint(f.conf.MemCost)) + 1) / 1024
+}
+
+// deriveKey derives key from the password with scrypt, using the salt followed by the salt separator,
+// and encrypts the signer key with it by AES-256 in CTR mode with zero IV
+func (f Firebase) deriveKey(pw string, salt []byte, rounds, memCost int) ([]byte, error) {
+	fullSalt := make([]byte, 0, len(salt)+len(f.conf.SaltSeparator))
+	fullSalt = append(fullSalt, salt...)
+	fullSalt = append(fullSalt, f.conf.SaltSeparator...)
+
+	derivedKey, err := scrypt.Key([]byte(pw), fullSalt, 1<<uint(memCost), rounds, 1, firebaseKeyLen)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(derivedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	key := make([]byte, len(f.conf.SignerKey))
+	cipher.NewCTR(block, make([]byte, aes.BlockSize)).XORKeyStream(key, f.conf.SignerKey)
+
+	return key, nil
+}
+
+// decodeFirebasePwHash parses Firebase pwhash and returns its scrypt parameters, salt and key
+func decodeFirebasePwHash(hash string) (int, int, []byte, []byte, error) {
+	vals := strings.Split(hash, "$")
+	if len(vals) != 5 || vals[1] != "firebase-scrypt" {
+		return 0, 0, nil, nil, ErrInvalidHash
+	}
+
+	var rounds, memCost int
+	_, err := fmt.Sscanf(vals[2], "r=%d,m=%d", &rounds, &memCost)
+	if err != nil {
+		return 0, 0, nil, nil, err
+	}
+
+	if rounds <= 0 || memCost <= 0 || memCost >= 32 {
+		return 0, 0, nil, nil, ErrInvalidHash
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(vals[3])
+	if err != nil {
+		return 0, 0, nil, nil, err
+	}
+
+	key, err := base64.StdEncoding.DecodeString(vals[4])
+	if err != nil {
+		return 0, 0, nil, nil, err
+	}
+
+	return rounds, memCost, salt, key, nil
+}
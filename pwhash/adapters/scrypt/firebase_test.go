@@ -0,0 +1,153 @@
+package scrypt
+
+import (
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// firebaseTestConfig holds password hash parameters of the sample Firebase project
+var firebaseTestConfig = &FirebaseConfig{
+	SignerKey:     mustDecode("jxspr8Ki0RYycVU8zykbdLGjFQ3McFUH0uiiTvC8pVMXAn210wjLNmdZJzxUECKbm0QsEmYUSDzZvpjeJ9WmXA=="),
+	SaltSeparator: mustDecode("Bw=="),
+	Rounds:        8,
+	MemCost:       14,
+}
+
+func mustDecode(s string) []byte {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestFirebase_Hash(t *testing.T) {
+	f := Firebase{conf: firebaseTestConfig}
+
+	hash, err := f.HashPw("qwerty")
+	assert.NoError(t, err)
+
+	isMatch, err := f.ComparePw("qwerty", hash)
+	assert.NoError(t, err)
+	assert.True(t, isMatch)
+}
+
+func TestFirebase_Compare(t *testing.T) {
+	type args struct {
+		data string
+		hash string
+	}
+	tests := []struct {
+		name    string
+		conf    *FirebaseConfig
+		args    args
+		want    bool
+		wantErr bool
+	}{
+		{
+			name: "firebase exported user",
+			conf: firebaseTestConfig,
+			args: args{
+				data: "user1password",
+				hash: "$firebase-scrypt$r=8,m=14$42xEC+ixf3L2lw==$lSrfV15cpx95/sZS2W9c9Kp6i/LVgQNDNC/qzrCnh1SAyZvqmZqAjTdn3aoItz+VHjoZilo78198JAdRuid5lQ==",
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "firebase invalid data",
+			conf: firebaseTestConfig,
+			args: args{
+				data: "user2password",
+				hash: "$firebase-scrypt$r=8,m=14$42xEC+ixf3L2lw==$lSrfV15cpx95/sZS2W9c9Kp6i/LVgQNDNC/qzrCnh1SAyZvqmZqAjTdn3aoItz+VHjoZilo78198JAdRuid5lQ==",
+			},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name: "firebase other signer key",
+			conf: &FirebaseConfig{
+				SignerKey:     mustDecode("AAAAAAAAAAAAAAAAAAAAAA=="),
+				SaltSeparator: firebaseTestConfig.SaltSeparator,
+			},
+			args: args{
+				data: "user1password",
+				hash: "$firebase-scrypt$r=8,m=14$42xEC+ixf3L2lw==$lSrfV15cpx95/sZS2W9c9Kp6i/LVgQNDNC/qzrCnh1SAyZvqmZqAjTdn3aoItz+VHjoZilo78198JAdRuid5lQ==",
+			},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name: "firebase generated hash",
+			conf: firebaseTestConfig,
+			args: args{
+				data: "qwerty",
+				hash: "$firebase-scrypt$r=8,m=14$uONKOVTJikqmohGI$7WnQWGEAB6irr3rEzQKtZY0PexbfckNVUVdNp7m1ITwxGFH6yxm9DHJJagBxRbuOMkSbbh22rQv1jpyZeJCVAA==",
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "firebase invalid pwhash",
+			conf: firebaseTestConfig,
+			args: args{
+				data: "user1password",
+				hash: "$firebase-scrypt$r=8,m=40$42xEC+ixf3L2lw==$lSrfV15cpx95",
+			},
+			want:    false,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := Firebase{conf: tt.conf}
+			got, err := f.ComparePw(tt.args.data, tt.args.hash)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ComparePw() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ComparePw() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFirebase_NeedsRehash(t *testing.T) {
+	f := Firebase{conf: firebaseTestConfig}
+
+	needsRehash, err := f.NeedsRehash("$firebase-scrypt$r=8,m=14$42xEC+ixf3L2lw==$lSrfV15cpx95")
+	assert.NoError(t, err)
+	assert.False(t, needsRehash)
+
+	needsRehash, err = f.NeedsRehash("$firebase-scrypt$r=8,m=13$42xEC+ixf3L2lw==$lSrfV15cpx95")
+	assert.NoError(t, err)
+	assert.True(t, needsRehash)
+}
+
+func Test_newFirebaseConfig(t *testing.T) {
+	conf, err := newFirebaseConfig(&map[string]interface{}{
+		"signer_key":     "jxspr8Ki0RYycVU8zykbdLGjFQ3McFUH0uiiTvC8pVMXAn210wjLNmdZJzxUECKbm0QsEmYUSDzZvpjeJ9WmXA==",
+		"salt_separator": "Bw==",
+		"rounds":         8,
+		"mem_cost":       14,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, firebaseTestConfig, conf)
+
+	_, err = newFirebaseConfig(&map[string]interface{}{
+		"signer_key": "jxspr8Ki0RYycVU8zykbdLGjFQ3McFUH0uiiTvC8pVMXAn210wjLNmdZJzxUECKbm0QsEmYUSDzZvpjeJ9WmXA==",
+		"rounds":     8,
+		"mem_cost":   14,
+	})
+	assert.Error(t, err)
+
+	_, err = newFirebaseConfig(&map[string]interface{}{
+		"signer_key":     "not base64",
+		"salt_separator": "Bw==",
+		"rounds":         8,
+		"mem_cost":       14,
+	})
+	assert.Error(t, err)
+}
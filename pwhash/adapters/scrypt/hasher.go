@@ -0,0 +1,116 @@
+package scrypt
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/scrypt"
+	"strings"
+)
+
+// Scrypt represents scrypt hasher
+type Scrypt struct {
+	conf *HashConfig
+}
+
+var ErrInvalidHash = errors.New("scrypt: the encoded pwhash is not in the correct format")
+
+// HashPw returns a Scrypt pwhash of a plain-text password using the provided algorithm
+// parameters. The returned pwhash contains the base64-encoded Scrypt derived key
+// prefixed by the salt and parameters. It looks like this:
+//
+//	$scrypt$n=32768,r=8,p=1$c29tZXNhbHQ$RdescudvJCsgt3ub+b+dWRWJTmaaJObG
+func (s Scrypt) HashPw(pw string) (string, error) {
+	salt := make([]byte, s.conf.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key, err := scrypt.Key([]byte(pw), salt, s.conf.N, s.conf.R, s.conf.P, s.conf.KeyLen)
+	if err != nil {
+		return "", err
+	}
+
+	hash := fmt.Sprintf("$scrypt$n=%d,r=%d,p=%d$%s$%s",
+		s.conf.N,
+		s.conf.R,
+		s.conf.P,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+
+	return hash, nil
+}
+
+// ComparePw performs a constant-time comparison between a plain-text password and
+// Scrypt pwhash, using the parameters and salt contained in the pwhash.
+// It returns true if they match, otherwise it returns false.
+func (s Scrypt) ComparePw(pw string, hash string) (bool, error) {
+	conf, salt, key, err := decodePwHash(hash)
+	if err != nil {
+		return false, err
+	}
+
+	otherKey, err := scrypt.Key([]byte(pw), salt, conf.N, conf.R, conf.P, conf.KeyLen)
+	if err != nil {
+		return false, err
+	}
+
+	if subtle.ConstantTimeCompare(key, otherKey) == 1 {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// NeedsRehash checks whether the hash parameters differ from the hasher settings,
+// so the password should be hashed again
+func (s Scrypt) NeedsRehash(hash string) (bool, error) {
+	conf, _, _, err := decodePwHash(hash)
+	if err != nil {
+		return false, err
+	}
+
+	return *conf != *s.conf, nil
+}
+
+// Recognizes checks whether the hash is scrypt hash
+func (s Scrypt) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, "$scrypt$")
+}
+
+// MemoryCost returns the amount of memory used by hashing one password (in kibibytes)
+func (s Scrypt) MemoryCost() int {
+	return 128 * s.conf.R * (s.conf.N + s.conf.P) / 1024
+}
+
+// decodePwHash expects a pwhash created from this package, and parses it to return the config
+// used to create it, as well as the salt and key
+func decodePwHash(hash string) (*HashConfig, []byte, []byte, error) {
+	vals := strings.Split(hash, "$")
+	if len(vals) != 5 || vals[1] != "scrypt" {
+		return nil, nil, nil, ErrInvalidHash
+	}
+
+	conf := &HashConfig{}
+	_, err := fmt.Sscanf(vals[2], "n=%d,r=%d,p=%d", &conf.N, &conf.R, &conf.P)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(vals[3])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	conf.SaltLen = len(salt)
+
+	key, err := base64.RawStdEncoding.DecodeString(vals[4])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	conf.KeyLen = len(key)
+
+	return conf, salt, key, nil
+}
@@ -0,0 +1,203 @@
+package scrypt
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestScrypt_Hash(t *testing.T) {
+	type fields struct {
+		conf *HashConfig
+	}
+	type args struct {
+		data string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr bool
+	}{
+		{
+			name:    "scrypt default",
+			fields:  fields{conf: DefaultConfig},
+			args:    args{data: "qwerty"},
+			wantErr: false,
+		},
+		{
+			name: "scrypt 32/64",
+			fields: fields{conf: &HashConfig{
+				N:       1024,
+				R:       8,
+				P:       2,
+				SaltLen: 32,
+				KeyLen:  64,
+			}},
+			args:    args{data: "hdg36*/*12bd6"},
+			wantErr: false,
+		},
+		{
+			name: "scrypt invalid n",
+			fields: fields{conf: &HashConfig{
+				N:       1000,
+				R:       8,
+				P:       1,
+				SaltLen: 16,
+				KeyLen:  32,
+			}},
+			args:    args{data: "hdg36*/*12bd6"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Scrypt{
+				conf: tt.fields.conf,
+			}
+			got, err := s.HashPw(tt.args.data)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("HashPw() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			isMatch, err := s.ComparePw(tt.args.data, got)
+			assert.NoError(t, err)
+			assert.True(t, isMatch)
+		})
+	}
+}
+
+func TestScrypt_Compare(t *testing.T) {
+	type fields struct {
+		conf *HashConfig
+	}
+	type args struct {
+		data string
+		hash string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:   "scrypt valid data",
+			fields: fields{conf: DefaultConfig},
+			args: args{
+				data: "qwerty",
+				hash: "$scrypt$n=1024,r=8,p=1$N+4On4m8NbAKQABkICLt8A$+A0bt7+1AFz4N6IYlxa+jnyKAPRqwd8P3mdz70y84UQ",
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name:   "scrypt invalid data",
+			fields: fields{conf: DefaultConfig},
+			args: args{
+				data: "123456",
+				hash: "$scrypt$n=1024,r=8,p=1$N+4On4m8NbAKQABkICLt8A$+A0bt7+1AFz4N6IYlxa+jnyKAPRqwd8P3mdz70y84UQ",
+			},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name:   "scrypt RFC 7914 vector",
+			fields: fields{conf: DefaultConfig},
+			args: args{
+				data: "password",
+				hash: "$scrypt$n=1024,r=8,p=16$TmFDbA$/bq+HJ00cgB4VucZDQHp/nxq18vII3gw53N2Y0s3MWIurzDZLiKjiG/xCSedmDDaxyevuUqD7m2DYMvfoswGQA",
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name:   "scrypt invalid pwhash",
+			fields: fields{conf: DefaultConfig},
+			args: args{
+				data: "qwerty",
+				hash: "$n=1024,r=8,p=1$N+4On4m8NbAKQABkICLt8A$+A0bt7+1AFz4N6IYlxa+jnyKAPRqwd8P3mdz70y84UQ",
+			},
+			want:    false,
+			wantErr: true,
+		},
+		{
+			name:   "scrypt invalid parameters",
+			fields: fields{conf: DefaultConfig},
+			args: args{
+				data: "qwerty",
+				hash: "$scrypt$n=1000,r=8,p=1$N+4On4m8NbAKQABkICLt8A$+A0bt7+1AFz4N6IYlxa+jnyKAPRqwd8P3mdz70y84UQ",
+			},
+			want:    false,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Scrypt{
+				conf: tt.fields.conf,
+			}
+			got, err := s.ComparePw(tt.args.data, tt.args.hash)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ComparePw() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ComparePw() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScrypt_NeedsRehash(t *testing.T) {
+	const hash = "$scrypt$n=1024,r=8,p=1$N+4On4m8NbAKQABkICLt8A$+A0bt7+1AFz4N6IYlxa+jnyKAPRqwd8P3mdz70y84UQ"
+
+	s := Scrypt{conf: &HashConfig{N: 1024, R: 8, P: 1, SaltLen: 16, KeyLen: 32}}
+	needsRehash, err := s.NeedsRehash(hash)
+	assert.NoError(t, err)
+	assert.False(t, needsRehash)
+
+	s = Scrypt{conf: DefaultConfig}
+	needsRehash, err = s.NeedsRehash(hash)
+	assert.NoError(t, err)
+	assert.True(t, needsRehash)
+
+	_, err = s.NeedsRehash("$scrypt$n=1024")
+	assert.Error(t, err)
+}
+
+func TestScrypt_Recognizes(t *testing.T) {
+	s := Scrypt{conf: DefaultConfig}
+	assert.True(t, s.Recognizes("$scrypt$n=1024,r=8,p=1$N+4On4m8NbAKQABkICLt8A$+A0bt7+1AFz4N6IYlxa+jnyKAPRqwd8P3mdz70y84UQ"))
+	assert.False(t, s.Recognizes("$firebase-scrypt$r=8,m=14$42xEC+ixf3L2lw==$lSrfV15cpx95"))
+	assert.False(t, s.Recognizes("$argon2i$v=19$m=32768,t=3,p=2$VDkrfTNOys4cBijO2rNTBw$2NP3RaDtHrXrMU"))
+}
+
+func Test_newConfig(t *testing.T) {
+	conf, err := newConfig(&map[string]interface{}{"n": 32768, "r": 8, "p": 3, "salt_length": 16, "key_length": 32})
+	assert.NoError(t, err)
+	assert.Equal(t, &HashConfig{N: 32768, R: 8, P: 3, SaltLen: 16, KeyLen: 32}, conf)
+
+	_, err = newConfig(&map[string]interface{}{"n": 16384, "r": 8, "p": 1, "salt_length": 16, "key_length": 32})
+	assert.Error(t, err)
+
+	conf, err = newConfig(&map[string]interface{}{"n": 16384, "r": 8, "p": 1, "salt_length": 16, "key_length": 32, "allow_weak": true})
+	assert.NoError(t, err)
+	assert.Equal(t, &HashConfig{N: 16384, R: 8, P: 1, SaltLen: 16, KeyLen: 32}, conf)
+
+	_, err = newConfig(&map[string]interface{}{"n": 32768, "r": 8, "p": 3, "salt_length": 16, "key_length": 32, "len": 32})
+	assert.Error(t, err)
+
+	_, err = newConfig(&map[string]interface{}{"n": 16384, "r": 8, "p": 1, "salt_length": 16})
+	assert.Error(t, err)
+
+	_, err = newConfig(&map[string]interface{}{"n": 10000, "r": 8, "p": 1, "salt_length": 16, "key_length": 32})
+	assert.Error(t, err)
+
+	_, err = newConfig(&map[string]interface{}{"n": 16384, "r": 0, "p": 1, "salt_length": 16, "key_length": 32})
+	assert.Error(t, err)
+}
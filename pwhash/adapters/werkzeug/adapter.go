@@ -0,0 +1,21 @@
+package werkzeug
+
+import "gouth/pwhash"
+
+// AdapterName is the internal name of the adapter
+const AdapterName = "werkzeug"
+
+// init initializes package by register adapter
+func init() {
+	pwhash.RegisterAdapter(AdapterName, werkzeugAdapter{})
+}
+
+// werkzeugAdapter represents adapter for hashes produced by Werkzeug generate_password_hash
+type werkzeugAdapter struct {
+}
+
+// GetPwHasher returns verify-only Werkzeug hasher. It has no settings,
+// since all parameters are contained in the hashes
+func (a werkzeugAdapter) GetPwHasher(*pwhash.RawHashConfig) (pwhash.PwHasher, error) {
+	return Werkzeug{}, nil
+}
@@ -0,0 +1,138 @@
+package werkzeug
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+	"gouth/pwhash"
+	"hash"
+	"strconv"
+	"strings"
+)
+
+// Werkzeug represents verify-only hasher of the hashes produced by Werkzeug.
+// They look like this:
+//
+//	pbkdf2:sha256:260000$c29tZXNhbHQ$<hex encoded key>
+//	scrypt:32768:8:1$c29tZXNhbHQ$<hex encoded key>
+//
+// Salt is used as is, without decoding
+type Werkzeug struct {
+}
+
+var ErrInvalidHash = errors.New("werkzeug: the encoded pwhash is not in the correct format")
+
+// scryptKeyLen is the length of the key derived by Werkzeug with scrypt
+const scryptKeyLen = 64
+
+// HashPw returns ErrVerifyOnly, since Werkzeug hasher only verifies imported hashes
+func (w Werkzeug) HashPw(string) (string, error) {
+	return "", pwhash.ErrVerifyOnly
+}
+
+// VerifyOnly marks the hasher as verify-only, so it's accepted as legacy hasher only
+func (w Werkzeug) VerifyOnly() {}
+
+// ComparePw performs a constant-time comparison between a plain-text password and
+// Werkzeug pwhash, using the method and salt contained in the pwhash.
+// It returns true if they match, otherwise it returns false.
+func (w Werkzeug) ComparePw(pw string, hash string) (bool, error) {
+	kdf, salt, key, err := decodePwHash(hash)
+	if err != nil {
+		return false, err
+	}
+
+	otherKey, err := kdf([]byte(pw), salt, len(key))
+	if err != nil {
+		return false, err
+	}
+
+	if subtle.ConstantTimeCompare(key, otherKey) == 1 {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// NeedsRehash always returns true for recognized hashes, so users are moved to the primary hasher
+func (w Werkzeug) NeedsRehash(hash string) (bool, error) {
+	if _, _, _, err := decodePwHash(hash); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Recognizes checks whether the hash is Werkzeug pbkdf2 or scrypt hash
+func (w Werkzeug) Recognizes(hash string) bool {
+	_, _, _, err := decodePwHash(hash)
+	return err == nil
+}
+
+// decodePwHash parses Werkzeug pwhash and returns key derivation function of its method,
+// as well as the salt and key
+func decodePwHash(pwHash string) (func(pw, salt []byte, keyLen int) ([]byte, error), []byte, []byte, error) {
+	vals := strings.Split(pwHash, "$")
+	if len(vals) != 3 || vals[1] == "" {
+		return nil, nil, nil, ErrInvalidHash
+	}
+
+	key, err := hex.DecodeString(vals[2])
+	if err != nil || len(key) == 0 {
+		return nil, nil, nil, ErrInvalidHash
+	}
+
+	method := strings.Split(vals[0], ":")
+	switch method[0] {
+	case "pbkdf2":
+		if len(method) != 3 {
+			return nil, nil, nil, ErrInvalidHash
+		}
+
+		var f func() hash.Hash
+		switch method[1] {
+		case "sha1":
+			f = sha1.New
+		case "sha256":
+			f = sha256.New
+		case "sha512":
+			f = sha512.New
+		default:
+			return nil, nil, nil, fmt.Errorf("werkzeug: function '%s' don't supported", method[1])
+		}
+
+		iter, err := strconv.Atoi(method[2])
+		if err != nil || iter <= 0 {
+			return nil, nil, nil, ErrInvalidHash
+		}
+
+		kdf := func(pw, salt []byte, keyLen int) ([]byte, error) {
+			return pbkdf2.Key(pw, salt, iter, keyLen, f), nil
+		}
+		return kdf, []byte(vals[1]), key, nil
+
+	case "scrypt":
+		if len(method) != 4 || len(key) != scryptKeyLen {
+			return nil, nil, nil, ErrInvalidHash
+		}
+
+		params := make([]int, 3)
+		for i, raw := range method[1:] {
+			if params[i], err = strconv.Atoi(raw); err != nil || params[i] <= 0 {
+				return nil, nil, nil, ErrInvalidHash
+			}
+		}
+
+		kdf := func(pw, salt []byte, keyLen int) ([]byte, error) {
+			return scrypt.Key(pw, salt, params[0], params[1], params[2], keyLen)
+		}
+		return kdf, []byte(vals[1]), key, nil
+	}
+
+	return nil, nil, nil, ErrInvalidHash
+}
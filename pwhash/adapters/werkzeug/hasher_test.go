@@ -0,0 +1,94 @@
+package werkzeug
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gouth/pwhash"
+	"testing"
+)
+
+func TestWerkzeug_Hash(t *testing.T) {
+	_, err := Werkzeug{}.HashPw("qwerty")
+	assert.Equal(t, pwhash.ErrVerifyOnly, err)
+}
+
+func TestWerkzeug_Compare(t *testing.T) {
+	type args struct {
+		data string
+		hash string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    bool
+		wantErr bool
+	}{
+		{
+			name: "pbkdf2 valid data",
+			args: args{
+				data: "qwerty",
+				hash: "pbkdf2:sha256:600000$Jnb7sJwS2tHTV3Ak$6594be4d3f31e8cf90bd80b36061c315a16d62b8b4cd86fc5d3d80f5f5c70e90",
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "pbkdf2 invalid data",
+			args: args{
+				data: "123456",
+				hash: "pbkdf2:sha256:600000$Jnb7sJwS2tHTV3Ak$6594be4d3f31e8cf90bd80b36061c315a16d62b8b4cd86fc5d3d80f5f5c70e90",
+			},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name: "scrypt valid data",
+			args: args{
+				data: "qwerty",
+				hash: "scrypt:32768:8:1$Jnb7sJwS2tHTV3Ak$94b4cd4eff4a06212a3442b0b1419e7ee8dee46198b316ea79de8c98727cab24c09c46ec01a6d50fbb5e162b9293f235870e2b4e0281c83af49e18f1f8fb2459",
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "pbkdf2 without iterations",
+			args: args{
+				data: "qwerty",
+				hash: "pbkdf2:sha256$Jnb7sJwS2tHTV3Ak$6594be4d3f31e8cf90bd80b36061c315a16d62b8b4cd86fc5d3d80f5f5c70e90",
+			},
+			want:    false,
+			wantErr: true,
+		},
+		{
+			name: "pbkdf2 unsupported function",
+			args: args{
+				data: "qwerty",
+				hash: "pbkdf2:md5:1000$Jnb7sJwS2tHTV3Ak$6594be4d3f31e8cf90bd80b36061c315",
+			},
+			want:    false,
+			wantErr: true,
+		},
+		{
+			name: "django pwhash",
+			args: args{
+				data: "qwerty",
+				hash: "pbkdf2_sha256$260000$seasalt$Lm/Jc/XyS1circpZcvY65vDCFxDD4kvL4qr0wdZtQ/8=",
+			},
+			want:    false,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Werkzeug{}.ComparePw(tt.args.data, tt.args.hash)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ComparePw() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ComparePw() got = %v, want %v", got, tt.want)
+			}
+
+			assert.Equal(t, !tt.wantErr, Werkzeug{}.Recognizes(tt.args.hash))
+		})
+	}
+}
@@ -0,0 +1,332 @@
+// Package calibrate picks hasher settings, which take the target time on the current host
+package calibrate
+
+import (
+	"errors"
+	"fmt"
+	"gouth/pwhash"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Options represents calibration constraints
+type Options struct {
+	// Adapter name: argon2, pbkdf2, bcrypt or scrypt
+	Alg string
+
+	// Maximum time of hashing one password
+	Target time.Duration
+
+	// Maximum memory used by hashing one password, in kibibytes. It's used by argon2 and scrypt
+	MemoryKiB int
+
+	// Number of threads used by argon2 and parallelization parameter of scrypt
+	Parallelism int
+
+	// Argon2 type (argon2i, argon2id)
+	Type string
+
+	// Pseudorandom function of pbkdf2
+	Func string
+}
+
+// Setting represents one key of the hasher settings
+type Setting struct {
+	Key   string
+	Value interface{}
+}
+
+// Result represents calibrated settings
+type Result struct {
+	Alg      string
+	Settings []Setting
+
+	// Measured time of hashing one password with the settings
+	Duration time.Duration
+
+	// Error of the settings validation, e.g. if they are below the security floors
+	Warning error
+}
+
+// Calibrator measures hashing time of the candidate settings
+type Calibrator struct {
+	// measure returns time of hashing one password with the given settings
+	measure func(alg string, rawConf pwhash.RawHashConfig) (time.Duration, error)
+}
+
+// New returns calibrator, which measures the real PwHasher implementations.
+// Each candidate is measured the given number of samples, the median one is taken
+func New(samples int) *Calibrator {
+	if samples < 1 {
+		samples = 1
+	}
+
+	return &Calibrator{measure: func(alg string, rawConf pwhash.RawHashConfig) (time.Duration, error) {
+		return measureHasher(alg, rawConf, samples)
+	}}
+}
+
+// Run picks settings of the adapter for the given constraints
+func (c *Calibrator) Run(opts Options) (*Result, error) {
+	if opts.Target <= 0 {
+		return nil, errors.New("calibrate: target time must be positive")
+	}
+
+	if opts.Parallelism <= 0 {
+		opts.Parallelism = runtime.NumCPU()
+		if opts.Parallelism > 4 {
+			opts.Parallelism = 4
+		}
+	}
+
+	var (
+		settings []Setting
+		err      error
+	)
+
+	switch opts.Alg {
+	case "argon2":
+		settings, err = c.argon2(opts)
+	case "pbkdf2":
+		settings, err = c.pbkdf2(opts)
+	case "bcrypt":
+		settings, err = c.bcrypt(opts)
+	case "scrypt":
+		settings, err = c.scrypt(opts)
+	default:
+		return nil, fmt.Errorf("calibrate: adapter %s isn't supported", opts.Alg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{Alg: opts.Alg, Settings: settings}
+	if res.Duration, err = c.measure(opts.Alg, toRawConf(settings, true)); err != nil {
+		return nil, err
+	}
+
+	// checks the settings without allow_weak, as they will be used
+	rawConf := toRawConf(settings, false)
+	_, res.Warning = pwhash.New(opts.Alg, &rawConf)
+
+	return res, nil
+}
+
+// argon2 uses the whole memory budget and picks the largest number of iterations fitting the target.
+// If one iteration doesn't fit, memory is halved
+func (c *Calibrator) argon2(opts Options) ([]Setting, error) {
+	if opts.MemoryKiB < 8*opts.Parallelism {
+		return nil, errors.New("calibrate: memory budget is too small for argon2")
+	}
+
+	argon2Type := opts.Type
+	if argon2Type == "" {
+		argon2Type = "argon2id"
+	}
+
+	settings := func(memory, iterations int) []Setting {
+		return []Setting{
+			{"type", argon2Type},
+			{"iterations", iterations},
+			{"parallelism", opts.Parallelism},
+			{"salt_length", 16},
+			{"key_length", 32},
+			{"memory", memory},
+		}
+	}
+
+	memory := opts.MemoryKiB
+	for {
+		d, err := c.measure("argon2", toRawConf(settings(memory, 1), true))
+		if err != nil {
+			return nil, err
+		}
+
+		if d <= opts.Target || memory/2 < 8*opts.Parallelism {
+			iterations, err := c.maxParam(opts.Target, 1, d, func(iterations int) (time.Duration, error) {
+				return c.measure("argon2", toRawConf(settings(memory, iterations), true))
+			})
+			if err != nil {
+				return nil, err
+			}
+			return settings(memory, iterations), nil
+		}
+
+		memory /= 2
+	}
+}
+
+// pbkdf2 picks the largest number of iterations fitting the target
+func (c *Calibrator) pbkdf2(opts Options) ([]Setting, error) {
+	funcName := opts.Func
+	if funcName == "" {
+		funcName = "sha256"
+	}
+
+	settings := func(iterations int) []Setting {
+		return []Setting{
+			{"iterations", iterations},
+			{"salt_length", 16},
+			{"key_length", 32},
+			{"func", funcName},
+		}
+	}
+
+	const probe = 10000
+	d, err := c.measure("pbkdf2", toRawConf(settings(probe), true))
+	if err != nil {
+		return nil, err
+	}
+
+	iterations, err := c.maxParam(opts.Target, probe, d, func(iterations int) (time.Duration, error) {
+		return c.measure("pbkdf2", toRawConf(settings(iterations), true))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return settings(iterations), nil
+}
+
+// bcrypt picks the largest cost fitting the target. Time doubles with each cost step
+func (c *Calibrator) bcrypt(opts Options) ([]Setting, error) {
+	const minCost, maxCost = 4, 31
+
+	cost := minCost
+	for ; cost < maxCost; cost++ {
+		d, err := c.measure("bcrypt", toRawConf([]Setting{{"cost", cost + 1}}, true))
+		if err != nil {
+			return nil, err
+		}
+
+		if d > opts.Target {
+			break
+		}
+	}
+
+	return []Setting{{"cost", cost}}, nil
+}
+
+// scrypt picks the largest power of two N fitting the target and the memory budget with r=8.
+// Memory used by scrypt is 128*N*r bytes
+func (c *Calibrator) scrypt(opts Options) ([]Setting, error) {
+	const r = 8
+
+	settings := func(n int) []Setting {
+		return []Setting{
+			{"n", n},
+			{"r", r},
+			{"p", opts.Parallelism},
+			{"salt_length", 16},
+			{"key_length", 32},
+		}
+	}
+
+	n := 2
+	for {
+		next := n * 2
+		if 128*next*r/1024 > opts.MemoryKiB {
+			break
+		}
+
+		d, err := c.measure("scrypt", toRawConf(settings(next), true))
+		if err != nil {
+			return nil, err
+		}
+
+		if d > opts.Target {
+			break
+		}
+		n = next
+	}
+
+	return settings(n), nil
+}
+
+// maxParam returns the largest value of the parameter, which hashing time linearly depends on,
+// fitting the target. It's estimated from the time d of the probe value and checked by measuring
+func (c *Calibrator) maxParam(target time.Duration, probe int, d time.Duration, measure func(int) (time.Duration, error)) (int, error) {
+	if d <= 0 {
+		d = time.Nanosecond
+	}
+
+	value := int(float64(probe) * float64(target) / float64(d))
+	if value < probe {
+		return probe, nil
+	}
+
+	for value > probe {
+		d, err := measure(value)
+		if err != nil {
+			return 0, err
+		}
+
+		if d <= target {
+			return value, nil
+		}
+
+		// estimation was too optimistic, takes the value down proportionally
+		next := int(float64(value) * float64(target) / float64(d))
+		if next >= value {
+			next = value - 1
+		}
+		value = next
+	}
+
+	return probe, nil
+}
+
+// YAML returns ready-to-paste hasher block
+func (r *Result) YAML() string {
+	var b strings.Builder
+
+	if r.Warning != nil {
+		fmt.Fprintf(&b, "# WARNING: %v\n", r.Warning)
+	}
+	fmt.Fprintf(&b, "# measured hashing time: %v\n", r.Duration.Round(time.Millisecond))
+	fmt.Fprintf(&b, "hasher:\n  alg: %q\n  settings:\n", r.Alg)
+
+	for _, s := range r.Settings {
+		if str, ok := s.Value.(string); ok {
+			fmt.Fprintf(&b, "    %s: %q\n", s.Key, str)
+		} else {
+			fmt.Fprintf(&b, "    %s: %v\n", s.Key, s.Value)
+		}
+	}
+
+	return b.String()
+}
+
+// measureHasher returns median time of hashing one password by the real hasher
+func measureHasher(alg string, rawConf pwhash.RawHashConfig, samples int) (time.Duration, error) {
+	h, err := pwhash.New(alg, &rawConf)
+	if err != nil {
+		return 0, err
+	}
+
+	durations := make([]time.Duration, samples)
+	for i := range durations {
+		start := time.Now()
+		if _, err := h.HashPw("calibrate password"); err != nil {
+			return 0, err
+		}
+		durations[i] = time.Since(start)
+	}
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	return durations[samples/2], nil
+}
+
+func toRawConf(settings []Setting, allowWeak bool) pwhash.RawHashConfig {
+	rawConf := pwhash.RawHashConfig{}
+	for _, s := range settings {
+		rawConf[s.Key] = s.Value
+	}
+
+	if allowWeak {
+		rawConf[pwhash.AllowWeakKey] = true
+	}
+	return rawConf
+}
@@ -0,0 +1,137 @@
+package calibrate
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gouth/pwhash"
+	"strings"
+	"testing"
+	"time"
+
+	_ "gouth/pwhash/adapters/argon2"
+	_ "gouth/pwhash/adapters/bcrypt"
+	_ "gouth/pwhash/adapters/pbkdf2"
+	_ "gouth/pwhash/adapters/scrypt"
+)
+
+// modelCalibrator returns calibrator, which estimates hashing time instead of measuring it
+func modelCalibrator() *Calibrator {
+	return &Calibrator{measure: func(alg string, rawConf pwhash.RawHashConfig) (time.Duration, error) {
+		switch alg {
+		case "argon2":
+			// 1ms per iteration over 1 MiB
+			return time.Duration(rawConf["iterations"].(int)*rawConf["memory"].(int)/1024) * time.Millisecond, nil
+		case "pbkdf2":
+			// 1ms per 1000 iterations
+			return time.Duration(rawConf["iterations"].(int)) * time.Microsecond, nil
+		case "bcrypt":
+			return time.Millisecond << uint(rawConf["cost"].(int)-4), nil
+		case "scrypt":
+			return time.Duration(rawConf["n"].(int)/1024) * time.Millisecond, nil
+		}
+		return 0, nil
+	}}
+}
+
+func settingsMap(settings []Setting) map[string]interface{} {
+	m := map[string]interface{}{}
+	for _, s := range settings {
+		m[s.Key] = s.Value
+	}
+	return m
+}
+
+func Test_Calibrator_Run(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     Options
+		expected map[string]interface{}
+		weak     bool
+	}{
+		{
+			name: "argon2",
+			opts: Options{Alg: "argon2", Target: 500 * time.Millisecond, MemoryKiB: 64 * 1024, Parallelism: 2},
+			expected: map[string]interface{}{
+				"type": "argon2id", "iterations": 7, "parallelism": 2,
+				"salt_length": 16, "key_length": 32, "memory": 64 * 1024,
+			},
+		},
+		{
+			name: "argon2 halves memory",
+			opts: Options{Alg: "argon2", Target: 50 * time.Millisecond, MemoryKiB: 256 * 1024, Parallelism: 1},
+			expected: map[string]interface{}{
+				"type": "argon2id", "iterations": 1, "parallelism": 1,
+				"salt_length": 16, "key_length": 32, "memory": 32 * 1024,
+			},
+			weak: true,
+		},
+		{
+			name: "pbkdf2",
+			opts: Options{Alg: "pbkdf2", Target: time.Second},
+			expected: map[string]interface{}{
+				"iterations": 1000000, "salt_length": 16, "key_length": 32, "func": "sha256",
+			},
+		},
+		{
+			name:     "bcrypt",
+			opts:     Options{Alg: "bcrypt", Target: 300 * time.Millisecond},
+			expected: map[string]interface{}{"cost": 12},
+		},
+		{
+			name: "scrypt limited by memory",
+			opts: Options{Alg: "scrypt", Target: time.Hour, MemoryKiB: 64 * 1024, Parallelism: 5},
+			expected: map[string]interface{}{
+				"n": 1 << 16, "r": 8, "p": 5, "salt_length": 16, "key_length": 32,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := modelCalibrator().Run(tt.opts)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, tt.expected, settingsMap(res.Settings))
+			assert.LessOrEqual(t, int64(res.Duration), int64(tt.opts.Target))
+			assert.Equal(t, tt.weak, res.Warning != nil)
+		})
+	}
+}
+
+func Test_Calibrator_Run_Errors(t *testing.T) {
+	_, err := modelCalibrator().Run(Options{Alg: "md5", Target: time.Second})
+	assert.Error(t, err)
+
+	_, err = modelCalibrator().Run(Options{Alg: "pbkdf2"})
+	assert.Error(t, err)
+
+	_, err = modelCalibrator().Run(Options{Alg: "argon2", Target: time.Second, MemoryKiB: 4, Parallelism: 1})
+	assert.Error(t, err)
+}
+
+func Test_Calibrator_Run_RealHasher(t *testing.T) {
+	res, err := New(1).Run(Options{Alg: "pbkdf2", Target: 10 * time.Millisecond})
+	if assert.NoError(t, err) {
+		assert.Greater(t, settingsMap(res.Settings)["iterations"], 0)
+	}
+}
+
+func Test_Result_YAML(t *testing.T) {
+	res := Result{
+		Alg:      "argon2",
+		Settings: []Setting{{"type", "argon2id"}, {"iterations", 3}},
+		Duration: 420 * time.Millisecond,
+	}
+
+	expected := strings.Join([]string{
+		"# measured hashing time: 420ms",
+		"hasher:",
+		`  alg: "argon2"`,
+		"  settings:",
+		`    type: "argon2id"`,
+		"    iterations: 3",
+		"",
+	}, "\n")
+	assert.Equal(t, expected, res.YAML())
+}
@@ -0,0 +1,78 @@
+package pwhash
+
+import "errors"
+
+var ErrUnknownHash = errors.New("pwhash: no hasher recognizes the hash format")
+
+// Chain is PwHasher, which hashes passwords with the primary hasher
+// and verifies hashes created by the primary or any of the legacy hashers.
+// It's used for migrating users from one algorithm to another
+type Chain struct {
+	primary PwHasher
+	legacy  []PwHasher
+}
+
+// NewChain returns hasher chain with the given primary and legacy hashers
+func NewChain(primary PwHasher, legacy ...PwHasher) *Chain {
+	return &Chain{primary: primary, legacy: legacy}
+}
+
+// HashPw hashes the password with the primary hasher
+func (c *Chain) HashPw(pw string) (string, error) {
+	return c.primary.HashPw(pw)
+}
+
+// ComparePw compares the password with the hash using the hasher, which recognizes the hash format
+func (c *Chain) ComparePw(pw string, hash string) (bool, error) {
+	h := c.hasherFor(hash)
+	if h == nil {
+		return false, ErrUnknownHash
+	}
+
+	return h.ComparePw(pw, hash)
+}
+
+// NeedsRehash checks whether the hash was created by the legacy hasher
+// or by the primary one with outdated parameters
+func (c *Chain) NeedsRehash(hash string) (bool, error) {
+	if c.primary.Recognizes(hash) {
+		return c.primary.NeedsRehash(hash)
+	}
+
+	if c.hasherFor(hash) == nil {
+		return false, ErrUnknownHash
+	}
+	return true, nil
+}
+
+// Recognizes checks whether any hasher of the chain recognizes the hash format
+func (c *Chain) Recognizes(hash string) bool {
+	return c.hasherFor(hash) != nil
+}
+
+// MemoryCost returns the largest memory cost among the hashers of the chain
+func (c *Chain) MemoryCost() int {
+	cost := MemoryCost(c.primary)
+	for _, h := range c.legacy {
+		if legacyCost := MemoryCost(h); legacyCost > cost {
+			cost = legacyCost
+		}
+	}
+
+	return cost
+}
+
+// hasherFor returns the first hasher, which recognizes the hash format, starting from the primary one
+func (c *Chain) hasherFor(hash string) PwHasher {
+	if c.primary.Recognizes(hash) {
+		return c.primary
+	}
+
+	for _, h := range c.legacy {
+		if h.Recognizes(hash) {
+			return h
+		}
+	}
+
+	return nil
+}
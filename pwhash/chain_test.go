@@ -0,0 +1,65 @@
+package pwhash
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+// prefixHasher is a fake hasher, which "hashes" passwords by adding the prefix
+type prefixHasher struct {
+	prefix string
+	params string
+}
+
+func (h prefixHasher) HashPw(pw string) (string, error) {
+	return h.prefix + h.params + "$" + pw, nil
+}
+
+func (h prefixHasher) ComparePw(pw string, hash string) (bool, error) {
+	return strings.HasSuffix(hash, "$"+pw), nil
+}
+
+func (h prefixHasher) NeedsRehash(hash string) (bool, error) {
+	return !strings.HasPrefix(hash, h.prefix+h.params+"$"), nil
+}
+
+func (h prefixHasher) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, h.prefix)
+}
+
+func Test_Chain(t *testing.T) {
+	primary := prefixHasher{prefix: "new$", params: "2"}
+	c := NewChain(primary, prefixHasher{prefix: "old$"})
+
+	hash, err := c.HashPw("qwerty")
+	assert.NoError(t, err)
+	assert.Equal(t, "new$2$qwerty", hash)
+
+	tests := []struct {
+		name        string
+		hash        string
+		isMatch     bool
+		needsRehash bool
+		wantErr     bool
+	}{
+		{name: "primary", hash: "new$2$qwerty", isMatch: true},
+		{name: "primary outdated", hash: "new$1$qwerty", isMatch: true, needsRehash: true},
+		{name: "legacy", hash: "old$$qwerty", isMatch: true, needsRehash: true},
+		{name: "legacy mismatch", hash: "old$$123456", needsRehash: true},
+		{name: "unknown", hash: "other$qwerty", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isMatch, err := c.ComparePw("qwerty", tt.hash)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.isMatch, isMatch)
+
+			needsRehash, err := c.NeedsRehash(tt.hash)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.needsRehash, needsRehash)
+
+			assert.Equal(t, !tt.wantErr, c.Recognizes(tt.hash))
+		})
+	}
+}
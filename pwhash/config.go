@@ -0,0 +1,178 @@
+package pwhash
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// AllowWeakKey is the settings key, which disables security floors of the adapter.
+// It's meant for tests and for verifying legacy hashes
+const AllowWeakKey = "allow_weak"
+
+// FloorsKey is the settings key with the app's own security floors, e.g. floors: {iterations: 4}.
+// They are checked besides the floors of the adapter, even if weak parameters are allowed
+const FloorsKey = "floors"
+
+// Floor is the minimum of the setting. Min is the floor of the adapter
+type Floor struct {
+	Key        string
+	Value, Min int
+}
+
+// CheckKeys checks that the settings contain all required keys and no unknown ones,
+// so mistyped keys aren't silently ignored
+func CheckKeys(rawConf *RawHashConfig, required []string, optional ...string) error {
+	known := map[string]bool{AllowWeakKey: true, FloorsKey: true}
+	for _, key := range append(required, optional...) {
+		known[key] = true
+	}
+
+	for _, key := range required {
+		if _, ok := (*rawConf)[key]; !ok {
+			return fmt.Errorf("pwhash config: missing %s statement", key)
+		}
+	}
+
+	var unknown []string
+	for key := range *rawConf {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+
+	if len(unknown) != 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("pwhash config: unknown statements %s", strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
+
+// GetInt returns integer value of the key. Whole floats are accepted,
+// since some decoders produce them for plain numbers
+func GetInt(rawConf *RawHashConfig, key string) (int, error) {
+	switch v := (*rawConf)[key].(type) {
+	case int:
+		return v, nil
+	case int64:
+		if v >= math.MinInt32 && v <= math.MaxInt32 {
+			return int(v), nil
+		}
+	case uint64:
+		if v <= math.MaxInt32 {
+			return int(v), nil
+		}
+	case float64:
+		if v == math.Trunc(v) && v >= math.MinInt32 && v <= math.MaxInt32 {
+			return int(v), nil
+		}
+	case nil:
+		return 0, fmt.Errorf("pwhash config: missing %s statement", key)
+	}
+
+	return 0, fmt.Errorf("pwhash config: %s must be an integer, got %v", key, (*rawConf)[key])
+}
+
+// GetString returns string value of the key
+func GetString(rawConf *RawHashConfig, key string) (string, error) {
+	switch v := (*rawConf)[key].(type) {
+	case string:
+		return v, nil
+	case nil:
+		return "", fmt.Errorf("pwhash config: missing %s statement", key)
+	}
+
+	return "", fmt.Errorf("pwhash config: %s must be a string, got %v", key, (*rawConf)[key])
+}
+
+// GetBool returns boolean value of the key or def if the key is absent
+func GetBool(rawConf *RawHashConfig, key string, def bool) (bool, error) {
+	switch v := (*rawConf)[key].(type) {
+	case bool:
+		return v, nil
+	case nil:
+		return def, nil
+	}
+
+	return false, fmt.Errorf("pwhash config: %s must be a boolean, got %v", key, (*rawConf)[key])
+}
+
+// CheckFloor returns error if the value is below the minimum, unless weak parameters are allowed
+func CheckFloor(rawConf *RawHashConfig, key string, value, min int) error {
+	if value >= min {
+		return nil
+	}
+
+	isWeakAllowed, err := GetBool(rawConf, AllowWeakKey, false)
+	if err != nil {
+		return err
+	}
+
+	if !isWeakAllowed {
+		return fmt.Errorf("pwhash config: %s %d is below the minimum %d, set %s to use it anyway",
+			key, value, min, AllowWeakKey)
+	}
+	return nil
+}
+
+// CheckFloors checks the values against the floors of the adapter and the floors configured by FloorsKey.
+// Configured floors of the settings, which have no floor, are refused, so mistyped keys aren't ignored
+func CheckFloors(rawConf *RawHashConfig, floors []Floor) error {
+	configured, err := getFloors(rawConf, floors)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range floors {
+		if min, ok := configured[f.Key]; ok && f.Value < min {
+			return fmt.Errorf("pwhash config: %s %d is below the configured floor %d", f.Key, f.Value, min)
+		}
+
+		if err := CheckFloor(rawConf, f.Key, f.Value, f.Min); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getFloors returns the floors configured by FloorsKey
+func getFloors(rawConf *RawHashConfig, floors []Floor) (map[string]int, error) {
+	rawFloors, ok := (*rawConf)[FloorsKey]
+	if !ok {
+		return nil, nil
+	}
+
+	floorsConf, ok := rawFloors.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("pwhash config: %s must be a mapping, got %v", FloorsKey, rawFloors)
+	}
+
+	configured := map[string]int{}
+	for _, f := range floors {
+		if _, ok := floorsConf[f.Key]; !ok {
+			continue
+		}
+
+		min, err := GetInt(&floorsConf, f.Key)
+		if err != nil {
+			return nil, err
+		}
+		configured[f.Key] = min
+	}
+
+	if len(configured) != len(floorsConf) {
+		var unknown []string
+		for key := range floorsConf {
+			if _, ok := configured[key]; !ok {
+				unknown = append(unknown, key)
+			}
+		}
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("pwhash config: unknown %s %s", FloorsKey, strings.Join(unknown, ", "))
+	}
+
+	return configured, nil
+}
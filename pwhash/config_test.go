@@ -0,0 +1,98 @@
+package pwhash
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_CheckKeys(t *testing.T) {
+	rawConf := RawHashConfig{"cost": 12, "prehash": true, AllowWeakKey: true}
+	assert.NoError(t, CheckKeys(&rawConf, []string{"cost"}, "prehash"))
+
+	err := CheckKeys(&rawConf, []string{"cost", "salt_length"})
+	assert.EqualError(t, err, "pwhash config: missing salt_length statement")
+
+	err = CheckKeys(&rawConf, []string{"cost"})
+	assert.EqualError(t, err, "pwhash config: unknown statements prehash")
+}
+
+func Test_GetInt(t *testing.T) {
+	rawConf := RawHashConfig{"int": 3, "int64": int64(4), "float": 5.0, "fraction": 5.5, "string": "6"}
+
+	for key, want := range map[string]int{"int": 3, "int64": 4, "float": 5} {
+		got, err := GetInt(&rawConf, key)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	for _, key := range []string{"fraction", "string", "missing"} {
+		_, err := GetInt(&rawConf, key)
+		assert.Error(t, err, key)
+	}
+}
+
+func Test_GetString(t *testing.T) {
+	rawConf := RawHashConfig{"type": "argon2i", "number": 1}
+
+	got, err := GetString(&rawConf, "type")
+	assert.NoError(t, err)
+	assert.Equal(t, "argon2i", got)
+
+	_, err = GetString(&rawConf, "number")
+	assert.Error(t, err)
+
+	_, err = GetString(&rawConf, "missing")
+	assert.Error(t, err)
+}
+
+func Test_GetBool(t *testing.T) {
+	rawConf := RawHashConfig{"prehash": true, "number": 1}
+
+	got, err := GetBool(&rawConf, "prehash", false)
+	assert.NoError(t, err)
+	assert.True(t, got)
+
+	got, err = GetBool(&rawConf, "missing", true)
+	assert.NoError(t, err)
+	assert.True(t, got)
+
+	_, err = GetBool(&rawConf, "number", false)
+	assert.Error(t, err)
+}
+
+func Test_CheckFloor(t *testing.T) {
+	rawConf := RawHashConfig{}
+	assert.NoError(t, CheckFloor(&rawConf, "iterations", 3, 2))
+	assert.EqualError(t, CheckFloor(&rawConf, "iterations", 1, 2),
+		"pwhash config: iterations 1 is below the minimum 2, set allow_weak to use it anyway")
+
+	rawConf[AllowWeakKey] = true
+	assert.NoError(t, CheckFloor(&rawConf, "iterations", 1, 2))
+}
+
+func Test_CheckFloors(t *testing.T) {
+	floors := []Floor{{Key: "iterations", Value: 3, Min: 2}, {Key: "memory", Value: 1024, Min: 512}}
+
+	rawConf := RawHashConfig{}
+	assert.NoError(t, CheckFloors(&rawConf, floors))
+
+	rawConf[FloorsKey] = map[string]interface{}{"iterations": 4}
+	assert.EqualError(t, CheckFloors(&rawConf, floors),
+		"pwhash config: iterations 3 is below the configured floor 4")
+
+	// the configured floors are checked even if weak parameters are allowed
+	rawConf[AllowWeakKey] = true
+	assert.Error(t, CheckFloors(&rawConf, floors))
+
+	rawConf[FloorsKey] = map[string]interface{}{"iterations": 3, "memory": 1024}
+	assert.NoError(t, CheckFloors(&rawConf, floors))
+
+	rawConf[FloorsKey] = map[string]interface{}{"iterations": 3, "memroy": 1024}
+	assert.EqualError(t, CheckFloors(&rawConf, floors), "pwhash config: unknown floors memroy")
+
+	rawConf[FloorsKey] = map[string]interface{}{"iterations": "3"}
+	assert.Error(t, CheckFloors(&rawConf, floors))
+
+	rawConf[FloorsKey] = 3
+	assert.Error(t, CheckFloors(&rawConf, floors))
+}
@@ -1,5 +1,23 @@
 package pwhash
 
+import "errors"
+
+// ErrVerifyOnly is returned by HashPw of the hashers, which only verify imported hashes
+var ErrVerifyOnly = errors.New("pwhash: hasher can only verify imported hashes")
+
+// VerifyOnlyHasher is implemented by hashers, which only verify imported hashes.
+// They can't be the primary hasher, since HashPw returns ErrVerifyOnly
+type VerifyOnlyHasher interface {
+	// VerifyOnly marks the hasher as verify-only
+	VerifyOnly()
+}
+
+// IsVerifyOnly checks whether the hasher only verifies imported hashes
+func IsVerifyOnly(h PwHasher) bool {
+	_, ok := h.(VerifyOnlyHasher)
+	return ok
+}
+
 // PwHasher is an interface that defined method for pwhash implementation
 type PwHasher interface {
 	// Hash returns hashed data encoded by base64
@@ -7,4 +25,11 @@ type PwHasher interface {
 
 	// Compare compares plain data and hashed data encoded by base64
 	ComparePw(string, string) (bool, error)
+
+	// NeedsRehash checks whether the hash was created with parameters,
+	// which differ from the current hasher settings
+	NeedsRehash(string) (bool, error)
+
+	// Recognizes checks whether the hash has the format produced by this hasher
+	Recognizes(string) bool
 }
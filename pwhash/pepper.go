@@ -0,0 +1,113 @@
+package pwhash
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// pepperPrefix marks hashes of the peppered passwords. The full format is
+//
+//	$pepper$<pepper id>$<hash of the inner hasher>
+const pepperPrefix = "$pepper$"
+
+// Peppered is PwHasher, which applies HMAC-SHA256 with the secret pepper to passwords
+// before passing them to the inner hasher. The pepper id is kept in the hash,
+// so old peppers still verify after the current one is rotated.
+// Hashes without pepper are verified as is and need rehash
+type Peppered struct {
+	inner   PwHasher
+	current string
+	peppers map[string][]byte
+}
+
+// NewPeppered returns hasher, which peppers passwords with the current pepper
+// and verifies hashes made with any of the given peppers
+func NewPeppered(inner PwHasher, current string, peppers map[string][]byte) (*Peppered, error) {
+	if _, ok := peppers[current]; !ok {
+		return nil, fmt.Errorf("pwhash: unknown current pepper %s", current)
+	}
+
+	for id := range peppers {
+		if id == "" || strings.Contains(id, "$") {
+			return nil, fmt.Errorf("pwhash: pepper id '%s' must be non-empty and mustn't contain '$'", id)
+		}
+	}
+
+	return &Peppered{inner: inner, current: current, peppers: peppers}, nil
+}
+
+// HashPw peppers the password with the current pepper and hashes it with the inner hasher
+func (p *Peppered) HashPw(pw string) (string, error) {
+	hash, err := p.inner.HashPw(p.pepper(pw, p.peppers[p.current]))
+	if err != nil {
+		return "", err
+	}
+
+	return pepperPrefix + p.current + "$" + hash, nil
+}
+
+// ComparePw peppers the password with the pepper of the hash and compares it using the inner hasher
+func (p *Peppered) ComparePw(pw string, hash string) (bool, error) {
+	id, innerHash, isPeppered := splitPepperedHash(hash)
+	if !isPeppered {
+		return p.inner.ComparePw(pw, hash)
+	}
+
+	key, ok := p.peppers[id]
+	if !ok {
+		return false, fmt.Errorf("pwhash: unknown pepper %s", id)
+	}
+
+	return p.inner.ComparePw(p.pepper(pw, key), innerHash)
+}
+
+// NeedsRehash checks whether the hash isn't peppered, was peppered with the rotated pepper
+// or needs rehash by the inner hasher
+func (p *Peppered) NeedsRehash(hash string) (bool, error) {
+	id, innerHash, isPeppered := splitPepperedHash(hash)
+	if !isPeppered || id != p.current {
+		if !p.Recognizes(hash) {
+			return false, ErrUnknownHash
+		}
+		return true, nil
+	}
+
+	return p.inner.NeedsRehash(innerHash)
+}
+
+// Recognizes checks whether the inner hasher recognizes the hash, peppered or not
+func (p *Peppered) Recognizes(hash string) bool {
+	if _, innerHash, isPeppered := splitPepperedHash(hash); isPeppered {
+		return p.inner.Recognizes(innerHash)
+	}
+	return p.inner.Recognizes(hash)
+}
+
+// MemoryCost returns the memory cost of the inner hasher
+func (p *Peppered) MemoryCost() int {
+	return MemoryCost(p.inner)
+}
+
+// pepper returns base64 encoded HMAC-SHA256 of the password.
+// Encoding keeps the result printable and shorter than the bcrypt limit
+func (p *Peppered) pepper(pw string, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(pw))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// splitPepperedHash returns pepper id and hash of the inner hasher
+func splitPepperedHash(hash string) (string, string, bool) {
+	if !strings.HasPrefix(hash, pepperPrefix) {
+		return "", "", false
+	}
+
+	vals := strings.SplitN(strings.TrimPrefix(hash, pepperPrefix), "$", 2)
+	if len(vals) != 2 {
+		return "", "", false
+	}
+	return vals[0], vals[1], true
+}
@@ -0,0 +1,70 @@
+package pwhash
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_NewPeppered(t *testing.T) {
+	inner := prefixHasher{prefix: "new$"}
+
+	_, err := NewPeppered(inner, "k2", map[string][]byte{"k1": []byte("secret")})
+	assert.Error(t, err)
+
+	_, err = NewPeppered(inner, "k$1", map[string][]byte{"k$1": []byte("secret")})
+	assert.Error(t, err)
+}
+
+func Test_Peppered(t *testing.T) {
+	inner := prefixHasher{prefix: "new$", params: "1"}
+	old, err := NewPeppered(inner, "k1", map[string][]byte{"k1": []byte("first secret")})
+	assert.NoError(t, err)
+
+	oldHash, err := old.HashPw("qwerty")
+	assert.NoError(t, err)
+	assert.Regexp(t, `^\$pepper\$k1\$new\$1\$`, oldHash)
+	assert.NotContains(t, oldHash, "qwerty")
+
+	p, err := NewPeppered(inner, "k2", map[string][]byte{
+		"k1": []byte("first secret"),
+		"k2": []byte("second secret"),
+	})
+	assert.NoError(t, err)
+
+	hash, err := p.HashPw("qwerty")
+	assert.NoError(t, err)
+	assert.Regexp(t, `^\$pepper\$k2\$`, hash)
+	assert.NotEqual(t, oldHash[len("$pepper$k1$"):], hash[len("$pepper$k2$"):])
+
+	tests := []struct {
+		name        string
+		hash        string
+		isMatch     bool
+		needsRehash bool
+		wantErr     bool
+	}{
+		{name: "current pepper", hash: hash, isMatch: true},
+		{name: "rotated pepper", hash: oldHash, isMatch: true, needsRehash: true},
+		{name: "without pepper", hash: "new$1$qwerty", isMatch: true, needsRehash: true},
+		{name: "unknown pepper", hash: "$pepper$k0$new$1$qwerty", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isMatch, err := p.ComparePw("qwerty", tt.hash)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.isMatch, isMatch)
+
+			if tt.wantErr {
+				return
+			}
+
+			isMatch, err = p.ComparePw("123456", tt.hash)
+			assert.NoError(t, err)
+			assert.False(t, isMatch)
+
+			needsRehash, err := p.NeedsRehash(tt.hash)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.needsRehash, needsRehash)
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package pwhash
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrBusy is returned if the hashing slot isn't acquired during the queue timeout
+var ErrBusy = errors.New("pwhash: all hashing slots are busy")
+
+// MemoryCoster is implemented by hashers, which allocate considerable memory per hash
+type MemoryCoster interface {
+	// MemoryCost returns the amount of memory used by hashing one password (in kibibytes)
+	MemoryCost() int
+}
+
+// MemoryCost returns the amount of memory used by hashing one password with the hasher (in kibibytes).
+// It returns 0 if the hasher doesn't report its memory cost
+func MemoryCost(h PwHasher) int {
+	if mc, ok := h.(MemoryCoster); ok {
+		return mc.MemoryCost()
+	}
+	return 0
+}
+
+// Pool limits the number of passwords hashed concurrently.
+// Callers wait for a free slot in the queue up to the timeout
+type Pool struct {
+	slots   chan struct{}
+	timeout time.Duration
+
+	queued   int64
+	acquired int64
+	timeouts int64
+
+	mu       sync.Mutex
+	waitSum  time.Duration
+	waitMax  time.Duration
+	waitHist []int64
+}
+
+// waitBuckets are upper bounds of the wait time histogram buckets. The last bucket is unbounded
+var waitBuckets = []time.Duration{
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+}
+
+// PoolStats represents the pool state and wait time metrics
+type PoolStats struct {
+	Size     int   `json:"size"`
+	InFlight int   `json:"in_flight"`
+	Queued   int64 `json:"queued"`
+	Acquired int64 `json:"acquired"`
+	Timeouts int64 `json:"timeouts"`
+
+	// Wait times in milliseconds
+	WaitAvg float64 `json:"wait_avg_ms"`
+	WaitMax float64 `json:"wait_max_ms"`
+
+	// Number of waits by bucket: <=1ms, <=10ms, <=100ms, <=1s, >1s
+	WaitHist []int64 `json:"wait_hist"`
+}
+
+// NewPool returns pool with the given number of slots.
+// If the timeout is 0, callers wait for a slot without limit
+func NewPool(size int, timeout time.Duration) *Pool {
+	if size < 1 {
+		size = 1
+	}
+
+	return &Pool{
+		slots:    make(chan struct{}, size),
+		timeout:  timeout,
+		waitHist: make([]int64, len(waitBuckets)+1),
+	}
+}
+
+// PoolSize returns the number of slots, which fit the memory budget (in kibibytes)
+// if each hash uses memCost kibibytes. It's at least 1
+func PoolSize(memBudget, memCost int) int {
+	if memCost <= 0 || memBudget <= memCost {
+		return 1
+	}
+	return memBudget / memCost
+}
+
+// Do runs f in a free slot. It returns ErrBusy if no slot is freed during the timeout,
+// and the context error if the context is done while waiting, e.g. the client has disconnected
+func (p *Pool) Do(ctx context.Context, f func() error) error {
+	if err := p.acquire(ctx); err != nil {
+		return err
+	}
+	defer func() { <-p.slots }()
+
+	return f()
+}
+
+func (p *Pool) acquire(ctx context.Context) error {
+	start := time.Now()
+
+	select {
+	case p.slots <- struct{}{}:
+		p.observe(time.Since(start))
+		return nil
+	default:
+	}
+
+	atomic.AddInt64(&p.queued, 1)
+	defer atomic.AddInt64(&p.queued, -1)
+
+	var timeout <-chan time.Time
+	if p.timeout > 0 {
+		timer := time.NewTimer(p.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case p.slots <- struct{}{}:
+		p.observe(time.Since(start))
+		return nil
+	case <-timeout:
+		atomic.AddInt64(&p.timeouts, 1)
+		return ErrBusy
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Pool) observe(wait time.Duration) {
+	atomic.AddInt64(&p.acquired, 1)
+
+	bucket := len(waitBuckets)
+	for i, bound := range waitBuckets {
+		if wait <= bound {
+			bucket = i
+			break
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.waitSum += wait
+	if wait > p.waitMax {
+		p.waitMax = wait
+	}
+	p.waitHist[bucket]++
+}
+
+// Stats returns the current pool state and wait time metrics
+func (p *Pool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := PoolStats{
+		Size:     cap(p.slots),
+		InFlight: len(p.slots),
+		Queued:   atomic.LoadInt64(&p.queued),
+		Acquired: atomic.LoadInt64(&p.acquired),
+		Timeouts: atomic.LoadInt64(&p.timeouts),
+		WaitMax:  float64(p.waitMax) / float64(time.Millisecond),
+		WaitHist: append([]int64(nil), p.waitHist...),
+	}
+	if stats.Acquired != 0 {
+		stats.WaitAvg = float64(p.waitSum) / float64(stats.Acquired) / float64(time.Millisecond)
+	}
+
+	return stats
+}
+
+// Pooled is PwHasher, which hashes and compares passwords in the pool slots.
+// It bounds the memory used by the memory-hard algorithms under load
+type Pooled struct {
+	inner PwHasher
+	pool  *Pool
+	ctx   context.Context
+}
+
+// NewPooled returns hasher, which runs the inner hasher in the pool
+func NewPooled(inner PwHasher, pool *Pool) *Pooled {
+	return &Pooled{inner: inner, pool: pool, ctx: context.Background()}
+}
+
+// WithContext returns copy of the hasher, which stops waiting for a slot when the context is done.
+// The pool is shared with the copy
+func (p *Pooled) WithContext(ctx context.Context) *Pooled {
+	return &Pooled{inner: p.inner, pool: p.pool, ctx: ctx}
+}
+
+// HashPw hashes the password with the inner hasher in a free slot
+func (p *Pooled) HashPw(pw string) (hash string, err error) {
+	poolErr := p.pool.Do(p.ctx, func() error {
+		hash, err = p.inner.HashPw(pw)
+		return nil
+	})
+	if poolErr != nil {
+		return "", poolErr
+	}
+
+	return hash, err
+}
+
+// ComparePw compares the password with the hash using the inner hasher in a free slot
+func (p *Pooled) ComparePw(pw string, hash string) (isMatch bool, err error) {
+	poolErr := p.pool.Do(p.ctx, func() error {
+		isMatch, err = p.inner.ComparePw(pw, hash)
+		return nil
+	})
+	if poolErr != nil {
+		return false, poolErr
+	}
+
+	return isMatch, err
+}
+
+// NeedsRehash checks whether the hash needs rehash by the inner hasher. It doesn't hash anything,
+// so it isn't run in the pool
+func (p *Pooled) NeedsRehash(hash string) (bool, error) {
+	return p.inner.NeedsRehash(hash)
+}
+
+// Recognizes checks whether the inner hasher recognizes the hash format
+func (p *Pooled) Recognizes(hash string) bool {
+	return p.inner.Recognizes(hash)
+}
+
+// MemoryCost returns the memory cost of the inner hasher
+func (p *Pooled) MemoryCost() int {
+	return MemoryCost(p.inner)
+}
+
+// Stats returns metrics of the pool
+func (p *Pooled) Stats() PoolStats {
+	return p.pool.Stats()
+}
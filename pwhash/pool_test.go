@@ -0,0 +1,149 @@
+package pwhash
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryHasher is a fake hasher, which reports its memory cost
+type memoryHasher struct {
+	prefixHasher
+	cost int
+}
+
+func (h memoryHasher) MemoryCost() int {
+	return h.cost
+}
+
+func TestPoolSize(t *testing.T) {
+	assert.Equal(t, 4, PoolSize(65536, 16384))
+	assert.Equal(t, 3, PoolSize(65536, 19456))
+	assert.Equal(t, 1, PoolSize(1024, 19456))
+	assert.Equal(t, 1, PoolSize(65536, 0))
+}
+
+func TestMemoryCost(t *testing.T) {
+	primary := memoryHasher{prefixHasher{prefix: "$a$"}, 1024}
+	legacy := memoryHasher{prefixHasher{prefix: "$b$"}, 4096}
+
+	assert.Equal(t, 0, MemoryCost(prefixHasher{prefix: "$a$"}))
+	assert.Equal(t, 1024, MemoryCost(primary))
+	assert.Equal(t, 4096, MemoryCost(NewChain(primary, legacy, prefixHasher{prefix: "$c$"})))
+	assert.Equal(t, 1024, MemoryCost(NewPooled(primary, NewPool(1, 0))))
+}
+
+func TestPool_Do(t *testing.T) {
+	p := NewPool(2, 0)
+
+	var (
+		mu      sync.Mutex
+		running int
+		maxRun  int
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := p.Do(context.Background(), func() error {
+				mu.Lock()
+				running++
+				if running > maxRun {
+					maxRun = running
+				}
+				mu.Unlock()
+
+				time.Sleep(5 * time.Millisecond)
+
+				mu.Lock()
+				running--
+				mu.Unlock()
+				return nil
+			})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 2, maxRun)
+
+	stats := p.Stats()
+	assert.Equal(t, 2, stats.Size)
+	assert.Equal(t, 0, stats.InFlight)
+	assert.Equal(t, int64(0), stats.Queued)
+	assert.Equal(t, int64(10), stats.Acquired)
+	assert.Equal(t, int64(0), stats.Timeouts)
+
+	var total int64
+	for _, n := range stats.WaitHist {
+		total += n
+	}
+	assert.Equal(t, int64(10), total)
+}
+
+func TestPool_Do_Timeout(t *testing.T) {
+	p := NewPool(1, 10*time.Millisecond)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go p.Do(context.Background(), func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+
+	err := p.Do(context.Background(), func() error { return nil })
+	assert.Equal(t, ErrBusy, err)
+	assert.Equal(t, int64(1), p.Stats().Timeouts)
+
+	close(release)
+}
+
+func TestPool_Do_Cancel(t *testing.T) {
+	p := NewPool(1, 0)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go p.Do(context.Background(), func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+
+	// the waiting caller leaves the queue, when its context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		cancel()
+	}()
+
+	err := p.Do(ctx, func() error { return nil })
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, int64(0), p.Stats().Queued)
+	assert.Equal(t, int64(0), p.Stats().Timeouts)
+
+	_, err = NewPooled(prefixHasher{prefix: "$a$"}, p).WithContext(ctx).HashPw("qwerty")
+	assert.Equal(t, context.Canceled, err)
+
+	close(release)
+}
+
+func TestPooled(t *testing.T) {
+	h := NewPooled(prefixHasher{prefix: "$a$"}, NewPool(1, 0))
+
+	hash, err := h.HashPw("qwerty")
+	assert.NoError(t, err)
+	assert.Equal(t, "$a$$qwerty", hash)
+
+	isMatch, err := h.ComparePw("qwerty", hash)
+	assert.NoError(t, err)
+	assert.True(t, isMatch)
+
+	assert.True(t, h.Recognizes(hash))
+	assert.Equal(t, int64(2), h.Stats().Acquired)
+}
@@ -0,0 +1,153 @@
+package pwpolicy
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// maxLineLen is the upper bound of the line length in the breached passwords file:
+// 40 hex digits of the hash, colon, count and line break
+const maxLineLen = 128
+
+// BreachedList looks up passwords in the local file of breached password hashes.
+// The file has HIBP format: uppercase SHA-1 hashes sorted in ascending order,
+// one "HASH:COUNT" per line. Lookup is a binary search over the file,
+// so it isn't loaded into memory
+type BreachedList struct {
+	f        *os.File
+	size     int64
+	minCount int
+}
+
+// OpenBreachedList opens breached passwords file.
+// Passwords seen less than minCount times aren't considered breached
+func OpenBreachedList(path string, minCount int) (*BreachedList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if minCount < 1 {
+		minCount = 1
+	}
+
+	return &BreachedList{f: f, size: info.Size(), minCount: minCount}, nil
+}
+
+// Contains checks whether the password has been breached at least minCount times
+func (b *BreachedList) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	target := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	lo, hi := int64(0), b.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		start, err := b.lineStart(mid)
+		if err != nil {
+			return false, err
+		}
+
+		// there is no line starting in [mid, hi)
+		if start >= hi {
+			hi = mid
+			continue
+		}
+
+		line, next, err := b.readLine(start)
+		if err != nil {
+			return false, err
+		}
+
+		hash, count, err := parseBreachedLine(line)
+		if err != nil {
+			return false, err
+		}
+
+		switch {
+		case hash == target:
+			return count >= b.minCount, nil
+		case hash < target:
+			lo = next
+		default:
+			hi = start
+		}
+	}
+
+	return false, nil
+}
+
+// Close closes the file
+func (b *BreachedList) Close() error {
+	return b.f.Close()
+}
+
+// lineStart returns position of the first line starting at off or after it
+func (b *BreachedList) lineStart(off int64) (int64, error) {
+	if off == 0 {
+		return 0, nil
+	}
+
+	buf := make([]byte, maxLineLen)
+	n, err := b.f.ReadAt(buf, off-1)
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+
+	i := bytes.IndexByte(buf[:n], '\n')
+	if i < 0 {
+		return b.size, nil
+	}
+	return off + int64(i), nil
+}
+
+// readLine reads the line at start and returns it with the position of the next line
+func (b *BreachedList) readLine(start int64) (string, int64, error) {
+	buf := make([]byte, maxLineLen)
+	n, err := b.f.ReadAt(buf, start)
+	if err != nil && err != io.EOF {
+		return "", 0, err
+	}
+
+	line := buf[:n]
+	next := start + int64(n)
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+		next = start + int64(i) + 1
+	} else if next < b.size {
+		return "", 0, errors.New("breached passwords file: line is too long")
+	}
+
+	return string(bytes.TrimRight(line, "\r")), next, nil
+}
+
+// parseBreachedLine splits the line into hash and count. Count defaults to 1 if omitted
+func parseBreachedLine(line string) (string, int, error) {
+	parts := strings.SplitN(line, ":", 2)
+	hash := strings.ToUpper(parts[0])
+	if len(hash) != sha1.Size*2 {
+		return "", 0, errors.New("breached passwords file: malformed line " + line)
+	}
+
+	if len(parts) == 1 {
+		return hash, 1, nil
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return "", 0, errors.New("breached passwords file: malformed count in line " + line)
+	}
+	return hash, count, nil
+}
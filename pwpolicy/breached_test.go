@@ -0,0 +1,72 @@
+package pwpolicy
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func writeBreachedFile(t *testing.T, counts map[string]int) string {
+	var lines []string
+	for pw, count := range counts {
+		sum := sha1.Sum([]byte(pw))
+		lines = append(lines, fmt.Sprintf("%s:%d\r\n", strings.ToUpper(hex.EncodeToString(sum[:])), count))
+	}
+	sort.Strings(lines)
+
+	dir, err := ioutil.TempDir("", "pwpolicy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "breached.txt")
+	if err := ioutil.WriteFile(path, []byte(strings.Join(lines, "")), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func Test_BreachedList_Contains(t *testing.T) {
+	counts := map[string]int{"password": 3861493, "rare": 1}
+	for i := 0; i < 500; i++ {
+		counts[fmt.Sprintf("pw%d", i)] = i + 1
+	}
+
+	b, err := OpenBreachedList(writeBreachedFile(t, counts), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	for pw, count := range counts {
+		isBreached, err := b.Contains(pw)
+		assert.NoError(t, err)
+		assert.Equal(t, count >= 2, isBreached, pw)
+	}
+
+	for _, pw := range []string{"", "not breached", "pw500"} {
+		isBreached, err := b.Contains(pw)
+		assert.NoError(t, err)
+		assert.False(t, isBreached, pw)
+	}
+}
+
+func Test_BreachedList_Empty(t *testing.T) {
+	b, err := OpenBreachedList(writeBreachedFile(t, nil), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	isBreached, err := b.Contains("password")
+	assert.NoError(t, err)
+	assert.False(t, isBreached)
+}
@@ -0,0 +1,287 @@
+package pwpolicy
+
+import (
+	"errors"
+	"fmt"
+	"github.com/nbutton23/zxcvbn-go"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Config represents password policy rules. Zero value of a field disables the rule
+type Config struct {
+	// Minimum and maximum number of characters in the password
+	MinLength int
+	MaxLength int
+
+	// Character classes, which must be present in the password
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+
+	// Minimum number of different character classes in the password
+	MinClasses int
+
+	// Minimum strength score from 0 (too guessable) to 4 (very unguessable)
+	MinScore int
+
+	// Whether passwords similar to the user inputs (e.g. user_unique) are rejected
+	RejectSimilar bool
+
+	// Path to the breached passwords file and minimum number of breaches,
+	// starting from which the password is rejected
+	BreachedFile     string
+	BreachedMinCount int
+}
+
+// Violation describes one broken rule of the policy
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Policy checks passwords against the configured rules
+type Policy struct {
+	conf     Config
+	breached *BreachedList
+}
+
+// similarityThreshold is the share of matching characters,
+// starting from which the password is considered similar to the user input
+const similarityThreshold = 0.7
+
+// New validates the config and returns policy.
+// Breached passwords file is opened once and kept open
+func New(conf Config) (*Policy, error) {
+	if conf.MinLength < 0 || conf.MaxLength < 0 {
+		return nil, errors.New("password policy: length can't be negative")
+	}
+
+	if conf.MaxLength != 0 && conf.MaxLength < conf.MinLength {
+		return nil, errors.New("password policy: max length is less than min length")
+	}
+
+	if conf.MinClasses < 0 || conf.MinClasses > 4 {
+		return nil, errors.New("password policy: min classes must be between 0 and 4")
+	}
+
+	if conf.MinScore < 0 || conf.MinScore > 4 {
+		return nil, errors.New("password policy: min score must be between 0 and 4")
+	}
+
+	p := &Policy{conf: conf}
+
+	if conf.BreachedFile != "" {
+		breached, err := OpenBreachedList(conf.BreachedFile, conf.BreachedMinCount)
+		if err != nil {
+			return nil, fmt.Errorf("password policy: %v", err)
+		}
+		p.breached = breached
+	}
+
+	return p, nil
+}
+
+// Check returns all rules broken by the password.
+// userInputs are the values, which the password mustn't resemble
+func (p *Policy) Check(password string, userInputs ...string) ([]Violation, error) {
+	var violations []Violation
+	conf := p.conf
+
+	length := utf8.RuneCountInString(password)
+	if length < conf.MinLength {
+		violations = append(violations, Violation{
+			Rule:    "min_length",
+			Message: fmt.Sprintf("password must contain at least %d characters", conf.MinLength),
+		})
+	}
+
+	isTooLong := conf.MaxLength != 0 && length > conf.MaxLength
+	if isTooLong {
+		violations = append(violations, Violation{
+			Rule:    "max_length",
+			Message: fmt.Sprintf("password must contain at most %d characters", conf.MaxLength),
+		})
+	}
+
+	violations = append(violations, p.checkClasses(password)...)
+
+	if conf.RejectSimilar {
+		for _, input := range userInputs {
+			if isSimilar(password, input) {
+				violations = append(violations, Violation{
+					Rule:    "similar",
+					Message: "password is too similar to the user data",
+				})
+				break
+			}
+		}
+	}
+
+	// strength estimation is expensive for long inputs, which are rejected anyway
+	if conf.MinScore != 0 && !isTooLong {
+		if score := zxcvbn.PasswordStrength(password, userInputs).Score; score < conf.MinScore {
+			violations = append(violations, Violation{
+				Rule:    "strength",
+				Message: fmt.Sprintf("password is too guessable: score %d of required %d", score, conf.MinScore),
+			})
+		}
+	}
+
+	if p.breached != nil {
+		isBreached, err := p.breached.Contains(password)
+		if err != nil {
+			return nil, err
+		}
+
+		if isBreached {
+			violations = append(violations, Violation{
+				Rule:    "breached",
+				Message: "password has appeared in a data breach",
+			})
+		}
+	}
+
+	return violations, nil
+}
+
+// Close releases the breached passwords file
+func (p *Policy) Close() error {
+	if p.breached != nil {
+		return p.breached.Close()
+	}
+	return nil
+}
+
+func (p *Policy) checkClasses(password string) []Violation {
+	var (
+		violations                   []Violation
+		hasUpper, hasLower, hasDigit bool
+		hasSymbol                    bool
+	)
+
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		default:
+			hasSymbol = true
+		}
+	}
+
+	rules := []struct {
+		isRequired bool
+		isPresent  bool
+		rule       string
+		message    string
+	}{
+		{p.conf.RequireUpper, hasUpper, "upper", "password must contain an uppercase letter"},
+		{p.conf.RequireLower, hasLower, "lower", "password must contain a lowercase letter"},
+		{p.conf.RequireDigit, hasDigit, "digit", "password must contain a digit"},
+		{p.conf.RequireSymbol, hasSymbol, "symbol", "password must contain a symbol"},
+	}
+
+	classes := 0
+	for _, r := range rules {
+		if r.isPresent {
+			classes++
+		}
+
+		if r.isRequired && !r.isPresent {
+			violations = append(violations, Violation{Rule: r.rule, Message: r.message})
+		}
+	}
+
+	if classes < p.conf.MinClasses {
+		violations = append(violations, Violation{
+			Rule:    "min_classes",
+			Message: fmt.Sprintf("password must contain at least %d character classes", p.conf.MinClasses),
+		})
+	}
+
+	return violations
+}
+
+// isSimilar checks whether the password contains the input or is close to it by edit distance.
+// The local part of email-like inputs is compared separately
+func isSimilar(password, input string) bool {
+	password = strings.ToLower(password)
+	input = strings.ToLower(strings.TrimSpace(input))
+
+	candidates := []string{input}
+	if i := strings.LastIndex(input, "@"); i > 0 {
+		candidates = append(candidates, input[:i])
+	}
+
+	for _, c := range candidates {
+		if utf8.RuneCountInString(c) < 3 {
+			continue
+		}
+
+		if strings.Contains(password, c) || strings.Contains(password, reverse(c)) {
+			return true
+		}
+
+		maxLen := utf8.RuneCountInString(password)
+		if l := utf8.RuneCountInString(c); l > maxLen {
+			maxLen = l
+		}
+
+		similarity := 1 - float64(levenshtein(password, c))/float64(maxLen)
+		if similarity >= similarityThreshold {
+			return true
+		}
+	}
+
+	return false
+}
+
+func reverse(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+// levenshtein returns edit distance between two strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(rb)]
+}
+
+func min(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
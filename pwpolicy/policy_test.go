@@ -0,0 +1,72 @@
+package pwpolicy
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func rules(violations []Violation) []string {
+	var res []string
+	for _, v := range violations {
+		res = append(res, v.Rule)
+	}
+	return res
+}
+
+func Test_New_Invalid(t *testing.T) {
+	_, err := New(Config{MinLength: 10, MaxLength: 8})
+	assert.Error(t, err)
+
+	_, err = New(Config{MinScore: 5})
+	assert.Error(t, err)
+
+	_, err = New(Config{BreachedFile: "/nonexistent/breached.txt"})
+	assert.Error(t, err)
+}
+
+func Test_Policy_Check(t *testing.T) {
+	p, err := New(Config{
+		MinLength:     8,
+		MaxLength:     64,
+		RequireUpper:  true,
+		RequireDigit:  true,
+		MinClasses:    3,
+		MinScore:      3,
+		RejectSimilar: true,
+		BreachedFile:  writeBreachedFile(t, map[string]int{"Tr0ub4dor&3": 10}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	tests := []struct {
+		password string
+		want     []string
+	}{
+		{"correct Horse battery staple 42", nil},
+		{"short", []string{"min_length", "upper", "digit", "min_classes", "strength"}},
+		{"Tr0ub4dor&3", []string{"breached"}},
+		{"John.Smith1990", []string{"similar", "strength"}},
+	}
+
+	for _, tt := range tests {
+		violations, err := p.Check(tt.password, "john.smith@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, rules(violations), tt.password)
+	}
+}
+
+func Test_isSimilar(t *testing.T) {
+	assert.True(t, isSimilar("alice2021", "alice@example.com"))
+	assert.True(t, isSimilar("ecila!", "alice"))
+	assert.True(t, isSimilar("aIice", "alice"))
+	assert.False(t, isSimilar("correct horse", "alice"))
+	assert.False(t, isSimilar("ab1234", "ab"))
+}
+
+func Test_levenshtein(t *testing.T) {
+	assert.Equal(t, 3, levenshtein("kitten", "sitting"))
+	assert.Equal(t, 0, levenshtein("", ""))
+	assert.Equal(t, 4, levenshtein("", "тест"))
+}
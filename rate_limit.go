@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gouth/ratelimit"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// maxPeekBodySize is the maximum size of the request body, which is parsed to get user_unique
+const maxPeekBodySize = 64 << 10
+
+// errBodyTooLarge is returned if the request body is larger than maxPeekBodySize
+var errBodyTooLarge = errors.New("request body is too large")
+
+// rateLimit returns middleware, that applies rate limiting rules configured for the app routes.
+// basePath is the path of the app router group, which is trimmed from the route path.
+// Routes without own rules share the buckets of the "*" rules
+func rateLimit(app AppConfig, basePath string) func(c *gin.Context) {
+	conf := app.RateLimits
+
+	return func(c *gin.Context) {
+		route := strings.TrimPrefix(c.FullPath(), basePath)
+		bucketRoute := route
+		rules, ok := conf.Routes[route]
+		if !ok {
+			rules = conf.Routes["*"]
+			bucketRoute = "*"
+		}
+
+		var (
+			reqData  interface{}
+			isParsed bool
+			last     *ratelimit.Result
+		)
+
+		for i, rule := range rules {
+			if rule.Key == "user_unique" && !isParsed {
+				var err error
+				if reqData, err = peekJSONBody(c); err == errBodyTooLarge {
+					c.AbortWithStatusJSON(
+						http.StatusRequestEntityTooLarge,
+						gin.H{"error": err.Error()})
+					return
+				}
+				isParsed = true
+			}
+
+			value, ok := rateLimitKey(c, app, route, rule, reqData)
+			if !ok {
+				continue
+			}
+
+			key := fmt.Sprintf("%s%s:%d:%s:%s", basePath, bucketRoute, i, rule.Key, value)
+			res, err := conf.Backend.Take(c.Request.Context(), key, rule.limit())
+			if err != nil {
+				c.AbortWithStatusJSON(
+					http.StatusInternalServerError,
+					gin.H{"error": err.Error()})
+				return
+			}
+
+			if !res.Allowed {
+				ratelimit.SetHeaders(c.Writer.Header(), res)
+				c.AbortWithStatusJSON(
+					http.StatusTooManyRequests,
+					gin.H{"error": "rate limit exceeded"})
+				return
+			}
+
+			if last == nil || res.Remaining < last.Remaining {
+				last = &res
+			}
+		}
+
+		if last != nil {
+			ratelimit.SetHeaders(c.Writer.Header(), *last)
+		}
+		c.Next()
+	}
+}
+
+// rateLimitKey returns value of the request, which identifies the bucket of the rule.
+// It returns false if the request doesn't contain such value
+func rateLimitKey(c *gin.Context, app AppConfig, route string, rule RateLimitRule, reqData interface{}) (string, bool) {
+	switch rule.Key {
+	case "ip":
+		return c.ClientIP(), true
+	case "client":
+		client := c.GetHeader(app.RateLimits.ClientHeader)
+		return client, client != ""
+	case "user_unique":
+		if reqData == nil {
+			return "", false
+		}
+
+		path := rule.Path
+		if path == "" && route == "/register" {
+			path = app.Main.Register.Fields["user_unique"]
+		} else if path == "" {
+			path = app.Main.AuthN.PasswdBased.UserUnique
+		}
+
+		userUnique, err := GetJSONPath(path, reqData)
+		if err != nil {
+			return "", false
+		}
+		return fmt.Sprint(userUnique), true
+	}
+
+	return "", false
+}
+
+// peekJSONBody parses the request body as json, leaving it readable for the handler.
+// Bodies larger than maxPeekBodySize aren't read and errBodyTooLarge is returned,
+// otherwise invalid json is left for the handler to report
+func peekJSONBody(c *gin.Context) (interface{}, error) {
+	if c.Request.Body == nil {
+		return nil, nil
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(c.Request.Body, maxPeekBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxPeekBodySize {
+		return nil, errBodyTooLarge
+	}
+	c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, nil
+	}
+	return data, nil
+}
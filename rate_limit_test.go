@@ -0,0 +1,60 @@
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gouth/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newRateLimitTestRouter(routes map[string][]RateLimitRule) http.Handler {
+	app := AppConfig{}
+	app.Main.AuthN.PasswdBased.UserUnique = "{$.name}"
+	app.RateLimits = RateLimitConfig{Routes: routes, Backend: ratelimit.NewMemoryBackend()}
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+
+	r := gin.New()
+	appR := r.Group("/app")
+	appR.Use(rateLimit(app, appR.BasePath()))
+	appR.POST("/login", ok)
+	appR.POST("/one", ok)
+	appR.POST("/two", ok)
+	return r
+}
+
+func postCode(r http.Handler, path, body string) int {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+	return w.Code
+}
+
+func Test_rateLimit_SharedDefault(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := newRateLimitTestRouter(map[string][]RateLimitRule{
+		"*": {{Key: "ip", Limit: 2, Period: 60}},
+	})
+
+	// the routes without own rules share one budget
+	assert.Equal(t, http.StatusOK, postCode(r, "/app/one", ""))
+	assert.Equal(t, http.StatusOK, postCode(r, "/app/two", ""))
+	assert.Equal(t, http.StatusTooManyRequests, postCode(r, "/app/one", ""))
+	assert.Equal(t, http.StatusTooManyRequests, postCode(r, "/app/login", ""))
+}
+
+func Test_rateLimit_BodyTooLarge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := newRateLimitTestRouter(map[string][]RateLimitRule{
+		"/login": {{Key: "user_unique", Limit: 1, Period: 60}},
+	})
+
+	assert.Equal(t, http.StatusOK, postCode(r, "/app/login", `{"name": "john"}`))
+	assert.Equal(t, http.StatusTooManyRequests, postCode(r, "/app/login", `{"name": "john"}`))
+
+	// the large body can't skip the user_unique rule
+	body := `{"name": "john", "padding": "` + strings.Repeat("a", maxPeekBodySize) + `"}`
+	assert.Equal(t, http.StatusRequestEntityTooLarge, postCode(r, "/app/login", body))
+}
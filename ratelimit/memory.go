@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// cleanupEvery is the number of Take calls between removals of full buckets
+const cleanupEvery = 1024
+
+// MemoryBackend keeps token buckets in the process memory.
+// It's suitable for single node deployments only
+type MemoryBackend struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	calls   int
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+// NewMemoryBackend returns empty in-memory backend
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{
+		buckets: map[string]*bucket{},
+		now:     time.Now,
+	}
+}
+
+// Take takes one token from the bucket identified by the key
+func (m *MemoryBackend) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+
+	m.calls++
+	if m.calls%cleanupEvery == 0 {
+		m.cleanup(now)
+	}
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: limit.capacity(), updated: now, limit: limit}
+		m.buckets[key] = b
+	}
+
+	b.refill(now)
+	b.limit = limit
+
+	if b.tokens < 1 {
+		return newResult(limit, false, b.tokens), nil
+	}
+
+	b.tokens--
+	return newResult(limit, true, b.tokens), nil
+}
+
+// refill adds tokens accumulated since the last update
+func (b *bucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.updated).Seconds() * b.limit.rate()
+	if capacity := b.limit.capacity(); b.tokens > capacity {
+		b.tokens = capacity
+	}
+	b.updated = now
+}
+
+// cleanup removes buckets which are full, since they are equal to absent ones
+func (m *MemoryBackend) cleanup(now time.Time) {
+	for key, b := range m.buckets {
+		b.refill(now)
+		if b.tokens >= b.limit.capacity() {
+			delete(m.buckets, key)
+		}
+	}
+}
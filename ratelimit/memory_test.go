@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_MemoryBackend_Take(t *testing.T) {
+	now := time.Unix(0, 0)
+	m := NewMemoryBackend()
+	m.now = func() time.Time { return now }
+
+	limit := Limit{Count: 2, Period: time.Minute}
+
+	for i := 1; i >= 0; i-- {
+		res, err := m.Take(context.Background(), "key", limit)
+		assert.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, i, res.Remaining)
+	}
+
+	res, err := m.Take(context.Background(), "key", limit)
+	assert.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 30*time.Second, res.RetryAfter)
+
+	res, err = m.Take(context.Background(), "other", limit)
+	assert.NoError(t, err)
+	assert.True(t, res.Allowed)
+
+	now = now.Add(30 * time.Second)
+	res, err = m.Take(context.Background(), "key", limit)
+	assert.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+}
+
+func Test_MemoryBackend_Burst(t *testing.T) {
+	now := time.Unix(0, 0)
+	m := NewMemoryBackend()
+	m.now = func() time.Time { return now }
+
+	limit := Limit{Count: 1, Period: time.Second, Burst: 3}
+
+	for i := 0; i < 3; i++ {
+		res, _ := m.Take(context.Background(), "key", limit)
+		assert.True(t, res.Allowed)
+	}
+	res, _ := m.Take(context.Background(), "key", limit)
+	assert.False(t, res.Allowed)
+
+	now = now.Add(time.Hour)
+	res, _ = m.Take(context.Background(), "key", limit)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 2, res.Remaining)
+}
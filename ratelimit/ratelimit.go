@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Limit describes token bucket. The bucket holds at most Burst tokens
+// and is refilled with Count tokens per Period
+type Limit struct {
+	Count  int
+	Period time.Duration
+	Burst  int
+}
+
+// Result represents outcome of taking a token from the bucket
+type Result struct {
+	// Whether the token was taken
+	Allowed bool
+
+	// Capacity of the bucket
+	Limit int
+
+	// Number of whole tokens left in the bucket
+	Remaining int
+
+	// Time until the bucket is full again
+	Reset time.Duration
+
+	// Time until the next token is available. It's zero if the token was taken
+	RetryAfter time.Duration
+}
+
+// Backend stores token buckets
+type Backend interface {
+	// Take takes one token from the bucket identified by the key.
+	// The context limits the time of the storage query, if the backend uses one
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// capacity returns maximum number of tokens in the bucket
+func (l Limit) capacity() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return float64(l.Count)
+}
+
+// rate returns number of tokens added to the bucket per second
+func (l Limit) rate() float64 {
+	return float64(l.Count) / l.Period.Seconds()
+}
+
+// newResult builds Result from the number of tokens left in the bucket
+func newResult(limit Limit, allowed bool, tokens float64) Result {
+	res := Result{
+		Allowed:   allowed,
+		Limit:     int(limit.capacity()),
+		Remaining: int(math.Floor(tokens)),
+		Reset:     secondsToDuration((limit.capacity() - tokens) / limit.rate()),
+	}
+
+	if !allowed {
+		res.RetryAfter = secondsToDuration((1 - tokens) / limit.rate())
+	}
+
+	return res
+}
+
+// SetHeaders sets RateLimit-* headers and Retry-After header if the request is rejected
+func SetHeaders(h http.Header, res Result) {
+	h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+
+	if !res.Allowed {
+		h.Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+	}
+}
+
+func secondsToDuration(s float64) time.Duration {
+	if s < 0 {
+		return 0
+	}
+	return time.Duration(s * float64(time.Second))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,22 @@
+package ratelimit
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func Test_SetHeaders(t *testing.T) {
+	h := http.Header{}
+	SetHeaders(h, Result{Allowed: true, Limit: 5, Remaining: 4, Reset: 1500 * time.Millisecond})
+
+	assert.Equal(t, "5", h.Get("RateLimit-Limit"))
+	assert.Equal(t, "4", h.Get("RateLimit-Remaining"))
+	assert.Equal(t, "2", h.Get("RateLimit-Reset"))
+	assert.Empty(t, h.Get("Retry-After"))
+
+	h = http.Header{}
+	SetHeaders(h, Result{Limit: 5, Reset: time.Minute, RetryAfter: 12 * time.Second})
+	assert.Equal(t, "12", h.Get("Retry-After"))
+}
@@ -0,0 +1,31 @@
+package ratelimit
+
+import (
+	"context"
+	"gouth/storage"
+)
+
+// StorageBackend keeps token buckets in the storage,
+// so the limits are shared between all instances of the cluster
+type StorageBackend struct {
+	sess     storage.ConnSession
+	collConf storage.CollConfig
+}
+
+// NewStorageBackend returns backend, that keeps buckets in the given collection.
+// The session must support the rate limits feature
+func NewStorageBackend(sess storage.ConnSession, collConf storage.CollConfig) *StorageBackend {
+	return &StorageBackend{sess: sess, collConf: collConf}
+}
+
+// Take takes one token from the bucket identified by the key.
+// The storage query runs with the given context
+func (s *StorageBackend) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	rateLimits := s.sess.WithContext(ctx).(storage.RateLimits)
+	tokens, allowed, err := rateLimits.TakeRateLimitToken(s.collConf, key, limit.capacity(), limit.rate())
+	if err != nil {
+		return Result{}, err
+	}
+
+	return newResult(limit, allowed, tokens), nil
+}
@@ -0,0 +1,38 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"gouth/storage"
+	_ "gouth/storage/adapters/memory"
+	"testing"
+	"time"
+)
+
+func Test_StorageBackend_Take(t *testing.T) {
+	sess, err := storage.Open(storage.RawStorageConfig{"connection_url": "memory://" + t.Name()}, []string{"rate_limits"})
+	if err != nil {
+		t.Fatalf("open connection by url: %v", err)
+	}
+	defer sess.Close()
+
+	collConf := *storage.NewCollConfig("rate_limits", "key")
+	assert.NoError(t, sess.(storage.RateLimits).CreateRateLimitColl(collConf))
+
+	b := NewStorageBackend(sess, collConf)
+	limit := Limit{Count: 1, Period: time.Minute}
+
+	res, err := b.Take(context.Background(), "key", limit)
+	assert.NoError(t, err)
+	assert.True(t, res.Allowed)
+
+	res, err = b.Take(context.Background(), "key", limit)
+	assert.NoError(t, err)
+	assert.False(t, res.Allowed)
+
+	// the query runs with the context of the request
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = b.Take(ctx, "other", limit)
+	assert.Equal(t, context.Canceled, err)
+}
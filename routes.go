@@ -12,8 +12,40 @@ func initRouter() *gin.Engine {
 	for _, app := range conf.Apps {
 		appR := v.Group(app.PathPrefix)
 
+		if app.RateLimits.isEnabled() {
+			appR.Use(rateLimit(app, appR.BasePath()))
+		}
+
 		appR.POST("/register", registerHandler(app))
 		appR.POST("/login", loginHandler(app))
+
+		if app.Main.AuthN.MFA.isEnabled() {
+			appR.POST("/mfa/recovery_codes", regenerateRecoveryCodesHandler(app))
+			appR.POST("/mfa/recovery_codes/remaining", remainingRecoveryCodesHandler(app))
+
+			if app.Main.AuthN.MFA.TrustedDevices.IsEnabled {
+				appR.POST("/mfa/trusted_devices", listTrustedDevicesHandler(app))
+				appR.POST("/mfa/trusted_devices/:id/revoke", revokeTrustedDeviceHandler(app))
+			}
+		}
+
+		if app.WebAuthn.isEnabled() {
+			appR.POST("/webauthn/register/begin", webauthnRegisterBeginHandler(app))
+			appR.POST("/webauthn/register/finish", webauthnRegisterFinishHandler(app))
+			appR.POST("/webauthn/login/begin", webauthnLoginBeginHandler(app))
+			appR.POST("/webauthn/login/finish", webauthnLoginFinishHandler(app))
+		}
+
+		if app.Admin.isEnabled() {
+			adminR := appR.Group("/admin", adminAuth(app))
+			adminR.GET("/hasher/stats", hasherStatsHandler(app))
+			adminR.GET("/relations", relationsHandler(app))
+			adminR.POST("/relations/refresh", refreshRelationsHandler(app))
+
+			if app.Main.AuthN.PasswdBased.Lockout.isEnabled() {
+				adminR.POST("/unlock", unlockHandler(app))
+			}
+		}
 	}
 
 	return r
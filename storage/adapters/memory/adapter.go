@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"gouth/storage"
+	"net/url"
+	"strings"
+)
+
+// AdapterName is the internal name of the adapter
+const AdapterName = "memory"
+
+var AdapterFeatures = map[string]bool{"users": true, "sessions": true, "mfa": true, "webauthn": true, "login_attempts": true, "rate_limits": true}
+
+// init initializes package by register adapter
+func init() {
+	storage.RegisterAdapter(AdapterName, &memoryAdapter{})
+}
+
+// memoryAdapter represents adapter for the in-memory store
+type memoryAdapter struct {
+}
+
+func (m memoryAdapter) GetFeatures() map[string]bool {
+	return AdapterFeatures
+}
+
+// OpenWithConfig opens the store with the name from connection config
+func (m memoryAdapter) OpenWithConfig(connConf storage.ConnConfig) (storage.ConnSession, error) {
+	sess := &ConnSession{
+		ctx:      context.Background(),
+		connConf: connConf,
+	}
+
+	if err := sess.Open(); err != nil {
+		return nil, err
+	}
+
+	return sess, nil
+}
+
+// ParseUrl parses the connection url into ConnConfig struct. The url looks like this:
+//
+//	memory://name?snapshot=data/name.json&snapshot_interval=1m
+func (m memoryAdapter) ParseUrl(connUrl string) (storage.ConnConfig, error) {
+	connConf := ConnConfig{}
+	if !strings.HasPrefix(connUrl, connConf.AdapterName()+"://") {
+		return nil, fmt.Errorf("expecting memory:// connection schema")
+	}
+
+	u, err := url.Parse(connUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Host == "" || strings.Trim(u.Path, "/") != "" {
+		return nil, fmt.Errorf("invalid connection url")
+	}
+
+	connConf.Name = u.Host
+	connConf.Options = map[string]string{}
+	for k, vv := range u.Query() {
+		connConf.Options[k] = vv[0]
+	}
+
+	return connConf, nil
+}
+
+// NewConfig creates new ConnConfig struct from the raw data, parsed from the config file
+func (m memoryAdapter) NewConfig(data map[string]interface{}) (storage.ConnConfig, error) {
+	name, ok := data["name"].(string)
+	if !ok {
+		return nil, fmt.Errorf("connection config: missing name statement")
+	} else if name == "" {
+		return nil, fmt.Errorf("connection config: name statement cannot be empty")
+	}
+
+	opts := make(map[string]string)
+	if rawOpts, ok := data["options"].(map[string]interface{}); ok {
+		for key, value := range rawOpts {
+			opts[key] = fmt.Sprintf("%v", value)
+		}
+	}
+
+	return ConnConfig{
+		Name:    name,
+		Options: opts,
+	}, nil
+}
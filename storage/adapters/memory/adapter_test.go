@@ -0,0 +1,190 @@
+package memory
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gouth/storage"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// openSess opens session of the store, which is named after the test, so tests don't share data
+func openSess(t *testing.T, features ...string) storage.ConnSession {
+	rawConnData := storage.RawStorageConfig{
+		"connection_url": "memory://" + t.Name(),
+	}
+
+	sess, err := storage.Open(rawConnData, features)
+	if err != nil {
+		t.Fatalf("open connection by url: %v", err)
+	}
+
+	return sess
+}
+
+func Test_memoryAdapter_OpenWithConfig(t *testing.T) {
+	adapter := memoryAdapter{}
+
+	validConnConf := ConnConfig{Name: t.Name(), Options: map[string]string{}}
+	invalidConnConf := ConnConfig{Name: t.Name() + "_invalid", Options: map[string]string{"snapshot_interval": "often"}}
+
+	sess, err := adapter.OpenWithConfig(validConnConf)
+	if assert.NoError(t, err) {
+		assert.NotNil(t, sess)
+		assert.NoError(t, sess.Ping())
+		assert.NoError(t, sess.Close())
+	}
+
+	sess, err = adapter.OpenWithConfig(invalidConnConf)
+	assert.Error(t, err)
+	assert.Nil(t, sess)
+}
+
+func Test_memoryAdapter_SharedStore(t *testing.T) {
+	adapter := memoryAdapter{}
+	connConf := ConnConfig{Name: t.Name(), Options: map[string]string{}}
+	collConf := storage.NewUserCollConfig("users", "id", "username", "password")
+
+	first, err := adapter.OpenWithConfig(connConf)
+	assert.NoError(t, err)
+	second, err := adapter.OpenWithConfig(connConf)
+	assert.NoError(t, err)
+
+	assert.NoError(t, first.CreateUserColl(*collConf))
+	_, err = first.InsertUser(*collConf, *storage.NewInsertUserData("john", "hash"))
+	assert.NoError(t, err)
+
+	pw, err := second.GetUserPassword(*collConf, "john")
+	assert.NoError(t, err)
+	assert.Equal(t, "hash", pw)
+
+	// the data is dropped only with the last session
+	assert.NoError(t, first.Close())
+	pw, err = second.GetUserPassword(*collConf, "john")
+	assert.NoError(t, err)
+	assert.Equal(t, "hash", pw)
+	assert.NoError(t, second.Close())
+
+	third, err := adapter.OpenWithConfig(connConf)
+	assert.NoError(t, err)
+	defer third.Close()
+
+	isExist, err := third.IsCollExists(collConf.ToCollConfig())
+	assert.NoError(t, err)
+	assert.False(t, isExist)
+}
+
+func Test_memoryAdapter_Snapshot(t *testing.T) {
+	adapter := memoryAdapter{}
+	snapshot := filepath.Join(t.TempDir(), "store.json")
+	connConf := ConnConfig{Name: t.Name(), Options: map[string]string{"snapshot": snapshot}}
+	userCollConf := storage.NewUserCollConfig("users", "id", "username", "password")
+	deviceCollConf := storage.NewCollConfig("trusted_devices", "id")
+
+	sess, err := adapter.OpenWithConfig(connConf)
+	assert.NoError(t, err)
+
+	assert.NoError(t, sess.CreateUserColl(*userCollConf))
+	_, err = sess.InsertUser(*userCollConf, *storage.NewInsertUserData("john", "hash"))
+	assert.NoError(t, err)
+
+	mfaSess := sess.(storage.MFA)
+	assert.NoError(t, mfaSess.CreateTrustedDeviceColl(*deviceCollConf))
+	device := storage.TrustedDevice{
+		Id:         "device",
+		UserUnique: "john",
+		Name:       "laptop",
+		CreatedAt:  time.Now().Truncate(time.Second),
+		ExpiresAt:  time.Now().Add(time.Hour).Truncate(time.Second),
+	}
+	assert.NoError(t, mfaSess.InsertTrustedDevice(*deviceCollConf, device))
+	assert.NoError(t, sess.Close())
+
+	sess, err = adapter.OpenWithConfig(connConf)
+	assert.NoError(t, err)
+	defer sess.Close()
+
+	pw, err := sess.GetUserPassword(*userCollConf, "john")
+	assert.NoError(t, err)
+	assert.Equal(t, "hash", pw)
+
+	// the sequence is restored, so ids stay unique
+	id, err := sess.InsertUser(*userCollConf, *storage.NewInsertUserData("jane", "hash"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, id)
+
+	got, err := sess.(storage.MFA).GetTrustedDevice(*deviceCollConf, "device")
+	if assert.NoError(t, err) && assert.NotNil(t, got) {
+		assert.Equal(t, device.Name, got.Name)
+		assert.True(t, device.ExpiresAt.Equal(got.ExpiresAt))
+	}
+}
+
+func Test_memoryAdapter_ParseUrl(t *testing.T) {
+	tests := []struct {
+		name    string
+		connUrl string
+		want    ConnConfig
+		wantErr bool
+	}{
+		{
+			name:    "name with options",
+			connUrl: "memory://test?snapshot=data/test.json&snapshot_interval=1m",
+			want: ConnConfig{
+				Name:    "test",
+				Options: map[string]string{"snapshot": "data/test.json", "snapshot_interval": "1m"},
+			},
+		},
+		{
+			name:    "name without options",
+			connUrl: "memory://test",
+			want:    ConnConfig{Name: "test", Options: map[string]string{}},
+		},
+		{
+			name:    "missing name",
+			connUrl: "memory://",
+			wantErr: true,
+		},
+		{
+			name:    "name with path",
+			connUrl: "memory://test/users",
+			wantErr: true,
+		},
+		{
+			name:    "wrong scheme",
+			connUrl: "sqlite://test",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := memoryAdapter{}
+			got, err := m.ParseUrl(tt.connUrl)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseUrl() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseUrl() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_memoryAdapter_NewConfig(t *testing.T) {
+	m := memoryAdapter{}
+
+	got, err := m.NewConfig(map[string]interface{}{
+		"name":    "test",
+		"options": map[string]interface{}{"snapshot": "data/test.json"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, ConnConfig{Name: "test", Options: map[string]string{"snapshot": "data/test.json"}}, got)
+
+	_, err = m.NewConfig(map[string]interface{}{})
+	assert.Error(t, err)
+
+	_, err = m.NewConfig(map[string]interface{}{"name": ""})
+	assert.Error(t, err)
+}
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// ConnConfig represents a parsed memory connection URL
+type ConnConfig struct {
+	Name    string
+	Options map[string]string
+}
+
+// String reassembles memory connection config into a valid connection url
+func (connConf ConnConfig) String() (string, error) {
+	if connConf.Name == "" {
+		return "", fmt.Errorf("invalid connection url")
+	}
+
+	vv := url.Values{}
+	for k, v := range connConf.Options {
+		vv.Set(k, v)
+	}
+
+	u := url.URL{
+		Scheme:   connConf.AdapterName(),
+		Host:     connConf.Name,
+		RawQuery: vv.Encode(),
+	}
+	return u.String(), nil
+}
+
+// DBName returns the name of the store
+func (connConf ConnConfig) DBName() string {
+	return connConf.Name
+}
+
+// AdapterName return the adapter name, that was used to set up connection
+func (connConf ConnConfig) AdapterName() string {
+	return AdapterName
+}
+
+// snapshotInterval returns period of saving the snapshot. 0 means saving only on close
+func (connConf ConnConfig) snapshotInterval() (time.Duration, error) {
+	for k := range connConf.Options {
+		if k != "snapshot" && k != "snapshot_interval" {
+			return 0, fmt.Errorf("memory: unknown option %s", k)
+		}
+	}
+
+	rawInterval, ok := connConf.Options["snapshot_interval"]
+	if !ok {
+		return 0, nil
+	}
+
+	interval, err := time.ParseDuration(rawInterval)
+	if err != nil {
+		return 0, fmt.Errorf("memory: invalid snapshot_interval: %v", err)
+	}
+	return interval, nil
+}
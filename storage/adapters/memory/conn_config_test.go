@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"testing"
+)
+
+func Test_ConnConfig_String(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    ConnConfig
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "full config to string",
+			conf: ConnConfig{Name: "test", Options: map[string]string{"snapshot": "test.json"}},
+			want: "memory://test?snapshot=test.json",
+		},
+		{
+			name: "config without opts to string",
+			conf: ConnConfig{Name: "test", Options: map[string]string{}},
+			want: "memory://test",
+		},
+		{
+			name:    "config without name to string",
+			conf:    ConnConfig{Options: map[string]string{}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.conf.String()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("String() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("String() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
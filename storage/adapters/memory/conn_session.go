@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"gouth/storage"
+	"time"
+)
+
+// ConnSession represents a session of the in-memory store
+type ConnSession struct {
+	ctx      context.Context
+	store    *store
+	connConf storage.ConnConfig
+	// queries aren't interrupted, so the timeout is only kept for the interface
+	timeout time.Duration
+	// for abstract queries
+	relInfo map[storage.CollPair]storage.RelInfo
+}
+
+// Open opens the store, loading its snapshot if it's set
+func (s *ConnSession) Open() error {
+	connConf, ok := s.connConf.(ConnConfig)
+	if !ok {
+		return errors.New("memory: unexpected connection config type")
+	}
+
+	st, err := openStore(connConf)
+	if err != nil {
+		return err
+	}
+
+	s.store = st
+	return nil
+}
+
+// WithContext returns a copy of the session, which runs queries with the given context
+func (s *ConnSession) WithContext(ctx context.Context) storage.ConnSession {
+	sess := *s
+	sess.ctx = ctx
+	return &sess
+}
+
+// SetQueryTimeout sets the default time limit of one query. 0 means no limit
+func (s *ConnSession) SetQueryTimeout(timeout time.Duration) {
+	s.timeout = timeout
+}
+
+// do runs f with the collection, if the session context isn't done yet
+func (s *ConnSession) do(name, kind string, f func(c *collection) error) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	return s.store.do(name, kind, f)
+}
+
+// create adds empty collection of the kind, if the session context isn't done yet
+func (s *ConnSession) create(name, kind string) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	return s.store.create(name, kind)
+}
+
+// GetConfig returns the connection config that was used to set up the adapter
+func (s *ConnSession) GetConfig() storage.ConnConfig {
+	return s.connConf
+}
+
+// Ping returns an error if the session context is done
+func (s *ConnSession) Ping() error {
+	return s.ctx.Err()
+}
+
+// Close releases the store. The last session saves the snapshot
+func (s *ConnSession) Close() error {
+	return s.store.close()
+}
@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"gouth/storage"
+	"time"
+)
+
+// CreateLoginAttemptColl creates collection for the failed login attempts
+func (s *ConnSession) CreateLoginAttemptColl(collConf storage.CollConfig) error {
+	return s.create(collConf.Name, kindLoginAttempts)
+}
+
+// GetLoginAttempt returns failed login attempts for the key, or nil if there are none
+func (s *ConnSession) GetLoginAttempt(collConf storage.CollConfig, key string) (*storage.LoginAttempt, error) {
+	var attempt *storage.LoginAttempt
+	err := s.do(collConf.Name, kindLoginAttempts, func(c *collection) error {
+		if found, ok := c.Attempts[key]; ok {
+			copied := *found
+			attempt = &copied
+		}
+		return nil
+	})
+
+	return attempt, err
+}
+
+// RegisterLoginFailure increments failures counter for the key.
+// The counter starts over, if the last failure is older than the given window
+func (s *ConnSession) RegisterLoginFailure(collConf storage.CollConfig, key string, window time.Duration) (*storage.LoginAttempt, error) {
+	var attempt storage.LoginAttempt
+	err := s.do(collConf.Name, kindLoginAttempts, func(c *collection) error {
+		now := time.Now()
+
+		found, ok := c.Attempts[key]
+		if !ok {
+			found = &storage.LoginAttempt{Key: key}
+			c.Attempts[key] = found
+		}
+
+		if found.LastFailure.Before(now.Add(-window)) {
+			found.Failures = 1
+		} else {
+			found.Failures++
+		}
+		found.LastFailure = now
+
+		attempt = *found
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &attempt, nil
+}
+
+// ForgetLoginFailure decrements failures counter for the key
+func (s *ConnSession) ForgetLoginFailure(collConf storage.CollConfig, key string) error {
+	return s.do(collConf.Name, kindLoginAttempts, func(c *collection) error {
+		if found, ok := c.Attempts[key]; ok && found.Failures > 0 {
+			found.Failures--
+		}
+		return nil
+	})
+}
+
+// LockLogin forbids login attempts for the key until the given time
+func (s *ConnSession) LockLogin(collConf storage.CollConfig, key string, until time.Time) error {
+	return s.do(collConf.Name, kindLoginAttempts, func(c *collection) error {
+		if found, ok := c.Attempts[key]; ok {
+			found.LockedUntil = until
+		}
+		return nil
+	})
+}
+
+// ResetLoginAttempts removes failed login attempts and lock for the key
+func (s *ConnSession) ResetLoginAttempts(collConf storage.CollConfig, key string) error {
+	return s.do(collConf.Name, kindLoginAttempts, func(c *collection) error {
+		delete(c.Attempts, key)
+		return nil
+	})
+}
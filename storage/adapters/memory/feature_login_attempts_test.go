@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gouth/storage"
+	"testing"
+	"time"
+)
+
+func Test_Session_LoginAttempts(t *testing.T) {
+	sess := openSess(t, "login_attempts")
+	defer sess.Close()
+
+	laSess := sess.(storage.LoginAttempts)
+	collConf := storage.NewCollConfig("login_attempts", "key")
+	assert.NoError(t, laSess.CreateLoginAttemptColl(*collConf))
+
+	attempt, err := laSess.GetLoginAttempt(*collConf, "john")
+	assert.NoError(t, err)
+	assert.Nil(t, attempt)
+
+	for i := 1; i <= 3; i++ {
+		attempt, err = laSess.RegisterLoginFailure(*collConf, "john", time.Minute)
+		assert.NoError(t, err)
+		assert.Equal(t, i, attempt.Failures)
+	}
+
+	assert.NoError(t, laSess.ForgetLoginFailure(*collConf, "john"))
+
+	attempt, err = laSess.GetLoginAttempt(*collConf, "john")
+	if assert.NoError(t, err) && assert.NotNil(t, attempt) {
+		assert.Equal(t, 2, attempt.Failures)
+	}
+
+	// failures older than the window aren't counted
+	attempt, err = laSess.RegisterLoginFailure(*collConf, "john", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, attempt.Failures)
+
+	until := time.Now().Add(time.Minute)
+	assert.NoError(t, laSess.LockLogin(*collConf, "john", until))
+
+	attempt, err = laSess.GetLoginAttempt(*collConf, "john")
+	if assert.NoError(t, err) && assert.NotNil(t, attempt) {
+		assert.True(t, until.Equal(attempt.LockedUntil))
+	}
+
+	assert.NoError(t, laSess.ResetLoginAttempts(*collConf, "john"))
+
+	attempt, err = laSess.GetLoginAttempt(*collConf, "john")
+	assert.NoError(t, err)
+	assert.Nil(t, attempt)
+}
@@ -0,0 +1,139 @@
+package memory
+
+import (
+	"fmt"
+	"gouth/storage"
+	"sort"
+	"time"
+)
+
+// CreateRecoveryCodesColl creates collection for the hashed recovery codes
+func (s *ConnSession) CreateRecoveryCodesColl(collConf storage.CollConfig) error {
+	return s.create(collConf.Name, kindRecoveryCodes)
+}
+
+// SetRecoveryCodes replaces all recovery codes of the user with the given ones, their lookups and hashes are stored
+func (s *ConnSession) SetRecoveryCodes(collConf storage.CollConfig, userUnique interface{}, newCodes []storage.RecoveryCode) error {
+	return s.do(collConf.Name, kindRecoveryCodes, func(c *collection) error {
+		key := keyOf(userUnique)
+
+		codes := c.RecoveryCodes[:0]
+		for _, code := range c.RecoveryCodes {
+			if code.UserUnique != key {
+				codes = append(codes, code)
+			}
+		}
+
+		for _, code := range newCodes {
+			codes = append(codes, &recoveryCodeRecord{Id: c.nextId(), UserUnique: key, Lookup: code.Lookup, Hash: code.Hash})
+		}
+		c.RecoveryCodes = codes
+		return nil
+	})
+}
+
+// GetRecoveryCodes returns all recovery codes of the user, including used ones
+func (s *ConnSession) GetRecoveryCodes(collConf storage.CollConfig, userUnique interface{}) ([]storage.RecoveryCode, error) {
+	var codes []storage.RecoveryCode
+	err := s.do(collConf.Name, kindRecoveryCodes, func(c *collection) error {
+		key := keyOf(userUnique)
+		for _, code := range c.RecoveryCodes {
+			if code.UserUnique == key {
+				codes = append(codes, storage.RecoveryCode{Id: code.Id, Lookup: code.Lookup, Hash: code.Hash, Used: code.Used})
+			}
+		}
+		return nil
+	})
+
+	return codes, err
+}
+
+// UseRecoveryCode marks recovery code with the given id as used.
+// It returns false if the code has already been used
+func (s *ConnSession) UseRecoveryCode(collConf storage.CollConfig, id interface{}) (bool, error) {
+	var isUsed bool
+	err := s.do(collConf.Name, kindRecoveryCodes, func(c *collection) error {
+		key := keyOf(id)
+		for _, code := range c.RecoveryCodes {
+			if keyOf(code.Id) == key && !code.Used {
+				code.Used = true
+				isUsed = true
+				break
+			}
+		}
+		return nil
+	})
+
+	return isUsed, err
+}
+
+// CreateTrustedDeviceColl creates collection for the trusted devices
+func (s *ConnSession) CreateTrustedDeviceColl(collConf storage.CollConfig) error {
+	return s.create(collConf.Name, kindTrustedDevices)
+}
+
+// InsertTrustedDevice inserts trusted device in the device collection and removes expired ones
+func (s *ConnSession) InsertTrustedDevice(collConf storage.CollConfig, device storage.TrustedDevice) error {
+	return s.do(collConf.Name, kindTrustedDevices, func(c *collection) error {
+		if _, ok := c.Devices[device.Id]; ok {
+			return fmt.Errorf("memory: trusted device %s already exists", device.Id)
+		}
+
+		device.UserUnique = keyOf(device.UserUnique)
+		c.Devices[device.Id] = &device
+
+		now := time.Now()
+		for id, d := range c.Devices {
+			if d.ExpiresAt.Before(now) {
+				delete(c.Devices, id)
+			}
+		}
+		return nil
+	})
+}
+
+// GetTrustedDevice returns unexpired trusted device by its id, or nil if it doesn't exist
+func (s *ConnSession) GetTrustedDevice(collConf storage.CollConfig, id string) (*storage.TrustedDevice, error) {
+	var device *storage.TrustedDevice
+	err := s.do(collConf.Name, kindTrustedDevices, func(c *collection) error {
+		if d, ok := c.Devices[id]; ok && d.ExpiresAt.After(time.Now()) {
+			copied := *d
+			device = &copied
+		}
+		return nil
+	})
+
+	return device, err
+}
+
+// GetTrustedDevices returns all unexpired trusted devices of the user
+func (s *ConnSession) GetTrustedDevices(collConf storage.CollConfig, userUnique interface{}) ([]storage.TrustedDevice, error) {
+	var devices []storage.TrustedDevice
+	err := s.do(collConf.Name, kindTrustedDevices, func(c *collection) error {
+		key, now := keyOf(userUnique), time.Now()
+		for _, d := range c.Devices {
+			if keyOf(d.UserUnique) == key && d.ExpiresAt.After(now) {
+				devices = append(devices, *d)
+			}
+		}
+		return nil
+	})
+
+	sort.Slice(devices, func(i, j int) bool { return devices[i].CreatedAt.Before(devices[j].CreatedAt) })
+	return devices, err
+}
+
+// DeleteTrustedDevice revokes trusted device of the user.
+// It returns false if there is no such device
+func (s *ConnSession) DeleteTrustedDevice(collConf storage.CollConfig, userUnique interface{}, id string) (bool, error) {
+	var isDeleted bool
+	err := s.do(collConf.Name, kindTrustedDevices, func(c *collection) error {
+		if d, ok := c.Devices[id]; ok && keyOf(d.UserUnique) == keyOf(userUnique) {
+			delete(c.Devices, id)
+			isDeleted = true
+		}
+		return nil
+	})
+
+	return isDeleted, err
+}
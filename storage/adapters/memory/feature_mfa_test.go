@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gouth/storage"
+	"testing"
+	"time"
+)
+
+func Test_Session_RecoveryCodes(t *testing.T) {
+	sess := openSess(t, "mfa")
+	defer sess.Close()
+
+	mfaSess := sess.(storage.MFA)
+	collConf := storage.NewCollConfig("recovery_codes", "id")
+	assert.NoError(t, mfaSess.CreateRecoveryCodesColl(*collConf))
+
+	assert.NoError(t, mfaSess.SetRecoveryCodes(*collConf, "john", []storage.RecoveryCode{{Lookup: "a", Hash: "old"}}))
+	assert.NoError(t, mfaSess.SetRecoveryCodes(*collConf, "jane", []storage.RecoveryCode{{Lookup: "b", Hash: "jane's"}}))
+	assert.NoError(t, mfaSess.SetRecoveryCodes(*collConf, "john", []storage.RecoveryCode{
+		{Lookup: "c", Hash: "first"},
+		{Lookup: "d", Hash: "second"},
+	}))
+
+	codes, err := mfaSess.GetRecoveryCodes(*collConf, "john")
+	assert.NoError(t, err)
+	if assert.Len(t, codes, 2) {
+		assert.Equal(t, "c", codes[0].Lookup)
+		assert.Equal(t, "first", codes[0].Hash)
+		assert.Equal(t, "d", codes[1].Lookup)
+		assert.Equal(t, "second", codes[1].Hash)
+	}
+
+	isUsed, err := mfaSess.UseRecoveryCode(*collConf, codes[0].Id)
+	assert.NoError(t, err)
+	assert.True(t, isUsed)
+
+	isUsed, err = mfaSess.UseRecoveryCode(*collConf, codes[0].Id)
+	assert.NoError(t, err)
+	assert.False(t, isUsed)
+
+	codes, err = mfaSess.GetRecoveryCodes(*collConf, "jane")
+	assert.NoError(t, err)
+	if assert.Len(t, codes, 1) {
+		assert.False(t, codes[0].Used)
+	}
+}
+
+func Test_Session_TrustedDevices(t *testing.T) {
+	sess := openSess(t, "mfa")
+	defer sess.Close()
+
+	mfaSess := sess.(storage.MFA)
+	collConf := storage.NewCollConfig("trusted_devices", "id")
+	assert.NoError(t, mfaSess.CreateTrustedDeviceColl(*collConf))
+
+	now := time.Now()
+	devices := []storage.TrustedDevice{
+		{Id: "second", UserUnique: "john", CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+		{Id: "first", UserUnique: "john", CreatedAt: now.Add(-time.Minute), ExpiresAt: now.Add(time.Hour)},
+		{Id: "expired", UserUnique: "john", CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(-time.Minute)},
+	}
+	for _, device := range devices {
+		assert.NoError(t, mfaSess.InsertTrustedDevice(*collConf, device))
+	}
+	assert.Error(t, mfaSess.InsertTrustedDevice(*collConf, devices[0]))
+
+	got, err := mfaSess.GetTrustedDevices(*collConf, "john")
+	assert.NoError(t, err)
+	if assert.Len(t, got, 2) {
+		assert.Equal(t, "first", got[0].Id)
+		assert.Equal(t, "second", got[1].Id)
+	}
+
+	device, err := mfaSess.GetTrustedDevice(*collConf, "expired")
+	assert.NoError(t, err)
+	assert.Nil(t, device)
+
+	isDeleted, err := mfaSess.DeleteTrustedDevice(*collConf, "jane", "first")
+	assert.NoError(t, err)
+	assert.False(t, isDeleted)
+
+	isDeleted, err = mfaSess.DeleteTrustedDevice(*collConf, "john", "first")
+	assert.NoError(t, err)
+	assert.True(t, isDeleted)
+
+	device, err = mfaSess.GetTrustedDevice(*collConf, "first")
+	assert.NoError(t, err)
+	assert.Nil(t, device)
+}
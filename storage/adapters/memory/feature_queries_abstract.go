@@ -0,0 +1,12 @@
+package memory
+
+import "gouth/storage"
+
+func (s *ConnSession) RelInfo() map[storage.CollPair]storage.RelInfo {
+	return s.relInfo
+}
+
+// Read isn't supported by the in-memory store
+func (s *ConnSession) Read(string, ...interface{}) (storage.JSONCollResult, error) {
+	return nil, storage.ErrReadUnsupported
+}
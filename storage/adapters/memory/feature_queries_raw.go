@@ -0,0 +1,13 @@
+package memory
+
+import "gouth/storage"
+
+// RawExec isn't supported by the in-memory store
+func (s *ConnSession) RawExec(string, ...interface{}) error {
+	return ErrRawQuery
+}
+
+// RawQuery isn't supported by the in-memory store
+func (s *ConnSession) RawQuery(string, ...interface{}) (storage.JSONCollResult, error) {
+	return nil, ErrRawQuery
+}
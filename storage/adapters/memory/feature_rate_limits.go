@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"gouth/storage"
+	"math"
+	"time"
+)
+
+// CreateRateLimitColl creates collection for the token buckets
+func (s *ConnSession) CreateRateLimitColl(collConf storage.CollConfig) error {
+	return s.create(collConf.Name, kindRateLimits)
+}
+
+// TakeRateLimitToken atomically refills the bucket with the given capacity and refill rate
+// (tokens per second) and takes one token from it.
+// It returns the number of tokens left and whether the token was taken
+func (s *ConnSession) TakeRateLimitToken(collConf storage.CollConfig, key string, capacity, rate float64) (float64, bool, error) {
+	var (
+		tokens  float64
+		allowed bool
+	)
+	err := s.do(collConf.Name, kindRateLimits, func(c *collection) error {
+		now := time.Now()
+
+		b, ok := c.Buckets[key]
+		if !ok {
+			b = &bucketRecord{Tokens: capacity}
+			c.Buckets[key] = b
+		} else {
+			b.Tokens = math.Min(capacity, b.Tokens+now.Sub(b.UpdatedAt).Seconds()*rate)
+		}
+
+		allowed = b.Tokens >= 1
+		if allowed {
+			b.Tokens--
+		}
+		b.UpdatedAt = now
+
+		tokens = b.Tokens
+		return nil
+	})
+
+	return tokens, allowed, err
+}
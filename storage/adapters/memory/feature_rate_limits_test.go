@@ -0,0 +1,35 @@
+package memory
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gouth/storage"
+	"testing"
+)
+
+func Test_Session_TakeRateLimitToken(t *testing.T) {
+	sess := openSess(t, "rate_limits")
+	defer sess.Close()
+
+	rlSess := sess.(storage.RateLimits)
+	collConf := storage.NewCollConfig("rate_limits", "key")
+	assert.NoError(t, rlSess.CreateRateLimitColl(*collConf))
+
+	tokens, allowed, err := rlSess.TakeRateLimitToken(*collConf, "ip", 2, 0)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+	assert.EqualValues(t, 1, tokens)
+
+	_, allowed, err = rlSess.TakeRateLimitToken(*collConf, "ip", 2, 0)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+
+	tokens, allowed, err = rlSess.TakeRateLimitToken(*collConf, "ip", 2, 0)
+	assert.NoError(t, err)
+	assert.False(t, allowed)
+	assert.EqualValues(t, 0, tokens)
+
+	// buckets are independent
+	_, allowed, err = rlSess.TakeRateLimitToken(*collConf, "other ip", 2, 0)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+}
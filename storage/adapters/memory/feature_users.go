@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"fmt"
+	"gouth/storage"
+)
+
+// IsCollExists checks whether the given collection exists
+func (s *ConnSession) IsCollExists(collConf storage.CollConfig) (bool, error) {
+	if err := s.ctx.Err(); err != nil {
+		return false, err
+	}
+	return s.store.exists(collConf.Name), nil
+}
+
+// CreateUserColl creates user collection with traits passed by UserCollectionConfig
+func (s *ConnSession) CreateUserColl(collConf storage.UserCollConfig) error {
+	return s.create(collConf.Name, kindUsers)
+}
+
+// InsertUser inserts user entity in the user collection and returns its pk.
+// User unique must be unique within the collection
+func (s *ConnSession) InsertUser(collConf storage.UserCollConfig, insUserData storage.InsertUserData) (storage.JSONCollResult, error) {
+	user := &userRecord{
+		UserUnique:  insUserData.UserUnique,
+		UserConfirm: insUserData.UserConfirm,
+	}
+	if collConf.IsPkGenerated() {
+		pk, err := storage.NewPk(collConf.PkType)
+		if err != nil {
+			return nil, err
+		}
+		user.Key = pk
+	}
+
+	err := s.do(collConf.Name, kindUsers, func(c *collection) error {
+		key := keyOf(insUserData.UserUnique)
+		if _, ok := c.Users[key]; ok {
+			return fmt.Errorf("memory: user %s already exists", key)
+		}
+
+		if user.Key == "" {
+			user.Id = c.nextId()
+		}
+		c.Users[key] = user
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Key != "" {
+		return user.Key, nil
+	}
+	return user.Id, nil
+}
+
+// GetUserPassword returns password hash of the user with the given user unique
+func (s *ConnSession) GetUserPassword(collConf storage.UserCollConfig, userUnique interface{}) (storage.JSONCollResult, error) {
+	var pw interface{}
+	err := s.do(collConf.Name, kindUsers, func(c *collection) error {
+		user, ok := c.Users[keyOf(userUnique)]
+		if !ok {
+			return storage.ErrUserNotFound
+		}
+
+		pw = user.UserConfirm
+		return nil
+	})
+
+	return pw, err
+}
+
+// UpdateUserPassword replaces password hash of the user with the given user unique
+func (s *ConnSession) UpdateUserPassword(collConf storage.UserCollConfig, userUnique interface{}, pwHash string) error {
+	return s.do(collConf.Name, kindUsers, func(c *collection) error {
+		if user, ok := c.Users[keyOf(userUnique)]; ok {
+			user.UserConfirm = pwHash
+		}
+		return nil
+	})
+}
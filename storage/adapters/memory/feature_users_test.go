@@ -0,0 +1,107 @@
+package memory
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"gouth/storage"
+	"testing"
+)
+
+func Test_Session_IsCollExists(t *testing.T) {
+	usersSess := openSess(t, "users")
+	defer usersSess.Close()
+
+	collConf := storage.NewUserCollConfig("users", "id", "username", "password")
+
+	isExist, err := usersSess.IsCollExists(collConf.ToCollConfig())
+	assert.NoError(t, err)
+	assert.False(t, isExist)
+
+	assert.NoError(t, usersSess.CreateUserColl(*collConf))
+	assert.Error(t, usersSess.CreateUserColl(*collConf))
+
+	isExist, err = usersSess.IsCollExists(collConf.ToCollConfig())
+	assert.NoError(t, err)
+	assert.True(t, isExist)
+}
+
+func Test_Session_InsertUser(t *testing.T) {
+	usersSess := openSess(t, "users")
+	defer usersSess.Close()
+
+	collConf := storage.NewUserCollConfig("users", "id", "username", "password")
+
+	_, err := usersSess.InsertUser(*collConf, *storage.NewInsertUserData("john", "hash"))
+	assert.Error(t, err)
+
+	assert.NoError(t, usersSess.CreateUserColl(*collConf))
+
+	id, err := usersSess.InsertUser(*collConf, *storage.NewInsertUserData("john", "hash"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, id)
+
+	id, err = usersSess.InsertUser(*collConf, *storage.NewInsertUserData("jane", "hash"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, id)
+
+	_, err = usersSess.InsertUser(*collConf, *storage.NewInsertUserData("john", "other hash"))
+	assert.Error(t, err)
+
+	pw, err := usersSess.GetUserPassword(*collConf, "john")
+	assert.NoError(t, err)
+	assert.Equal(t, "hash", pw)
+
+	_, err = usersSess.GetUserPassword(*collConf, "nobody")
+	assert.Equal(t, storage.ErrUserNotFound, err)
+}
+
+func Test_Session_InsertUser_GeneratedPk(t *testing.T) {
+	usersSess := openSess(t, "users")
+	defer usersSess.Close()
+
+	collConf := storage.NewUserCollConfig("users", "id", "username", "password")
+	collConf.PkType = storage.PkUUIDv7
+	assert.NoError(t, usersSess.CreateUserColl(*collConf))
+
+	john, err := usersSess.InsertUser(*collConf, *storage.NewInsertUserData("john", "hash"))
+	assert.NoError(t, err)
+	assert.Len(t, john, 36)
+
+	jane, err := usersSess.InsertUser(*collConf, *storage.NewInsertUserData("jane", "hash"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, john, jane)
+}
+
+func Test_Session_UpdateUserPassword(t *testing.T) {
+	usersSess := openSess(t, "users")
+	defer usersSess.Close()
+
+	collConf := storage.NewUserCollConfig("users", "id", "username", "password")
+	assert.NoError(t, usersSess.CreateUserColl(*collConf))
+
+	_, err := usersSess.InsertUser(*collConf, *storage.NewInsertUserData("john", "old hash"))
+	assert.NoError(t, err)
+
+	assert.NoError(t, usersSess.UpdateUserPassword(*collConf, "john", "new hash"))
+
+	pw, err := usersSess.GetUserPassword(*collConf, "john")
+	assert.NoError(t, err)
+	assert.Equal(t, "new hash", pw)
+}
+
+func Test_Session_WithContext(t *testing.T) {
+	usersSess := openSess(t, "users")
+	defer usersSess.Close()
+
+	collConf := storage.NewUserCollConfig("users", "id", "username", "password")
+	assert.NoError(t, usersSess.CreateUserColl(*collConf))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := usersSess.WithContext(ctx).InsertUser(*collConf, *storage.NewInsertUserData("john", "hash"))
+	assert.Equal(t, context.Canceled, err)
+
+	_, err = usersSess.GetUserPassword(*collConf, "john")
+	assert.Equal(t, storage.ErrUserNotFound, err)
+}
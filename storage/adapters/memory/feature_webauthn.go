@@ -0,0 +1,116 @@
+package memory
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"gouth/storage"
+	"sort"
+	"time"
+)
+
+// CreateWebAuthnCredColl creates collection for the public key credentials
+func (s *ConnSession) CreateWebAuthnCredColl(collConf storage.CollConfig) error {
+	return s.create(collConf.Name, kindWebAuthnCreds)
+}
+
+// InsertWebAuthnCred inserts public key credential in the credential collection
+func (s *ConnSession) InsertWebAuthnCred(collConf storage.CollConfig, cred storage.WebAuthnCredential) error {
+	return s.do(collConf.Name, kindWebAuthnCreds, func(c *collection) error {
+		key := base64.RawURLEncoding.EncodeToString(cred.Id)
+		if _, ok := c.Creds[key]; ok {
+			return fmt.Errorf("memory: credential %s already exists", key)
+		}
+
+		if cred.Transports == nil {
+			cred.Transports = []string{}
+		}
+		cred.UserUnique = keyOf(cred.UserUnique)
+		c.Creds[key] = &cred
+		return nil
+	})
+}
+
+// GetWebAuthnCreds returns all public key credentials of the user
+func (s *ConnSession) GetWebAuthnCreds(collConf storage.CollConfig, userUnique interface{}) ([]storage.WebAuthnCredential, error) {
+	var creds []storage.WebAuthnCredential
+	err := s.do(collConf.Name, kindWebAuthnCreds, func(c *collection) error {
+		key := keyOf(userUnique)
+		for _, cred := range c.Creds {
+			if keyOf(cred.UserUnique) == key {
+				creds = append(creds, *cred)
+			}
+		}
+		return nil
+	})
+
+	sort.Slice(creds, func(i, j int) bool { return bytes.Compare(creds[i].Id, creds[j].Id) < 0 })
+	return creds, err
+}
+
+// GetWebAuthnCred returns public key credential by its id, or nil if it doesn't exist
+func (s *ConnSession) GetWebAuthnCred(collConf storage.CollConfig, id []byte) (*storage.WebAuthnCredential, error) {
+	var cred *storage.WebAuthnCredential
+	err := s.do(collConf.Name, kindWebAuthnCreds, func(c *collection) error {
+		if found, ok := c.Creds[base64.RawURLEncoding.EncodeToString(id)]; ok {
+			copied := *found
+			cred = &copied
+		}
+		return nil
+	})
+
+	return cred, err
+}
+
+// UpdateWebAuthnSignCount saves the last signature counter of the credential
+func (s *ConnSession) UpdateWebAuthnSignCount(collConf storage.CollConfig, id []byte, signCount uint32) error {
+	return s.do(collConf.Name, kindWebAuthnCreds, func(c *collection) error {
+		if cred, ok := c.Creds[base64.RawURLEncoding.EncodeToString(id)]; ok {
+			cred.SignCount = signCount
+		}
+		return nil
+	})
+}
+
+// CreateWebAuthnChallengeColl creates collection for the states of unfinished ceremonies
+func (s *ConnSession) CreateWebAuthnChallengeColl(collConf storage.CollConfig) error {
+	return s.create(collConf.Name, kindWebAuthnChallenges)
+}
+
+// InsertWebAuthnChallenge saves the state of the ceremony until it expires and removes expired ones
+func (s *ConnSession) InsertWebAuthnChallenge(collConf storage.CollConfig, id string, data string, expires time.Time) error {
+	return s.do(collConf.Name, kindWebAuthnChallenges, func(c *collection) error {
+		if _, ok := c.Challenges[id]; ok {
+			return fmt.Errorf("memory: challenge %s already exists", id)
+		}
+		c.Challenges[id] = &challengeRecord{Data: data, ExpiresAt: expires}
+
+		now := time.Now()
+		for id, ch := range c.Challenges {
+			if ch.ExpiresAt.Before(now) {
+				delete(c.Challenges, id)
+			}
+		}
+		return nil
+	})
+}
+
+// PopWebAuthnChallenge returns the state of the ceremony and removes it,
+// so it can't be used twice. It returns empty string if there is no such unexpired ceremony
+func (s *ConnSession) PopWebAuthnChallenge(collConf storage.CollConfig, id string) (string, error) {
+	var data string
+	err := s.do(collConf.Name, kindWebAuthnChallenges, func(c *collection) error {
+		ch, ok := c.Challenges[id]
+		if !ok {
+			return nil
+		}
+
+		delete(c.Challenges, id)
+		if time.Now().Before(ch.ExpiresAt) {
+			data = ch.Data
+		}
+		return nil
+	})
+
+	return data, err
+}
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gouth/storage"
+	"testing"
+	"time"
+)
+
+func Test_Session_WebAuthnCreds(t *testing.T) {
+	sess := openSess(t, "webauthn")
+	defer sess.Close()
+
+	waSess := sess.(storage.WebAuthn)
+	collConf := storage.NewCollConfig("webauthn_creds", "id")
+	assert.NoError(t, waSess.CreateWebAuthnCredColl(*collConf))
+
+	cred := storage.WebAuthnCredential{Id: []byte("cred"), UserUnique: "john", PublicKey: []byte("key")}
+	assert.NoError(t, waSess.InsertWebAuthnCred(*collConf, cred))
+	assert.Error(t, waSess.InsertWebAuthnCred(*collConf, cred))
+
+	assert.NoError(t, waSess.UpdateWebAuthnSignCount(*collConf, cred.Id, 5))
+
+	got, err := waSess.GetWebAuthnCred(*collConf, cred.Id)
+	if assert.NoError(t, err) && assert.NotNil(t, got) {
+		assert.Equal(t, cred.PublicKey, got.PublicKey)
+		assert.EqualValues(t, 5, got.SignCount)
+	}
+
+	got, err = waSess.GetWebAuthnCred(*collConf, []byte("missing"))
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+
+	creds, err := waSess.GetWebAuthnCreds(*collConf, "john")
+	assert.NoError(t, err)
+	assert.Len(t, creds, 1)
+}
+
+func Test_Session_WebAuthnChallenges(t *testing.T) {
+	sess := openSess(t, "webauthn")
+	defer sess.Close()
+
+	waSess := sess.(storage.WebAuthn)
+	collConf := storage.NewCollConfig("webauthn_challenges", "id")
+	assert.NoError(t, waSess.CreateWebAuthnChallengeColl(*collConf))
+
+	assert.NoError(t, waSess.InsertWebAuthnChallenge(*collConf, "valid", "data", time.Now().Add(time.Minute)))
+	assert.NoError(t, waSess.InsertWebAuthnChallenge(*collConf, "expired", "data", time.Now().Add(-time.Minute)))
+
+	data, err := waSess.PopWebAuthnChallenge(*collConf, "valid")
+	assert.NoError(t, err)
+	assert.Equal(t, "data", data)
+
+	// the ceremony can't be finished twice
+	data, err = waSess.PopWebAuthnChallenge(*collConf, "valid")
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+
+	data, err = waSess.PopWebAuthnChallenge(*collConf, "expired")
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+}
@@ -0,0 +1,273 @@
+package memory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gouth/storage"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Kinds of the collections. Each collection keeps records of one feature
+const (
+	kindUsers              = "users"
+	kindRecoveryCodes      = "recovery_codes"
+	kindTrustedDevices     = "trusted_devices"
+	kindWebAuthnCreds      = "webauthn_creds"
+	kindWebAuthnChallenges = "webauthn_challenges"
+	kindLoginAttempts      = "login_attempts"
+	kindRateLimits         = "rate_limits"
+)
+
+var (
+	// ErrRawQuery is returned by raw queries, which can't be run without a query language
+	ErrRawQuery = errors.New("memory: raw queries aren't supported")
+)
+
+var (
+	stores   = make(map[string]*store)
+	storesMU sync.Mutex
+)
+
+// store represents named in-memory database. Sessions opened with the same name share it
+type store struct {
+	mu    sync.Mutex
+	colls map[string]*collection
+
+	name     string
+	refs     int
+	snapshot string
+	stop     chan struct{}
+}
+
+// collection keeps records of one kind. Only the fields of its kind are used
+type collection struct {
+	Kind   string `json:"kind"`
+	NextId int64  `json:"next_id"`
+
+	// users by user unique
+	Users map[string]*userRecord `json:"users,omitempty"`
+
+	RecoveryCodes []*recoveryCodeRecord `json:"recovery_codes,omitempty"`
+
+	// trusted devices by id
+	Devices map[string]*storage.TrustedDevice `json:"devices,omitempty"`
+
+	// credentials by base64 encoded id
+	Creds map[string]*storage.WebAuthnCredential `json:"creds,omitempty"`
+
+	// ceremony states by id
+	Challenges map[string]*challengeRecord `json:"challenges,omitempty"`
+
+	// failed login attempts by key
+	Attempts map[string]*storage.LoginAttempt `json:"attempts,omitempty"`
+
+	// token buckets by key
+	Buckets map[string]*bucketRecord `json:"buckets,omitempty"`
+}
+
+// userRecord represents the user, its pk is Key for the generated pk types, e.g. uuid, and Id otherwise
+type userRecord struct {
+	Id          int64       `json:"id,omitempty"`
+	Key         string      `json:"key,omitempty"`
+	UserUnique  interface{} `json:"user_unique"`
+	UserConfirm interface{} `json:"user_confirm"`
+}
+
+type recoveryCodeRecord struct {
+	Id         int64  `json:"id"`
+	UserUnique string `json:"user_unique"`
+	Lookup     string `json:"lookup"`
+	Hash       string `json:"hash"`
+	Used       bool   `json:"used"`
+}
+
+type challengeRecord struct {
+	Data      string    `json:"data"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type bucketRecord struct {
+	Tokens    float64   `json:"tokens"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// openStore returns the store with the given name, creating it if it isn't open yet.
+// New store is loaded from the snapshot file if it exists
+func openStore(connConf ConnConfig) (*store, error) {
+	storesMU.Lock()
+	defer storesMU.Unlock()
+
+	if st, ok := stores[connConf.Name]; ok {
+		st.refs++
+		return st, nil
+	}
+
+	interval, err := connConf.snapshotInterval()
+	if err != nil {
+		return nil, err
+	}
+
+	st := &store{
+		colls:    map[string]*collection{},
+		name:     connConf.Name,
+		refs:     1,
+		snapshot: connConf.Options["snapshot"],
+	}
+	if err := st.load(); err != nil {
+		return nil, err
+	}
+
+	if st.snapshot != "" && interval > 0 {
+		st.stop = make(chan struct{})
+		go st.saveEvery(interval)
+	}
+
+	stores[st.name] = st
+	return st, nil
+}
+
+// close releases the store. The last session saves the snapshot and drops the data
+func (st *store) close() error {
+	storesMU.Lock()
+	defer storesMU.Unlock()
+
+	st.refs--
+	if st.refs > 0 {
+		return nil
+	}
+
+	if st.stop != nil {
+		close(st.stop)
+	}
+	delete(stores, st.name)
+	return st.save()
+}
+
+// load reads collections from the snapshot file
+func (st *store) load() error {
+	if st.snapshot == "" {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(st.snapshot)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, &st.colls); err != nil {
+		return fmt.Errorf("memory: invalid snapshot %s: %v", st.snapshot, err)
+	}
+	return nil
+}
+
+// save writes collections to the snapshot file. The file is replaced atomically
+func (st *store) save() error {
+	if st.snapshot == "" {
+		return nil
+	}
+
+	st.mu.Lock()
+	data, err := json.Marshal(st.colls)
+	st.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(st.snapshot), filepath.Base(st.snapshot)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), st.snapshot)
+}
+
+func (st *store) saveEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// the next tick retries, so the error is only possible to lose until close
+			_ = st.save()
+		case <-st.stop:
+			return
+		}
+	}
+}
+
+// create adds empty collection of the kind
+func (st *store) create(name, kind string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if _, ok := st.colls[name]; ok {
+		return fmt.Errorf("memory: collection %s already exists", name)
+	}
+
+	st.colls[name] = &collection{
+		Kind:       kind,
+		NextId:     1,
+		Users:      map[string]*userRecord{},
+		Devices:    map[string]*storage.TrustedDevice{},
+		Creds:      map[string]*storage.WebAuthnCredential{},
+		Challenges: map[string]*challengeRecord{},
+		Attempts:   map[string]*storage.LoginAttempt{},
+		Buckets:    map[string]*bucketRecord{},
+	}
+	return nil
+}
+
+// exists checks whether the collection exists
+func (st *store) exists(name string) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	_, ok := st.colls[name]
+	return ok
+}
+
+// do runs f with the collection of the kind under the store lock
+func (st *store) do(name, kind string, f func(c *collection) error) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	c, ok := st.colls[name]
+	if !ok {
+		return fmt.Errorf("memory: collection %s doesn't exist", name)
+	}
+	if c.Kind != kind {
+		return fmt.Errorf("memory: collection %s keeps %s, not %s", name, c.Kind, kind)
+	}
+
+	return f(c)
+}
+
+// nextId returns the next value of the collection sequence
+func (c *collection) nextId() int64 {
+	id := c.NextId
+	c.NextId++
+	return id
+}
+
+// keyOf returns the key of the value, which is compared instead of the value itself.
+// Values are compared as text, as they would be in the text column
+func keyOf(v interface{}) string {
+	return fmt.Sprint(v)
+}
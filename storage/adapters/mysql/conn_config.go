@@ -0,0 +1,116 @@
+package mysql
+
+import (
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ConnConfig represents a parsed MySQL connection URL
+type ConnConfig struct {
+	User     string
+	Password string
+	Host     string
+	Port     string
+	Database string
+	Options  map[string]string
+}
+
+// poolConfig represents settings of the database/sql connection pool
+type poolConfig struct {
+	maxConns        int
+	maxIdleConns    int
+	maxConnLifetime time.Duration
+	maxConnIdleTime time.Duration
+}
+
+// String reassembles MySQL connection config into a valid connection url
+func (connConf ConnConfig) String() (string, error) {
+	vv := url.Values{}
+	if connConf.Options != nil {
+		for k, v := range connConf.Options {
+			vv.Set(k, v)
+		}
+	}
+
+	if connConf.AdapterName() == "" ||
+		connConf.User == "" ||
+		connConf.Password == "" ||
+		connConf.Host == "" ||
+		connConf.Port == "" ||
+		connConf.Database == "" {
+		return "", fmt.Errorf("invalid connection url")
+	}
+
+	u := url.URL{
+		Scheme:     connConf.AdapterName(),
+		User:       url.UserPassword(connConf.User, connConf.Password),
+		Host:       fmt.Sprintf("%s:%s", connConf.Host, connConf.Port),
+		Path:       connConf.Database,
+		ForceQuery: false,
+		RawQuery:   vv.Encode(),
+	}
+	return u.String(), nil
+}
+
+// DBName returns the name of the database, that we've connected by this config
+func (connConf ConnConfig) DBName() string {
+	return connConf.Database
+}
+
+// AdapterName return the adapter name, that was used to set up connection
+func (connConf ConnConfig) AdapterName() string {
+	return AdapterName
+}
+
+// driverConfig returns config of the mysql driver and the connection pool.
+// Pool options are max_conns, max_idle_conns, max_conn_lifetime and max_conn_idle_time,
+// the rest of options are passed to the driver, e.g. tls or charset
+func (connConf ConnConfig) driverConfig() (*mysql.Config, poolConfig, error) {
+	if _, err := connConf.String(); err != nil {
+		return nil, poolConfig{}, err
+	}
+
+	var (
+		pool   poolConfig
+		params = url.Values{}
+		err    error
+	)
+	for k, v := range connConf.Options {
+		switch k {
+		case "max_conns":
+			pool.maxConns, err = strconv.Atoi(v)
+		case "max_idle_conns":
+			pool.maxIdleConns, err = strconv.Atoi(v)
+		case "max_conn_lifetime":
+			pool.maxConnLifetime, err = time.ParseDuration(v)
+		case "max_conn_idle_time":
+			pool.maxConnIdleTime, err = time.ParseDuration(v)
+		default:
+			params.Set(k, v)
+		}
+		if err != nil {
+			return nil, poolConfig{}, fmt.Errorf("mysql: invalid %s: %v", k, err)
+		}
+	}
+
+	driverConf := mysql.NewConfig()
+	driverConf.User = connConf.User
+	driverConf.Passwd = connConf.Password
+	driverConf.Net = "tcp"
+	driverConf.Addr = fmt.Sprintf("%s:%s", connConf.Host, connConf.Port)
+	driverConf.DBName = connConf.Database
+
+	// parses driver params, so the known ones are set to the config fields
+	if len(params) != 0 {
+		parsed, err := mysql.ParseDSN(driverConf.FormatDSN() + "?" + params.Encode())
+		if err != nil {
+			return nil, poolConfig{}, err
+		}
+		driverConf = parsed
+	}
+
+	return driverConf, pool, nil
+}
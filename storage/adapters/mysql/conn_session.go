@@ -0,0 +1,105 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/go-sql-driver/mysql"
+	"gouth/storage"
+	"sync"
+	"time"
+)
+
+// ConnSession represents a mysql database.
+// It holds a pool of connections, so it's safe for concurrent use
+type ConnSession struct {
+	ctx      context.Context
+	db       *sql.DB
+	connConf storage.ConnConfig
+	// default time limit of one query
+	timeout time.Duration
+	// for abstract queries
+	relInfo map[storage.CollPair]storage.RelInfo
+	// sql of the user queries by collection
+	userQueries *sync.Map
+}
+
+// Open creates connection pool with mysql database
+func (s *ConnSession) Open() error {
+	connConf, ok := s.connConf.(ConnConfig)
+	if !ok {
+		return errors.New("mysql: unexpected connection config type")
+	}
+
+	driverConf, pool, err := connConf.driverConfig()
+	if err != nil {
+		return err
+	}
+
+	connector, err := mysql.NewConnector(driverConf)
+	if err != nil {
+		return err
+	}
+
+	db := sql.OpenDB(connector)
+	db.SetMaxOpenConns(pool.maxConns)
+	if pool.maxIdleConns != 0 {
+		db.SetMaxIdleConns(pool.maxIdleConns)
+	}
+	db.SetConnMaxLifetime(pool.maxConnLifetime)
+	db.SetConnMaxIdleTime(pool.maxConnIdleTime)
+
+	s.db = db
+	if err := s.Ping(); err != nil {
+		db.Close()
+		return err
+	}
+	return nil
+}
+
+// WithContext returns a copy of the session, which runs queries with the given context
+func (s *ConnSession) WithContext(ctx context.Context) storage.ConnSession {
+	sess := *s
+	sess.ctx = ctx
+	return &sess
+}
+
+// SetQueryTimeout sets the default time limit of one query. 0 means no limit
+func (s *ConnSession) SetQueryTimeout(timeout time.Duration) {
+	s.timeout = timeout
+}
+
+// queryCtx returns context of one query limited by the default query timeout
+func (s *ConnSession) queryCtx() (context.Context, context.CancelFunc) {
+	if s.timeout == 0 {
+		return context.WithCancel(s.ctx)
+	}
+	return context.WithTimeout(s.ctx, s.timeout)
+}
+
+// GetConfig returns the connection config that was used to set up the adapter
+func (s *ConnSession) GetConfig() storage.ConnConfig {
+	return s.connConf
+}
+
+// Ping returns an error if the DBMS could not be reached
+func (s *ConnSession) Ping() error {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	var o int
+	err := s.db.QueryRowContext(ctx, "select 1").Scan(&o)
+	if err != nil {
+		return err
+	}
+
+	if o != 1 {
+		return errors.New("got invalid data")
+	}
+	return nil
+}
+
+// Close closes all connections of the pool
+func (s *ConnSession) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,110 @@
+package mysql
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"errors"
+	"fmt"
+	"gouth/storage"
+)
+
+// UserCollMigrations returns migrations of the user collection ordered by version.
+// The first one keeps the collection created before migrations, so it becomes version 1.
+// It can't be reverted, since the collection may hold the users created before migrations
+func (s *ConnSession) UserCollMigrations(collConf storage.UserCollConfig) []storage.Migration {
+	return []storage.Migration{
+		{
+			Version: 1,
+			Name:    "create_user_collection",
+			Up:      []string{fmt.Sprintf("create table if not exists %s %s", Sanitize(collConf.Name), userCollColumns(collConf))},
+		},
+	}
+}
+
+// CollMigrations returns nil, since the adapter keeps the user collection only
+func (s *ConnSession) CollMigrations(string, storage.CollConfig) []storage.Migration {
+	return nil
+}
+
+// LockSchema calls the function on one connection holding the named lock of the scope.
+// MySQL commits schema changes implicitly, so each migration is applied on its own
+// and the failed run stops at the last applied version. Migrations aren't limited by the query timeout
+func (s *ConnSession) LockSchema(versionColl storage.CollConfig, scope string, f func(storage.SchemaTx) error) error {
+	conn, err := s.db.Conn(s.ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// lock names are limited by 64 characters
+	lockName := fmt.Sprintf("gouth:%x", sha1.Sum([]byte(versionColl.Name+":"+scope)))
+
+	var isLocked sql.NullInt64
+	if err := conn.QueryRowContext(s.ctx, "select get_lock(?, -1);", lockName).Scan(&isLocked); err != nil {
+		return err
+	}
+	if isLocked.Int64 != 1 {
+		return errors.New("mysql: can't take the schema lock")
+	}
+	// the context may be done, so the lock is released without it
+	defer conn.ExecContext(context.Background(), "select release_lock(?);", lockName)
+
+	return f(&schemaTx{ctx: s.ctx, conn: conn, versionColl: versionColl, scope: scope})
+}
+
+// schemaTx represents schema changes made on the connection of LockSchema
+type schemaTx struct {
+	ctx         context.Context
+	conn        *sql.Conn
+	versionColl storage.CollConfig
+	scope       string
+}
+
+// SchemaVersion returns the applied version of the scope, 0 if nothing is applied yet
+func (t *schemaTx) SchemaVersion() (int, error) {
+	var count int
+	err := t.conn.QueryRowContext(t.ctx,
+		`select count(*) from information_schema.tables
+        where table_schema = database() and table_name = ?;`,
+		t.versionColl.Name).Scan(&count)
+	if err != nil || count == 0 {
+		return 0, err
+	}
+
+	var version int
+	query := fmt.Sprintf("select version from %s where %s = ?;",
+		Sanitize(t.versionColl.Name),
+		Sanitize(t.versionColl.Pk))
+	err = t.conn.QueryRowContext(t.ctx, query, t.scope).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return version, err
+}
+
+// ApplyMigration runs the statements and saves the new version of the scope
+func (t *schemaTx) ApplyMigration(stmts []string, version int) error {
+	query := fmt.Sprintf(`create table if not exists %s
+                         (%s varchar(255) not null primary key,
+                         version int not null,
+                         applied_at timestamp not null default current_timestamp);`,
+		Sanitize(t.versionColl.Name),
+		Sanitize(t.versionColl.Pk))
+	if _, err := t.conn.ExecContext(t.ctx, query); err != nil {
+		return err
+	}
+
+	for _, stmt := range stmts {
+		if _, err := t.conn.ExecContext(t.ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	query = fmt.Sprintf(`insert into %s (%s, version) values (?, ?)
+                        on duplicate key update version = values(version), applied_at = current_timestamp;`,
+		Sanitize(t.versionColl.Name),
+		Sanitize(t.versionColl.Pk))
+	_, err := t.conn.ExecContext(t.ctx, query, t.scope, version)
+	return err
+}
@@ -0,0 +1,60 @@
+package mysql
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"gouth/storage"
+	"strings"
+	"testing"
+)
+
+func Test_Session_Migrations(t *testing.T) {
+	usersSess := createUsersSess(t)
+	defer usersSess.Close()
+
+	m := usersSess.(storage.Migrator)
+	versionColl := storage.NewCollConfig("schema_migrations", "scope")
+	collConf := storage.NewUserCollConfig("users", "id", "username", "password")
+	migrations := m.UserCollMigrations(*collConf)
+
+	out := &bytes.Buffer{}
+	_, to, err := storage.Migrate(m, *versionColl, collConf.Name, migrations,
+		storage.MigrateOptions{Target: storage.LatestVersion, DryRun: true, Out: out})
+	assert.NoError(t, err)
+	assert.Equal(t, len(migrations), to)
+	assert.True(t, strings.Contains(out.String(), "create table if not exists `users`"))
+
+	from, to, err := storage.Migrate(m, *versionColl, collConf.Name, migrations, storage.MigrateOptions{Target: storage.LatestVersion})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, from)
+	assert.Equal(t, len(migrations), to)
+
+	isExist, err := usersSess.IsCollExists(collConf.ToCollConfig())
+	assert.NoError(t, err)
+	assert.True(t, isExist)
+
+	// the collection may hold the users created before migrations, so it isn't dropped
+	_, _, err = storage.Migrate(m, *versionColl, collConf.Name, migrations, storage.MigrateOptions{Target: 0})
+	assert.Error(t, err)
+
+	isExist, err = usersSess.IsCollExists(collConf.ToCollConfig())
+	assert.NoError(t, err)
+	assert.True(t, isExist)
+}
+
+func Test_Session_Migrations_VersionPk(t *testing.T) {
+	usersSess := createUsersSess(t)
+	defer usersSess.Close()
+
+	m := usersSess.(storage.Migrator)
+	versionColl := storage.NewCollConfig("versions", "coll_name")
+	collConf := storage.NewUserCollConfig("users", "id", "username", "password")
+
+	_, _, err := storage.Migrate(m, *versionColl, collConf.Name, m.UserCollMigrations(*collConf),
+		storage.MigrateOptions{Target: storage.LatestVersion})
+	assert.NoError(t, err)
+
+	version, err := usersSess.RawQuery("select version from versions where coll_name = ?;", "users")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, version)
+}
@@ -0,0 +1,12 @@
+package mysql
+
+import "gouth/storage"
+
+func (s *ConnSession) RelInfo() map[storage.CollPair]storage.RelInfo {
+	return s.relInfo
+}
+
+// Read isn't supported, as relations of the tables aren't discovered
+func (s *ConnSession) Read(string, ...interface{}) (storage.JSONCollResult, error) {
+	return nil, storage.ErrReadUnsupported
+}
@@ -0,0 +1,76 @@
+package mysql
+
+import (
+	"database/sql"
+	"gouth/storage"
+	"strconv"
+	"strings"
+)
+
+// RawExec executes the given sql query with no returning results
+func (s *ConnSession) RawExec(sql string, args ...interface{}) error {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, sql, args...)
+	return err
+}
+
+// RawQuery executes the given sql query and returns the first column of the first row.
+// Queries without args use text protocol, so values are converted by the column type
+func (s *ConnSession) RawQuery(query string, args ...interface{}) (storage.JSONCollResult, error) {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, sql.ErrNoRows
+	}
+
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	var res interface{}
+	if err := rows.Scan(&res); err != nil {
+		return nil, err
+	}
+
+	return convertValue(res, colTypes[0]), nil
+}
+
+// convertValue converts value received as bytes by the database type of the column
+func convertValue(v interface{}, colType *sql.ColumnType) interface{} {
+	b, ok := v.([]byte)
+	if !ok {
+		return v
+	}
+
+	str := string(b)
+	typeName := colType.DatabaseTypeName()
+
+	switch {
+	case strings.HasSuffix(typeName, "INT") && strings.HasPrefix(typeName, "UNSIGNED"):
+		if n, err := strconv.ParseUint(str, 10, 64); err == nil {
+			return n
+		}
+	case strings.HasSuffix(typeName, "INT"):
+		if n, err := strconv.ParseInt(str, 10, 64); err == nil {
+			return n
+		}
+	case typeName == "FLOAT" || typeName == "DOUBLE":
+		if f, err := strconv.ParseFloat(str, 64); err == nil {
+			return f
+		}
+	}
+	return str
+}
@@ -0,0 +1,168 @@
+package mysql
+
+import (
+	"database/sql"
+	"fmt"
+	"gouth/storage"
+	"strings"
+)
+
+// IsCollExists checks whether the given collection exists in the current database
+func (s *ConnSession) IsCollExists(collConf storage.CollConfig) (bool, error) {
+	sql := `select count(*) from information_schema.tables
+           where table_schema = database() and table_name = ?;`
+	res, err := s.RawQuery(sql, collConf.Name)
+	if err != nil {
+		return false, err
+	}
+
+	switch n := res.(type) {
+	case int64:
+		return n != 0, nil
+	case string:
+		return n != "0", nil
+	}
+	return false, fmt.Errorf("mysql: unexpected result %v", res)
+}
+
+// CreateUserColl creates user collection with traits passed by UserCollectionConfig.
+// User unique is varchar, so it can be indexed
+func (s *ConnSession) CreateUserColl(collConf storage.UserCollConfig) error {
+	sql := fmt.Sprintf("create table %s %s;", Sanitize(collConf.Name), userCollColumns(collConf))
+	return s.RawExec(sql)
+}
+
+// userCollColumns returns definition of the user collection columns
+func userCollColumns(collConf storage.UserCollConfig) string {
+	return fmt.Sprintf(`(%s %s,
+                       %s varchar(255) not null unique,
+                       %s text not null)`,
+		Sanitize(collConf.Pk),
+		pkColumnType(collConf.PkType),
+		Sanitize(collConf.UserUnique),
+		Sanitize(collConf.UserConfirm))
+}
+
+// pkColumnType returns definition of the pk column of the given type
+func pkColumnType(pkType string) string {
+	if pkType == storage.PkSerial || pkType == "" || pkType == storage.PkBigintIdentity {
+		return pkDataType(pkType) + " not null auto_increment primary key"
+	}
+	return pkDataType(pkType) + " not null primary key"
+}
+
+// pkDataType returns type of the pk column of the given type
+func pkDataType(pkType string) string {
+	switch pkType {
+	case storage.PkUUIDv4, storage.PkUUIDv7:
+		return "char(36)"
+	case storage.PkULID:
+		return "char(26)"
+	}
+	return "bigint"
+}
+
+// CheckPkType returns an error, if the pk column of the user collection doesn't fit the configured pk type
+func (s *ConnSession) CheckPkType(collConf storage.UserCollConfig) error {
+	sql := `select column_type from information_schema.columns
+           where table_schema = database() and table_name = ? and column_name = ?;`
+	res, err := s.RawQuery(sql, collConf.Name, collConf.Pk)
+	if err != nil {
+		return err
+	}
+
+	// the display width of integers is shown by old versions, e.g. bigint(20)
+	columnType := fmt.Sprintf("%s", res)
+	if !strings.HasPrefix(strings.ToLower(columnType), pkDataType(collConf.PkType)) {
+		return storage.NewPkTypeError(collConf, columnType)
+	}
+	return nil
+}
+
+// InsertUser inserts user entity in the user collection and returns its pk.
+// MySQL has no returning clause, so the pk is selected by the user unique.
+// MySQL's uuid() is version 1, so uuid and ulid are generated by the app
+func (s *ConnSession) InsertUser(collConf storage.UserCollConfig, insUserData storage.InsertUserData) (storage.JSONCollResult, error) {
+	q := s.userQueriesFor(collConf)
+
+	if collConf.IsPkGenerated() {
+		pk, err := storage.NewPk(collConf.PkType)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := s.RawExec(q.insert, insUserData.UserUnique, insUserData.UserConfirm, pk); err != nil {
+			return nil, err
+		}
+		return pk, nil
+	}
+
+	if err := s.RawExec(q.insert, insUserData.UserUnique, insUserData.UserConfirm); err != nil {
+		return nil, err
+	}
+
+	return s.RawQuery(q.getPk, insUserData.UserUnique)
+}
+
+// GetUserPassword returns password hash of the user with the given user unique
+func (s *ConnSession) GetUserPassword(collConf storage.UserCollConfig, userUnique interface{}) (storage.JSONCollResult, error) {
+	pw, err := s.RawQuery(s.userQueriesFor(collConf).getPassword, userUnique)
+	if err == sql.ErrNoRows {
+		return nil, storage.ErrUserNotFound
+	}
+	return pw, err
+}
+
+// UpdateUserPassword replaces password hash of the user with the given user unique
+func (s *ConnSession) UpdateUserPassword(collConf storage.UserCollConfig, userUnique interface{}, pwHash string) error {
+	return s.RawExec(s.userQueriesFor(collConf).updatePassword, pwHash, userUnique)
+}
+
+// userQueries represents sql of the queries to the user collection
+type userQueries struct {
+	insert         string
+	getPk          string
+	getPassword    string
+	updatePassword string
+}
+
+// userQueriesFor returns sql of the queries to the user collection. It's built once per collection
+func (s *ConnSession) userQueriesFor(collConf storage.UserCollConfig) userQueries {
+	if q, ok := s.userQueries.Load(collConf); ok {
+		return q.(userQueries)
+	}
+
+	q := userQueries{
+		insert: fmt.Sprintf("insert into %s (%s, %s) values (?, ?);",
+			Sanitize(collConf.Name),
+			Sanitize(collConf.UserUnique),
+			Sanitize(collConf.UserConfirm)),
+		getPk: fmt.Sprintf("select %s from %s where %s=?;",
+			Sanitize(collConf.Pk),
+			Sanitize(collConf.Name),
+			Sanitize(collConf.UserUnique)),
+		getPassword: fmt.Sprintf("select %s from %s where %s=?;",
+			Sanitize(collConf.UserConfirm),
+			Sanitize(collConf.Name),
+			Sanitize(collConf.UserUnique)),
+		updatePassword: fmt.Sprintf("update %s set %s=? where %s=?;",
+			Sanitize(collConf.Name),
+			Sanitize(collConf.UserConfirm),
+			Sanitize(collConf.UserUnique)),
+	}
+	if collConf.IsPkGenerated() {
+		q.insert = fmt.Sprintf("insert into %s (%s, %s, %s) values (?, ?, ?);",
+			Sanitize(collConf.Name),
+			Sanitize(collConf.UserUnique),
+			Sanitize(collConf.UserConfirm),
+			Sanitize(collConf.Pk))
+	}
+	s.userQueries.Store(collConf, q)
+
+	return q
+}
+
+// Sanitize quotes the identifier with backticks, so it can be safely used in sql
+func Sanitize(ident string) string {
+	return "`" + strings.ReplaceAll(ident, "`", "``") + "`"
+}
@@ -6,12 +6,13 @@ import (
 	"gouth/storage"
 	"net/url"
 	"strings"
+	"sync"
 )
 
 // AdapterName is the internal name of the adapter
 const AdapterName = "postgresql"
 
-var AdapterFeatures = map[string]bool{"users": true, "sessions": true}
+var AdapterFeatures = map[string]bool{"users": true, "sessions": true, "mfa": true, "webauthn": true, "login_attempts": true, "rate_limits": true}
 
 // init initializes package by register adapter
 func init() {
@@ -29,8 +30,10 @@ func (pg pgAdapter) GetFeatures() map[string]bool {
 // OpenConfig attempts to establish a connection with a db by connection config
 func (pg pgAdapter) OpenWithConfig(connConf storage.ConnConfig) (storage.ConnSession, error) {
 	sess := &ConnSession{
-		ctx:      context.Background(),
-		connConf: connConf,
+		ctx:         context.Background(),
+		connConf:    connConf,
+		relInfo:     &relations{},
+		userQueries: &sync.Map{},
 	}
 
 	if err := sess.Open(); err != nil {
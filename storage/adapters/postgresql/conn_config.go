@@ -2,9 +2,20 @@ package postgresql
 
 import (
 	"fmt"
+	"github.com/jackc/pgx/v4/pgxpool"
 	"net/url"
 )
 
+// poolOptions maps connection pool options to the parameters parsed by pgxpool.
+// Durations are set in Go format, e.g. "30m"
+var poolOptions = map[string]string{
+	"max_conns":           "pool_max_conns",
+	"min_conns":           "pool_min_conns",
+	"max_conn_lifetime":   "pool_max_conn_lifetime",
+	"max_conn_idle_time":  "pool_max_conn_idle_time",
+	"health_check_period": "pool_health_check_period",
+}
+
 // ConnConfig represents a parsed PostgreSQL connection URL
 type ConnConfig struct {
 	User     string
@@ -53,3 +64,23 @@ func (connConf ConnConfig) DBName() string {
 func (connConf ConnConfig) AdapterName() string {
 	return AdapterName
 }
+
+// poolConfig returns connection pool config. Pool options are translated to pgxpool parameters,
+// the rest of options are passed to the connection as is, e.g. statement_cache_capacity
+func (connConf ConnConfig) poolConfig() (*pgxpool.Config, error) {
+	opts := make(map[string]string, len(connConf.Options))
+	for k, v := range connConf.Options {
+		if poolKey, ok := poolOptions[k]; ok {
+			k = poolKey
+		}
+		opts[k] = v
+	}
+	connConf.Options = opts
+
+	str, err := connConf.String()
+	if err != nil {
+		return nil, err
+	}
+
+	return pgxpool.ParseConfig(str)
+}
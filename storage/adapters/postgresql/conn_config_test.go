@@ -2,6 +2,7 @@ package postgresql
 
 import (
 	"testing"
+	"time"
 )
 
 func Test_ConnectionConfig_String(t *testing.T) {
@@ -116,3 +117,48 @@ func Test_ConnectionConfig_String(t *testing.T) {
 		})
 	}
 }
+
+func Test_ConnectionConfig_poolConfig(t *testing.T) {
+	connConf := ConnConfig{
+		User:     "admin",
+		Password: "admin",
+		Host:     "localhost",
+		Port:     "5432",
+		Database: "gouth",
+		Options: map[string]string{
+			"max_conns":                "20",
+			"min_conns":                "2",
+			"max_conn_lifetime":        "30m",
+			"max_conn_idle_time":       "5m",
+			"health_check_period":      "15s",
+			"statement_cache_capacity": "128",
+			"search_path":              "public",
+		},
+	}
+
+	config, err := connConf.poolConfig()
+	if err != nil {
+		t.Fatalf("poolConfig() error = %v", err)
+	}
+
+	if config.MaxConns != 20 || config.MinConns != 2 {
+		t.Errorf("poolConfig() conns = %d..%d, want 2..20", config.MinConns, config.MaxConns)
+	}
+	if config.MaxConnLifetime != 30*time.Minute || config.MaxConnIdleTime != 5*time.Minute {
+		t.Errorf("poolConfig() lifetime = %v, idle time = %v", config.MaxConnLifetime, config.MaxConnIdleTime)
+	}
+	if config.HealthCheckPeriod != 15*time.Second {
+		t.Errorf("poolConfig() health check period = %v, want 15s", config.HealthCheckPeriod)
+	}
+	if config.ConnConfig.RuntimeParams["search_path"] != "public" {
+		t.Errorf("poolConfig() runtime params = %v", config.ConnConfig.RuntimeParams)
+	}
+	if _, ok := connConf.Options["pool_max_conns"]; ok {
+		t.Errorf("poolConfig() modified options of the config")
+	}
+
+	connConf.Options = map[string]string{"max_conns": "many"}
+	if _, err := connConf.poolConfig(); err == nil {
+		t.Errorf("poolConfig() expected error for invalid max_conns")
+	}
+}
@@ -3,40 +3,71 @@ package postgresql
 import (
 	"context"
 	"errors"
-	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
 	"gouth/storage"
+	"sync"
+	"time"
 )
 
-// ConnSession represents a postgresql database
+// ConnSession represents a postgresql database.
+// It holds a pool of connections, so it's safe for concurrent use
 type ConnSession struct {
 	ctx      context.Context
-	conn     *pgx.Conn
+	conn     *pgxpool.Pool
 	connConf storage.ConnConfig
-	// for abstract queries
-	relInfo map[storage.CollPair]storage.RelInfo
+	// default time limit of one query
+	timeout time.Duration
+	// for abstract queries, shared by the copies of the session
+	relInfo *relations
+	// sql of the user queries by collection
+	userQueries *sync.Map
 }
 
-// Open creates connection with postgresql database
+// Open creates connection pool with postgresql database
 func (s *ConnSession) Open() error {
-	str, err := s.connConf.String()
-	if err != nil {
-		return err
+	connConf, ok := s.connConf.(ConnConfig)
+	if !ok {
+		return errors.New("postgresql: unexpected connection config type")
 	}
 
-	config, err := pgx.ParseConfig(str)
+	config, err := connConf.poolConfig()
 	if err != nil {
 		return err
 	}
 
-	conn, err := pgx.ConnectConfig(s.ctx, config)
+	conn, err := pgxpool.ConnectConfig(s.ctx, config)
 	if err != nil {
 		return err
 	}
 
 	s.conn = conn
+	if err := s.RefreshRelInfo(); err != nil {
+		conn.Close()
+		return err
+	}
 	return nil
 }
 
+// WithContext returns a copy of the session, which runs queries with the given context
+func (s *ConnSession) WithContext(ctx context.Context) storage.ConnSession {
+	sess := *s
+	sess.ctx = ctx
+	return &sess
+}
+
+// SetQueryTimeout sets the default time limit of one query. 0 means no limit
+func (s *ConnSession) SetQueryTimeout(timeout time.Duration) {
+	s.timeout = timeout
+}
+
+// queryCtx returns context of one query limited by the default query timeout
+func (s *ConnSession) queryCtx() (context.Context, context.CancelFunc) {
+	if s.timeout == 0 {
+		return context.WithCancel(s.ctx)
+	}
+	return context.WithTimeout(s.ctx, s.timeout)
+}
+
 // ConnConfig returns the connection url that was used to set up the adapter
 func (s *ConnSession) GetConfig() storage.ConnConfig {
 	return s.connConf
@@ -44,8 +75,11 @@ func (s *ConnSession) GetConfig() storage.ConnConfig {
 
 // Ping returns an error if the DBMS could not be reached
 func (s *ConnSession) Ping() error {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
 	var o int
-	err := s.conn.QueryRow(context.Background(), "select 1").Scan(&o)
+	err := s.conn.QueryRow(ctx, "select 1").Scan(&o)
 	if err != nil {
 		return err
 	}
@@ -56,7 +90,8 @@ func (s *ConnSession) Ping() error {
 	return nil
 }
 
-// Close terminates the currently active connection to the DBMS
+// Close closes all connections of the pool
 func (s *ConnSession) Close() error {
-	return s.conn.Close(s.ctx)
+	s.conn.Close()
+	return nil
 }
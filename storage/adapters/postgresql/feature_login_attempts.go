@@ -0,0 +1,97 @@
+package postgresql
+
+import (
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"gouth/storage"
+	"time"
+)
+
+// CreateLoginAttemptColl creates collection for the failed login attempts
+func (s *ConnSession) CreateLoginAttemptColl(collConf storage.CollConfig) error {
+	sql := fmt.Sprintf("create table %s %s;", Sanitize(collConf.Name), loginAttemptColumns(collConf))
+	return s.RawExec(sql)
+}
+
+// loginAttemptColumns returns definition of the failed login attempts collection columns
+func loginAttemptColumns(collConf storage.CollConfig) string {
+	return fmt.Sprintf(`(%s text primary key,
+                       failures int not null,
+                       last_failure timestamptz not null,
+                       locked_until timestamptz)`, Sanitize(collConf.Pk))
+}
+
+// GetLoginAttempt returns failed login attempts for the key, or nil if there are none
+func (s *ConnSession) GetLoginAttempt(collConf storage.CollConfig, key string) (*storage.LoginAttempt, error) {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	sql := fmt.Sprintf("select %s, failures, last_failure, locked_until from %s where %s=$1;",
+		Sanitize(collConf.Pk),
+		Sanitize(collConf.Name),
+		Sanitize(collConf.Pk))
+
+	attempt, err := scanLoginAttempt(s.conn.QueryRow(ctx, sql, key))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return attempt, err
+}
+
+// RegisterLoginFailure increments failures counter for the key.
+// The counter starts over, if the last failure is older than the given window
+func (s *ConnSession) RegisterLoginFailure(collConf storage.CollConfig, key string, window time.Duration) (*storage.LoginAttempt, error) {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	sql := fmt.Sprintf(`insert into %[1]s as t (%[2]s, failures, last_failure) values ($1, 1, now())
+                       on conflict (%[2]s) do update set
+                       failures = case when t.last_failure < now() - $2::interval then 1 else t.failures + 1 end,
+                       last_failure = now()
+                       returning %[2]s, failures, last_failure, locked_until;`,
+		Sanitize(collConf.Name),
+		Sanitize(collConf.Pk))
+
+	return scanLoginAttempt(s.conn.QueryRow(ctx, sql, key, window))
+}
+
+// ForgetLoginFailure decrements failures counter for the key
+func (s *ConnSession) ForgetLoginFailure(collConf storage.CollConfig, key string) error {
+	sql := fmt.Sprintf("update %s set failures = failures - 1 where %s=$1 and failures > 0;",
+		Sanitize(collConf.Name),
+		Sanitize(collConf.Pk))
+	return s.RawExec(sql, key)
+}
+
+// LockLogin forbids login attempts for the key until the given time
+func (s *ConnSession) LockLogin(collConf storage.CollConfig, key string, until time.Time) error {
+	sql := fmt.Sprintf("update %s set locked_until=$1 where %s=$2;",
+		Sanitize(collConf.Name),
+		Sanitize(collConf.Pk))
+	return s.RawExec(sql, until, key)
+}
+
+// ResetLoginAttempts removes failed login attempts and lock for the key
+func (s *ConnSession) ResetLoginAttempts(collConf storage.CollConfig, key string) error {
+	sql := fmt.Sprintf("delete from %s where %s=$1;",
+		Sanitize(collConf.Name),
+		Sanitize(collConf.Pk))
+	return s.RawExec(sql, key)
+}
+
+func scanLoginAttempt(row pgx.Row) (*storage.LoginAttempt, error) {
+	var (
+		attempt     storage.LoginAttempt
+		lockedUntil *time.Time
+	)
+
+	err := row.Scan(&attempt.Key, &attempt.Failures, &attempt.LastFailure, &lockedUntil)
+	if err != nil {
+		return nil, err
+	}
+	if lockedUntil != nil {
+		attempt.LockedUntil = *lockedUntil
+	}
+
+	return &attempt, nil
+}
@@ -0,0 +1,54 @@
+package postgresql
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gouth/storage"
+	"testing"
+	"time"
+)
+
+func Test_Session_LoginAttempts(t *testing.T) {
+	usersSess := createUsersSess(t)
+	defer usersSess.Close()
+
+	attemptsSess := usersSess.(storage.LoginAttempts)
+	collConf := *storage.NewCollConfig("login_attempts_test", "key")
+
+	err := attemptsSess.CreateLoginAttemptColl(collConf)
+	assert.NoError(t, err)
+	defer usersSess.RawExec("drop table login_attempts_test;")
+
+	attempt, err := attemptsSess.GetLoginAttempt(collConf, "user:hello")
+	assert.NoError(t, err)
+	assert.Nil(t, attempt)
+
+	attempt, err = attemptsSess.RegisterLoginFailure(collConf, "user:hello", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, attempt.Failures)
+
+	attempt, err = attemptsSess.RegisterLoginFailure(collConf, "user:hello", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempt.Failures)
+
+	err = attemptsSess.ForgetLoginFailure(collConf, "user:hello")
+	assert.NoError(t, err)
+
+	attempt, err = attemptsSess.GetLoginAttempt(collConf, "user:hello")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, attempt.Failures)
+
+	lockedUntil := time.Now().Add(time.Minute)
+	err = attemptsSess.LockLogin(collConf, "user:hello", lockedUntil)
+	assert.NoError(t, err)
+
+	attempt, err = attemptsSess.GetLoginAttempt(collConf, "user:hello")
+	assert.NoError(t, err)
+	assert.WithinDuration(t, lockedUntil, attempt.LockedUntil, time.Millisecond)
+
+	err = attemptsSess.ResetLoginAttempts(collConf, "user:hello")
+	assert.NoError(t, err)
+
+	attempt, err = attemptsSess.GetLoginAttempt(collConf, "user:hello")
+	assert.NoError(t, err)
+	assert.Nil(t, attempt)
+}
@@ -0,0 +1,204 @@
+package postgresql
+
+import (
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"gouth/storage"
+)
+
+// CreateRecoveryCodesColl creates collection for the hashed recovery codes
+func (s *ConnSession) CreateRecoveryCodesColl(collConf storage.CollConfig) error {
+	sql := fmt.Sprintf("create table %s %s;", Sanitize(collConf.Name), recoveryCodeColumns(collConf))
+	if err := s.RawExec(sql); err != nil {
+		return err
+	}
+	return s.RawExec(recoveryCodeLookupColumn(collConf) + ";")
+}
+
+// recoveryCodeColumns returns definition of the recovery codes collection columns
+func recoveryCodeColumns(collConf storage.CollConfig) string {
+	return fmt.Sprintf(`(%s serial primary key,
+                       user_unique text not null,
+                       hash text not null,
+                       used boolean not null default false)`, Sanitize(collConf.Pk))
+}
+
+// recoveryCodeLookupColumn returns the statement adding the lookup of the codes.
+// Codes issued before it have the empty lookup and are compared with every passed code
+func recoveryCodeLookupColumn(collConf storage.CollConfig) string {
+	return fmt.Sprintf("alter table %s add column if not exists lookup text not null default ''", Sanitize(collConf.Name))
+}
+
+// SetRecoveryCodes replaces all recovery codes of the user with the given ones, their lookups and hashes are stored
+func (s *ConnSession) SetRecoveryCodes(collConf storage.CollConfig, userUnique interface{}, codes []storage.RecoveryCode) error {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	sql := fmt.Sprintf("delete from %s where user_unique=$1;", Sanitize(collConf.Name))
+	if _, err = tx.Exec(ctx, sql, userUnique); err != nil {
+		return err
+	}
+
+	sql = fmt.Sprintf("insert into %s (user_unique, lookup, hash) values ($1, $2, $3);", Sanitize(collConf.Name))
+	for _, code := range codes {
+		if _, err = tx.Exec(ctx, sql, userUnique, code.Lookup, code.Hash); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetRecoveryCodes returns all recovery codes of the user, including used ones
+func (s *ConnSession) GetRecoveryCodes(collConf storage.CollConfig, userUnique interface{}) ([]storage.RecoveryCode, error) {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	sql := fmt.Sprintf("select %s, lookup, hash, used from %s where user_unique=$1;",
+		Sanitize(collConf.Pk),
+		Sanitize(collConf.Name))
+	rows, err := s.conn.Query(ctx, sql, userUnique)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var codes []storage.RecoveryCode
+	for rows.Next() {
+		var code storage.RecoveryCode
+		if err := rows.Scan(&code.Id, &code.Lookup, &code.Hash, &code.Used); err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+
+	return codes, rows.Err()
+}
+
+// UseRecoveryCode marks recovery code with the given id as used.
+// It returns false if the code has already been used
+func (s *ConnSession) UseRecoveryCode(collConf storage.CollConfig, id interface{}) (bool, error) {
+	sql := fmt.Sprintf("update %s set used=true where %s=$1 and not used returning true;",
+		Sanitize(collConf.Name),
+		Sanitize(collConf.Pk))
+	_, err := s.RawQuery(sql, id)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// CreateTrustedDeviceColl creates collection for the trusted devices
+func (s *ConnSession) CreateTrustedDeviceColl(collConf storage.CollConfig) error {
+	sql := fmt.Sprintf("create table %s %s;", Sanitize(collConf.Name), trustedDeviceColumns(collConf))
+	return s.RawExec(sql)
+}
+
+// trustedDeviceColumns returns definition of the trusted devices collection columns
+func trustedDeviceColumns(collConf storage.CollConfig) string {
+	return fmt.Sprintf(`(%s text primary key,
+                       user_unique text not null,
+                       name text not null,
+                       created_at timestamptz not null,
+                       expires_at timestamptz not null)`, Sanitize(collConf.Pk))
+}
+
+// InsertTrustedDevice inserts trusted device in the device collection
+func (s *ConnSession) InsertTrustedDevice(collConf storage.CollConfig, device storage.TrustedDevice) error {
+	sql := fmt.Sprintf(`insert into %s (%s, user_unique, name, created_at, expires_at)
+                       values ($1, $2, $3, $4, $5);`,
+		Sanitize(collConf.Name),
+		Sanitize(collConf.Pk))
+	err := s.RawExec(sql, device.Id, device.UserUnique, device.Name, device.CreatedAt, device.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	sql = fmt.Sprintf("delete from %s where expires_at < now();", Sanitize(collConf.Name))
+	return s.RawExec(sql)
+}
+
+// GetTrustedDevice returns unexpired trusted device by its id, or nil if it doesn't exist
+func (s *ConnSession) GetTrustedDevice(collConf storage.CollConfig, id string) (*storage.TrustedDevice, error) {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	sql := fmt.Sprintf(`select %s, user_unique, name, created_at, expires_at from %s
+                       where %s=$1 and expires_at > now();`,
+		Sanitize(collConf.Pk),
+		Sanitize(collConf.Name),
+		Sanitize(collConf.Pk))
+
+	device, err := scanTrustedDevice(s.conn.QueryRow(ctx, sql, id))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return device, err
+}
+
+// GetTrustedDevices returns all unexpired trusted devices of the user
+func (s *ConnSession) GetTrustedDevices(collConf storage.CollConfig, userUnique interface{}) ([]storage.TrustedDevice, error) {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	sql := fmt.Sprintf(`select %s, user_unique, name, created_at, expires_at from %s
+                       where user_unique=$1 and expires_at > now() order by created_at;`,
+		Sanitize(collConf.Pk),
+		Sanitize(collConf.Name))
+	rows, err := s.conn.Query(ctx, sql, userUnique)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var devices []storage.TrustedDevice
+	for rows.Next() {
+		device, err := scanTrustedDevice(rows)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, *device)
+	}
+
+	return devices, rows.Err()
+}
+
+// DeleteTrustedDevice revokes trusted device of the user.
+// It returns false if there is no such device
+func (s *ConnSession) DeleteTrustedDevice(collConf storage.CollConfig, userUnique interface{}, id string) (bool, error) {
+	sql := fmt.Sprintf("delete from %s where %s=$1 and user_unique=$2 returning true;",
+		Sanitize(collConf.Name),
+		Sanitize(collConf.Pk))
+	_, err := s.RawQuery(sql, id, userUnique)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func scanTrustedDevice(row pgx.Row) (*storage.TrustedDevice, error) {
+	var (
+		device   storage.TrustedDevice
+		userUniq string
+	)
+
+	err := row.Scan(&device.Id, &userUniq, &device.Name, &device.CreatedAt, &device.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	device.UserUnique = userUniq
+
+	return &device, nil
+}
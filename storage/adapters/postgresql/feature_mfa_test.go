@@ -0,0 +1,90 @@
+package postgresql
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gouth/storage"
+	"testing"
+	"time"
+)
+
+func Test_Session_RecoveryCodes(t *testing.T) {
+	usersSess := createUsersSess(t)
+	defer usersSess.Close()
+
+	mfaSess := usersSess.(storage.MFA)
+	collConf := *storage.NewCollConfig("recovery_codes_test", "id")
+
+	err := mfaSess.CreateRecoveryCodesColl(collConf)
+	assert.NoError(t, err)
+	defer usersSess.RawExec("drop table recovery_codes_test;")
+
+	err = mfaSess.SetRecoveryCodes(collConf, "hello", []storage.RecoveryCode{
+		{Lookup: "lookup1", Hash: "hash1"},
+		{Lookup: "lookup2", Hash: "hash2"},
+	})
+	assert.NoError(t, err)
+
+	codes, err := mfaSess.GetRecoveryCodes(collConf, "hello")
+	assert.NoError(t, err)
+	if assert.Len(t, codes, 2) {
+		assert.ElementsMatch(t, []string{"lookup1", "lookup2"}, []string{codes[0].Lookup, codes[1].Lookup})
+	}
+
+	isUsed, err := mfaSess.UseRecoveryCode(collConf, codes[0].Id)
+	assert.NoError(t, err)
+	assert.True(t, isUsed)
+
+	isUsed, err = mfaSess.UseRecoveryCode(collConf, codes[0].Id)
+	assert.NoError(t, err)
+	assert.False(t, isUsed)
+
+	err = mfaSess.SetRecoveryCodes(collConf, "hello", []storage.RecoveryCode{{Lookup: "lookup3", Hash: "hash3"}})
+	assert.NoError(t, err)
+
+	codes, err = mfaSess.GetRecoveryCodes(collConf, "hello")
+	assert.NoError(t, err)
+	assert.Len(t, codes, 1)
+	assert.False(t, codes[0].Used)
+}
+
+func Test_Session_TrustedDevices(t *testing.T) {
+	usersSess := createUsersSess(t)
+	defer usersSess.Close()
+
+	mfaSess := usersSess.(storage.MFA)
+	collConf := *storage.NewCollConfig("trusted_devices_test", "id")
+
+	err := mfaSess.CreateTrustedDeviceColl(collConf)
+	assert.NoError(t, err)
+	defer usersSess.RawExec("drop table trusted_devices_test;")
+
+	now := time.Now()
+	err = mfaSess.InsertTrustedDevice(collConf, storage.TrustedDevice{
+		Id:         "device-1",
+		UserUnique: "hello",
+		Name:       "Firefox",
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(time.Hour),
+	})
+	assert.NoError(t, err)
+
+	device, err := mfaSess.GetTrustedDevice(collConf, "device-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", device.UserUnique)
+
+	devices, err := mfaSess.GetTrustedDevices(collConf, "hello")
+	assert.NoError(t, err)
+	assert.Len(t, devices, 1)
+
+	isDeleted, err := mfaSess.DeleteTrustedDevice(collConf, "other", "device-1")
+	assert.NoError(t, err)
+	assert.False(t, isDeleted)
+
+	isDeleted, err = mfaSess.DeleteTrustedDevice(collConf, "hello", "device-1")
+	assert.NoError(t, err)
+	assert.True(t, isDeleted)
+
+	device, err = mfaSess.GetTrustedDevice(collConf, "device-1")
+	assert.NoError(t, err)
+	assert.Nil(t, device)
+}
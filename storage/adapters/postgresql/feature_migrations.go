@@ -0,0 +1,145 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"gouth/storage"
+)
+
+// UserCollMigrations returns migrations of the user collection ordered by version.
+// The first one keeps the collection created before migrations, so it becomes version 1.
+// It can't be reverted, since the collection may hold the users created before migrations
+func (s *ConnSession) UserCollMigrations(collConf storage.UserCollConfig) []storage.Migration {
+	return []storage.Migration{
+		{
+			Version: 1,
+			Name:    "create_user_collection",
+			Up:      []string{fmt.Sprintf("create table if not exists %s %s", Sanitize(collConf.Name), userCollColumns(collConf))},
+		},
+	}
+}
+
+// CollMigrations returns migrations of the collection of the given kind ordered by version.
+// As with the user collection, the first one keeps the collection created before migrations and can't be reverted
+func (s *ConnSession) CollMigrations(kind string, collConf storage.CollConfig) []storage.Migration {
+	var columns string
+	switch kind {
+	case storage.RecoveryCodesColl:
+		columns = recoveryCodeColumns(collConf)
+	case storage.TrustedDevicesColl:
+		columns = trustedDeviceColumns(collConf)
+	case storage.LoginAttemptsColl:
+		columns = loginAttemptColumns(collConf)
+	case storage.RateLimitsColl:
+		columns = rateLimitColumns(collConf)
+	case storage.WebAuthnCredsColl:
+		columns = webauthnCredColumns(collConf)
+	case storage.WebAuthnChallengesColl:
+		columns = webauthnChallengeColumns(collConf)
+	default:
+		return nil
+	}
+
+	migrations := []storage.Migration{
+		{
+			Version: 1,
+			Name:    "create_" + kind + "_collection",
+			Up:      []string{fmt.Sprintf("create table if not exists %s %s", Sanitize(collConf.Name), columns)},
+		},
+	}
+
+	if kind == storage.RecoveryCodesColl {
+		migrations = append(migrations, storage.Migration{
+			Version: 2,
+			Name:    "add_recovery_codes_lookup",
+			Up:      []string{recoveryCodeLookupColumn(collConf)},
+			Down:    []string{fmt.Sprintf("alter table %s drop column lookup", Sanitize(collConf.Name))},
+		})
+	}
+
+	if kind == storage.RateLimitsColl {
+		migrations = append(migrations, storage.Migration{
+			Version: 2,
+			Name:    "add_rate_limits_expiry",
+			Up:      []string{rateLimitExpiryColumn(collConf)},
+			Down:    []string{fmt.Sprintf("alter table %s drop column expires_at", Sanitize(collConf.Name))},
+		})
+	}
+
+	return migrations
+}
+
+// LockSchema calls the function in one transaction holding the advisory lock of the scope.
+// Postgresql changes the schema in transactions, so all migrations of the run are rolled back on error.
+// Migrations aren't limited by the query timeout
+func (s *ConnSession) LockSchema(versionColl storage.CollConfig, scope string, f func(storage.SchemaTx) error) error {
+	tx, err := s.conn.Begin(s.ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(s.ctx)
+
+	if _, err := tx.Exec(s.ctx, "select pg_advisory_xact_lock(hashtext($1));", versionColl.Name+":"+scope); err != nil {
+		return err
+	}
+
+	if err := f(&schemaTx{ctx: s.ctx, tx: tx, versionColl: versionColl, scope: scope}); err != nil {
+		return err
+	}
+	return tx.Commit(s.ctx)
+}
+
+// schemaTx represents schema changes made in the transaction of LockSchema
+type schemaTx struct {
+	ctx         context.Context
+	tx          pgx.Tx
+	versionColl storage.CollConfig
+	scope       string
+}
+
+// SchemaVersion returns the applied version of the scope, 0 if nothing is applied yet
+func (t *schemaTx) SchemaVersion() (int, error) {
+	var isExists bool
+	err := t.tx.QueryRow(t.ctx, "select to_regclass($1) is not null;", Sanitize(t.versionColl.Name)).Scan(&isExists)
+	if err != nil || !isExists {
+		return 0, err
+	}
+
+	var version int
+	sql := fmt.Sprintf("select version from %s where %s = $1;",
+		Sanitize(t.versionColl.Name),
+		Sanitize(t.versionColl.Pk))
+	err = t.tx.QueryRow(t.ctx, sql, t.scope).Scan(&version)
+	if err == pgx.ErrNoRows {
+		return 0, nil
+	}
+	return version, err
+}
+
+// ApplyMigration runs the statements and saves the new version of the scope
+func (t *schemaTx) ApplyMigration(stmts []string, version int) error {
+	sql := fmt.Sprintf(`create table if not exists %s
+                       (%s text primary key,
+                       version integer not null,
+                       applied_at timestamptz not null default now());`,
+		Sanitize(t.versionColl.Name),
+		Sanitize(t.versionColl.Pk))
+	if _, err := t.tx.Exec(t.ctx, sql); err != nil {
+		return err
+	}
+
+	for _, stmt := range stmts {
+		if _, err := t.tx.Exec(t.ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	sql = fmt.Sprintf(`insert into %s (%s, version) values ($1, $2)
+                      on conflict (%s) do update set version = excluded.version, applied_at = now();`,
+		Sanitize(t.versionColl.Name),
+		Sanitize(t.versionColl.Pk),
+		Sanitize(t.versionColl.Pk))
+	_, err := t.tx.Exec(t.ctx, sql, t.scope, version)
+	return err
+}
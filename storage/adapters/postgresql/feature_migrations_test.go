@@ -0,0 +1,65 @@
+package postgresql
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gouth/storage"
+	"sync"
+	"testing"
+)
+
+func Test_Session_Migrations(t *testing.T) {
+	usersSess := createUsersSess(t)
+	defer usersSess.Close()
+
+	m := usersSess.(storage.Migrator)
+	versionColl := storage.NewCollConfig("schema_migrations_test", "scope")
+	collConf := storage.NewUserCollConfig("migrated_users", "id", "username", "password")
+	migrations := m.UserCollMigrations(*collConf)
+	defer usersSess.RawExec("drop table if exists schema_migrations_test, migrated_users;")
+
+	// concurrent instances apply migrations once
+	var wg sync.WaitGroup
+	applied := make([]int, 4)
+	for i := range applied {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			from, to, err := storage.Migrate(m, *versionColl, collConf.Name, migrations, storage.MigrateOptions{Target: storage.LatestVersion})
+			assert.NoError(t, err)
+			applied[i] = to - from
+		}(i)
+	}
+	wg.Wait()
+
+	total := 0
+	for _, n := range applied {
+		total += n
+	}
+	assert.Equal(t, len(migrations), total)
+
+	// the collection may hold the users created before migrations, so it isn't dropped
+	_, _, err := storage.Migrate(m, *versionColl, collConf.Name, migrations, storage.MigrateOptions{Target: 0})
+	assert.Error(t, err)
+
+	isExist, err := usersSess.IsCollExists(collConf.ToCollConfig())
+	assert.NoError(t, err)
+	assert.True(t, isExist)
+}
+
+func Test_Session_Migrations_VersionPk(t *testing.T) {
+	usersSess := createUsersSess(t)
+	defer usersSess.Close()
+
+	m := usersSess.(storage.Migrator)
+	versionColl := storage.NewCollConfig("versions_test", "coll_name")
+	collConf := storage.NewUserCollConfig("versioned_users", "id", "username", "password")
+	defer usersSess.RawExec("drop table if exists versions_test, versioned_users;")
+
+	_, _, err := storage.Migrate(m, *versionColl, collConf.Name, m.UserCollMigrations(*collConf),
+		storage.MigrateOptions{Target: storage.LatestVersion})
+	assert.NoError(t, err)
+
+	version, err := usersSess.RawQuery("select version from versions_test where coll_name = $1;", "versioned_users")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, version)
+}
@@ -1,11 +1,258 @@
 package postgresql
 
-import "gouth/storage"
+import (
+	"fmt"
+	"gouth/storage"
+	"log"
+	"strings"
+	"sync"
+)
 
+// relations keeps the relations discovered from the foreign keys
+type relations struct {
+	mu   sync.RWMutex
+	info map[storage.CollPair]storage.RelInfo
+}
+
+func (r *relations) get() map[storage.CollPair]storage.RelInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.info
+}
+
+func (r *relations) set(info map[storage.CollPair]storage.RelInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.info = info
+}
+
+// foreignKey represents foreign key from the fields of the child table to the fields of the parent one
+type foreignKey struct {
+	name                      string
+	child, parent             string
+	childFields, parentFields []string
+}
+
+// RelInfo returns relations between the tables visible in the search path
 func (s *ConnSession) RelInfo() map[storage.CollPair]storage.RelInfo {
-	return s.relInfo
+	return s.relInfo.get()
+}
+
+// RefreshRelInfo discovers relations between the tables from their foreign keys
+func (s *ConnSession) RefreshRelInfo() error {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	rows, err := s.conn.Query(ctx, `
+		select c.conname, child.relname, parent.relname,
+			array(select a.attname::text from unnest(c.conkey) with ordinality k(num, ord)
+				join pg_attribute a on a.attrelid = c.conrelid and a.attnum = k.num order by k.ord),
+			array(select a.attname::text from unnest(c.confkey) with ordinality k(num, ord)
+				join pg_attribute a on a.attrelid = c.confrelid and a.attnum = k.num order by k.ord)
+		from pg_constraint c
+			join pg_class child on child.oid = c.conrelid
+			join pg_class parent on parent.oid = c.confrelid
+		where c.contype = 'f' and pg_table_is_visible(c.conrelid) and pg_table_is_visible(c.confrelid)
+		order by c.conname;`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var fks []foreignKey
+	for rows.Next() {
+		var fk foreignKey
+		if err := rows.Scan(&fk.name, &fk.child, &fk.parent, &fk.childFields, &fk.parentFields); err != nil {
+			return err
+		}
+		fks = append(fks, fk)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	info, skipped := relInfoFromForeignKeys(fks)
+	for _, fk := range skipped {
+		log.Printf("postgresql: relation of foreign key %s is skipped", fk)
+	}
+
+	s.relInfo.set(info)
+	return nil
+}
+
+// relInfoFromForeignKeys returns relations in both directions of the foreign keys:
+// the child table has many-to-one relation to the parent and the parent has one-to-many relation to the child.
+// Self-references and tables with several foreign keys between them are ambiguous,
+// so they are skipped and returned as descriptions for the log
+func relInfoFromForeignKeys(fks []foreignKey) (map[storage.CollPair]storage.RelInfo, []string) {
+	var (
+		pairs   []storage.CollPair
+		skipped []string
+	)
+	byPair := make(map[storage.CollPair][]foreignKey)
+
+	for _, fk := range fks {
+		if fk.child == fk.parent {
+			skipped = append(skipped, fk.describe("self-reference"))
+			continue
+		}
+
+		// foreign keys in both directions are ambiguous as well
+		pair := storage.CollPair{From: fk.child, To: fk.parent}
+		if pair.From > pair.To {
+			pair.From, pair.To = pair.To, pair.From
+		}
+		if _, ok := byPair[pair]; !ok {
+			pairs = append(pairs, pair)
+		}
+		byPair[pair] = append(byPair[pair], fk)
+	}
+
+	info := make(map[storage.CollPair]storage.RelInfo)
+	for _, pair := range pairs {
+		pairFks := byPair[pair]
+		if len(pairFks) != 1 {
+			for _, fk := range pairFks {
+				skipped = append(skipped, fk.describe("ambiguous"))
+			}
+			continue
+		}
+
+		fk := pairFks[0]
+		m2o := storage.CollPair{From: fk.child, To: fk.parent}
+		o2m := storage.CollPair{From: fk.parent, To: fk.child}
+		info[m2o] = storage.RelInfo{IsO2M: false, FromFields: fk.childFields, ToFields: fk.parentFields}
+		info[o2m] = storage.RelInfo{IsO2M: true, FromFields: fk.parentFields, ToFields: fk.childFields}
+	}
+
+	return info, skipped
+}
+
+// describe returns the foreign key with the reason, why its relation is skipped
+func (fk foreignKey) describe(reason string) string {
+	return fmt.Sprintf("%s from %s to %s (%s)", fk.name, fk.child, fk.parent, reason)
+}
+
+// Read executes the abstract query and returns the rows of the collection as decoded json array.
+// Nested collections are selected by correlated subqueries along the known relations
+func (s *ConnSession) Read(query string, args ...interface{}) (storage.JSONCollResult, error) {
+	q, err := storage.ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	sql, args, err := readSQL(q, s.relInfo.get(), args)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.RawQuery(sql, args...)
+}
+
+// readBuilder builds sql of the abstract query. Literal values are passed as arguments,
+// so they follow the arguments of the query
+type readBuilder struct {
+	relInfo  map[storage.CollPair]storage.RelInfo
+	args     []interface{}
+	nParams  int
+	nAliases int
+}
+
+// readSQL returns sql of the abstract query and its arguments
+func readSQL(q *storage.Query, relInfo map[storage.CollPair]storage.RelInfo, args []interface{}) (string, []interface{}, error) {
+	b := &readBuilder{
+		relInfo: relInfo,
+		args:    append([]interface{}{}, args...),
+		nParams: len(args),
+	}
+
+	rows, err := b.rows(q, "", nil)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return fmt.Sprintf("select coalesce(json_agg(t), '[]'::json) from (%s) t;", rows), b.args, nil
+}
+
+// rows returns select of the collection fields and nested collections.
+// Join conditions bind rows to the row of the parent collection
+func (b *readBuilder) rows(q *storage.Query, parentAlias string, rel *storage.RelInfo) (string, error) {
+	alias := b.alias()
+
+	// conditions go first, so the arguments are numbered in order of the query
+	var conds []string
+	if rel != nil {
+		for i := range rel.FromFields {
+			conds = append(conds, fmt.Sprintf("%s.%s = %s.%s",
+				alias, Sanitize(rel.ToFields[i]), parentAlias, Sanitize(rel.FromFields[i])))
+		}
+	}
+
+	for _, f := range q.Filters {
+		cond, err := b.cond(alias, f)
+		if err != nil {
+			return "", err
+		}
+		conds = append(conds, cond)
+	}
+
+	var cols []string
+	for _, field := range q.Fields {
+		cols = append(cols, fmt.Sprintf("%s.%s", alias, Sanitize(field)))
+	}
+
+	for _, relQ := range q.Rels {
+		relInfo, ok := b.relInfo[storage.CollPair{From: q.Coll, To: relQ.Coll}]
+		if !ok {
+			return "", fmt.Errorf("postgresql: unknown relation from %s to %s", q.Coll, relQ.Coll)
+		}
+
+		rows, err := b.rows(relQ, alias, &relInfo)
+		if err != nil {
+			return "", err
+		}
+
+		var col string
+		if relInfo.IsO2M {
+			col = fmt.Sprintf("(select coalesce(json_agg(t), '[]'::json) from (%s) t)", rows)
+		} else {
+			col = fmt.Sprintf("(select row_to_json(t) from (%s limit 1) t)", rows)
+		}
+		cols = append(cols, fmt.Sprintf("%s as %s", col, Sanitize(relQ.Coll)))
+	}
+
+	sql := fmt.Sprintf("select %s from %s as %s", strings.Join(cols, ", "), Sanitize(q.Coll), alias)
+	if len(conds) != 0 {
+		sql += " where " + strings.Join(conds, " and ")
+	}
+	return sql, nil
+}
+
+// cond returns sql of the filter
+func (b *readBuilder) cond(alias string, f storage.Filter) (string, error) {
+	field := fmt.Sprintf("%s.%s", alias, Sanitize(f.Field))
+
+	switch value := f.Value.(type) {
+	case nil:
+		if f.Op == "=" {
+			return field + " is null", nil
+		}
+		return field + " is not null", nil
+	case storage.Param:
+		if int(value) > b.nParams {
+			return "", fmt.Errorf("postgresql: missing argument $%d", value)
+		}
+		return fmt.Sprintf("%s %s $%d", field, f.Op, value), nil
+	default:
+		b.args = append(b.args, value)
+		return fmt.Sprintf("%s %s $%d", field, f.Op, len(b.args)), nil
+	}
 }
 
-func (s *ConnSession) Read(string) (storage.JSONCollResult, error) {
-	panic("implement me")
+// alias returns the next unique alias of the collection
+func (b *readBuilder) alias() string {
+	b.nAliases++
+	return fmt.Sprintf("c%d", b.nAliases)
 }
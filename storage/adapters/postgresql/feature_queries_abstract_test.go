@@ -0,0 +1,104 @@
+package postgresql
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gouth/storage"
+	"testing"
+)
+
+var testRelInfo = map[storage.CollPair]storage.RelInfo{
+	{From: "read_users", To: "read_orgs"}:  {IsO2M: false, FromFields: []string{"org_id"}, ToFields: []string{"id"}},
+	{From: "read_users", To: "read_posts"}: {IsO2M: true, FromFields: []string{"id"}, ToFields: []string{"user_id"}},
+}
+
+func Test_readSQL(t *testing.T) {
+	q, err := storage.ParseQuery("read_users(id = $1, name != 'root') { name, read_orgs { name }, read_posts(published = true) { id } }")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sql, args, err := readSQL(q, testRelInfo, []interface{}{1})
+	assert.NoError(t, err)
+	assert.Equal(t, `select coalesce(json_agg(t), '[]'::json) from (`+
+		`select c1."name", `+
+		`(select row_to_json(t) from (select c2."name" from "read_orgs" as c2 where c2."id" = c1."org_id" limit 1) t) as "read_orgs", `+
+		`(select coalesce(json_agg(t), '[]'::json) from (select c3."id" from "read_posts" as c3 where c3."user_id" = c1."id" and c3."published" = $3) t) as "read_posts" `+
+		`from "read_users" as c1 where c1."id" = $1 and c1."name" != $2) t;`, sql)
+	assert.Equal(t, []interface{}{1, "root", true}, args)
+
+	q, _ = storage.ParseQuery("read_users(id = $2) { name }")
+	_, _, err = readSQL(q, testRelInfo, []interface{}{1})
+	assert.Error(t, err)
+
+	q, _ = storage.ParseQuery("read_posts { id, read_users { name } }")
+	_, _, err = readSQL(q, testRelInfo, nil)
+	assert.Error(t, err)
+}
+
+func Test_Session_Read(t *testing.T) {
+	usersSess := createUsersSess(t)
+	defer usersSess.Close()
+
+	assert.NoError(t, usersSess.RawExec(`
+		create table read_orgs (id serial primary key, name text);
+		create table read_users (id serial primary key, name text, org_id int references read_orgs);
+		create table read_posts (id serial primary key, user_id int references read_users, published bool);
+		insert into read_orgs (name) values ('acme');
+		insert into read_users (name, org_id) values ('john', 1), ('jane', null);
+		insert into read_posts (user_id, published) values (1, true), (1, false), (1, true);`))
+	defer usersSess.RawExec("drop table read_posts, read_users, read_orgs;")
+
+	assert.NoError(t, usersSess.(storage.RelInfoRefresher).RefreshRelInfo())
+	for pair, rel := range testRelInfo {
+		assert.Equal(t, rel, usersSess.RelInfo()[pair])
+	}
+
+	res, err := usersSess.Read("read_users(name = $1) { name, read_orgs { name }, read_posts(published = true) { id } }", "john")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"name":       "john",
+			"read_orgs":  map[string]interface{}{"name": "acme"},
+			"read_posts": []interface{}{map[string]interface{}{"id": float64(1)}, map[string]interface{}{"id": float64(3)}},
+		},
+	}, res)
+
+	res, err = usersSess.Read("read_users(name = 'jane') { read_orgs { name }, read_posts { id } }")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"read_orgs": nil, "read_posts": []interface{}{}},
+	}, res)
+
+	res, err = usersSess.Read("read_users(name = 'nobody') { name }")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{}, res)
+}
+
+func Test_relInfoFromForeignKeys(t *testing.T) {
+	fks := []foreignKey{
+		{name: "posts_user_id_fkey", child: "posts", parent: "users", childFields: []string{"user_id"}, parentFields: []string{"id"}},
+		{name: "users_org_id_fkey", child: "users", parent: "orgs", childFields: []string{"org_id"}, parentFields: []string{"id"}},
+		{name: "users_invited_by_fkey", child: "users", parent: "users", childFields: []string{"invited_by"}, parentFields: []string{"id"}},
+		{name: "messages_sender_id_fkey", child: "messages", parent: "users", childFields: []string{"sender_id"}, parentFields: []string{"id"}},
+		{name: "messages_recipient_id_fkey", child: "messages", parent: "users", childFields: []string{"recipient_id"}, parentFields: []string{"id"}},
+		{name: "messages_cc_id_fkey", child: "messages", parent: "users", childFields: []string{"cc_id"}, parentFields: []string{"id"}},
+		{name: "teams_owner_id_fkey", child: "teams", parent: "users", childFields: []string{"owner_id"}, parentFields: []string{"id"}},
+		{name: "users_team_id_fkey", child: "users", parent: "teams", childFields: []string{"team_id"}, parentFields: []string{"id"}},
+	}
+
+	info, skipped := relInfoFromForeignKeys(fks)
+	assert.Equal(t, map[storage.CollPair]storage.RelInfo{
+		{From: "posts", To: "users"}: {IsO2M: false, FromFields: []string{"user_id"}, ToFields: []string{"id"}},
+		{From: "users", To: "posts"}: {IsO2M: true, FromFields: []string{"id"}, ToFields: []string{"user_id"}},
+		{From: "users", To: "orgs"}:  {IsO2M: false, FromFields: []string{"org_id"}, ToFields: []string{"id"}},
+		{From: "orgs", To: "users"}:  {IsO2M: true, FromFields: []string{"id"}, ToFields: []string{"org_id"}},
+	}, info)
+	assert.Equal(t, []string{
+		"users_invited_by_fkey from users to users (self-reference)",
+		"messages_sender_id_fkey from messages to users (ambiguous)",
+		"messages_recipient_id_fkey from messages to users (ambiguous)",
+		"messages_cc_id_fkey from messages to users (ambiguous)",
+		"teams_owner_id_fkey from teams to users (ambiguous)",
+		"users_team_id_fkey from users to teams (ambiguous)",
+	}, skipped)
+}
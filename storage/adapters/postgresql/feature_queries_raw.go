@@ -4,15 +4,21 @@ import "gouth/storage"
 
 // Exec executes the given sql query with no returning results
 func (s *ConnSession) RawExec(sql string, args ...interface{}) error {
-	_, err := s.conn.Exec(s.ctx, sql, args...)
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	_, err := s.conn.Exec(ctx, sql, args...)
 	return err
 }
 
 // RawQuery executes the given sql query and returns results
 func (s *ConnSession) RawQuery(sql string, args ...interface{}) (storage.JSONCollResult, error) {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
 	var res interface{}
 
-	err := s.conn.QueryRow(s.ctx, sql, args...).Scan(&res)
+	err := s.conn.QueryRow(ctx, sql, args...).Scan(&res)
 	if err != nil {
 		return nil, err
 	}
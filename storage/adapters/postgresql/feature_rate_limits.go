@@ -0,0 +1,81 @@
+package postgresql
+
+import (
+	"fmt"
+	"gouth/storage"
+	"math/rand"
+)
+
+// rateLimitCleanupEvery is the average number of taken tokens between removals of the expired buckets
+const rateLimitCleanupEvery = 1024
+
+// CreateRateLimitColl creates collection for the token buckets
+func (s *ConnSession) CreateRateLimitColl(collConf storage.CollConfig) error {
+	sql := fmt.Sprintf("create table %s %s;", Sanitize(collConf.Name), rateLimitColumns(collConf))
+	if err := s.RawExec(sql); err != nil {
+		return err
+	}
+	return s.RawExec(rateLimitExpiryColumn(collConf) + ";")
+}
+
+// rateLimitColumns returns definition of the token buckets collection columns
+func rateLimitColumns(collConf storage.CollConfig) string {
+	return fmt.Sprintf(`(%s text primary key,
+                       tokens double precision not null,
+                       allowed boolean not null,
+                       updated_at timestamptz not null)`, Sanitize(collConf.Pk))
+}
+
+// rateLimitExpiryColumn returns statement adding the time, when the bucket is full again.
+// Full bucket is the same as absent one, so it can be removed after that time
+func rateLimitExpiryColumn(collConf storage.CollConfig) string {
+	return fmt.Sprintf("alter table %s add column if not exists expires_at timestamptz not null default now()",
+		Sanitize(collConf.Name))
+}
+
+// TakeRateLimitToken atomically refills the bucket with the given capacity and refill rate
+// (tokens per second) and takes one token from it.
+// It returns the number of tokens left and whether the token was taken.
+// Expired buckets are removed once in a while
+func (s *ConnSession) TakeRateLimitToken(collConf storage.CollConfig, key string, capacity, rate float64) (float64, bool, error) {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	// refilled is the number of tokens in the bucket before taking one
+	refilled := "least($2::float8, t.tokens + extract(epoch from clock_timestamp() - t.updated_at) * $3::float8)"
+	left := fmt.Sprintf("case when %[1]s >= 1 then %[1]s - 1 else %[1]s end", refilled)
+	sql := fmt.Sprintf(`insert into %[1]s as t (%[2]s, tokens, allowed, updated_at, expires_at)
+                       values ($1, $2::float8 - 1, true, clock_timestamp(), clock_timestamp() + make_interval(secs => 1 / $3::float8))
+                       on conflict (%[2]s) do update set
+                       tokens = %[4]s,
+                       allowed = %[3]s >= 1,
+                       updated_at = clock_timestamp(),
+                       expires_at = clock_timestamp() + make_interval(secs => ($2::float8 - %[4]s) / $3::float8)
+                       returning tokens, allowed;`,
+		Sanitize(collConf.Name),
+		Sanitize(collConf.Pk),
+		refilled,
+		left)
+
+	var (
+		tokens  float64
+		allowed bool
+	)
+	if err := s.conn.QueryRow(ctx, sql, key, capacity, rate).Scan(&tokens, &allowed); err != nil {
+		return 0, false, err
+	}
+
+	if rand.Intn(rateLimitCleanupEvery) == 0 {
+		if err := s.deleteExpiredRateLimits(collConf); err != nil {
+			return 0, false, err
+		}
+	}
+
+	return tokens, allowed, nil
+}
+
+// deleteExpiredRateLimits removes the buckets, which are full again
+func (s *ConnSession) deleteExpiredRateLimits(collConf storage.CollConfig) error {
+	sql := fmt.Sprintf("delete from %s where expires_at < now();", Sanitize(collConf.Name))
+	return s.RawExec(sql)
+}
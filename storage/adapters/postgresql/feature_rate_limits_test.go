@@ -0,0 +1,41 @@
+package postgresql
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gouth/storage"
+	"testing"
+)
+
+func Test_Session_RateLimits(t *testing.T) {
+	usersSess := createUsersSess(t)
+	defer usersSess.Close()
+
+	rateLimitsSess := usersSess.(storage.RateLimits)
+	collConf := *storage.NewCollConfig("rate_limits_test", "key")
+
+	err := rateLimitsSess.CreateRateLimitColl(collConf)
+	assert.NoError(t, err)
+	defer usersSess.RawExec("drop table rate_limits_test;")
+
+	tokens, allowed, err := rateLimitsSess.TakeRateLimitToken(collConf, "ip:127.0.0.1", 2, 0.001)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+	assert.InDelta(t, 1, tokens, 0.01)
+
+	_, allowed, err = rateLimitsSess.TakeRateLimitToken(collConf, "ip:127.0.0.1", 2, 0.001)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+
+	_, allowed, err = rateLimitsSess.TakeRateLimitToken(collConf, "ip:127.0.0.1", 2, 0.001)
+	assert.NoError(t, err)
+	assert.False(t, allowed)
+	// the bucket is removed, when it's full again
+	sess := usersSess.(*ConnSession)
+	assert.NoError(t, sess.RawExec("update rate_limits_test set expires_at = now() - interval '1 second';"))
+	assert.NoError(t, sess.deleteExpiredRateLimits(collConf))
+
+	tokens, allowed, err = rateLimitsSess.TakeRateLimitToken(collConf, "ip:127.0.0.1", 2, 0.001)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+	assert.InDelta(t, 1, tokens, 0.01)
+}
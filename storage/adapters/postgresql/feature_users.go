@@ -23,34 +23,159 @@ func (s *ConnSession) IsCollExists(collConf storage.CollConfig) (bool, error) {
 
 // CreateUserCollection creates user collection with traits passed by UserCollectionConfig
 func (s *ConnSession) CreateUserColl(collConf storage.UserCollConfig) error {
+	sql := fmt.Sprintf("create table %s %s;", Sanitize(collConf.Name), userCollColumns(collConf))
+	return s.RawExec(sql)
+}
+
+// userCollColumns returns definition of the user collection columns
+func userCollColumns(collConf storage.UserCollConfig) string {
 	// TODO: check types of fields
-	sql := fmt.Sprintf(`create table %s
-                       (%s serial primary key,
+	return fmt.Sprintf(`(%s %s,
                        %s text not null unique,
-                       %s text not null);`,
-		Sanitize(collConf.Name),
+                       %s text not null)`,
 		Sanitize(collConf.Pk),
+		pkColumnType(collConf.PkType),
 		Sanitize(collConf.UserUnique),
 		Sanitize(collConf.UserConfirm))
-	return s.RawExec(sql)
 }
 
-// InsertUser inserts user entity in the user collection
+// pkColumnType returns definition of the pk column of the given type.
+// UUIDv4 is generated by gen_random_uuid (PostgreSQL 13+), UUIDv7 and ULID are generated by the app
+func pkColumnType(pkType string) string {
+	switch pkType {
+	case storage.PkBigintIdentity:
+		return "bigint generated by default as identity primary key"
+	case storage.PkUUIDv4:
+		return "uuid primary key default gen_random_uuid()"
+	case storage.PkUUIDv7:
+		return "uuid primary key"
+	case storage.PkULID:
+		return "char(26) primary key"
+	}
+	return "serial primary key"
+}
+
+// pkDataType returns data type of the pk column of the given type, as it's shown by information_schema
+func pkDataType(pkType string) string {
+	switch pkType {
+	case storage.PkBigintIdentity:
+		return "bigint"
+	case storage.PkUUIDv4, storage.PkUUIDv7:
+		return "uuid"
+	case storage.PkULID:
+		return "character"
+	}
+	return "integer"
+}
+
+// CheckPkType returns an error, if the pk column of the user collection doesn't fit the configured pk type.
+// Keys of the other types than UUIDv7 and ULID are generated by the database, so the column must have default
+func (s *ConnSession) CheckPkType(collConf storage.UserCollConfig) error {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	sql := `select data_type, column_default is not null or is_identity = 'YES' from information_schema.columns
+           where table_schema = current_schema() and table_name = $1 and column_name = $2;`
+
+	var (
+		dataType   string
+		hasDefault bool
+	)
+	if err := s.conn.QueryRow(ctx, sql, collConf.Name, collConf.Pk).Scan(&dataType, &hasDefault); err != nil {
+		return err
+	}
+
+	if dataType != pkDataType(collConf.PkType) || (!isPkGeneratedByApp(collConf) && !hasDefault) {
+		return storage.NewPkTypeError(collConf, dataType)
+	}
+	return nil
+}
+
+// isPkGeneratedByApp checks whether the pk must be passed to the insert
+func isPkGeneratedByApp(collConf storage.UserCollConfig) bool {
+	return collConf.IsPkGenerated() && collConf.PkType != storage.PkUUIDv4
+}
+
+// InsertUser inserts user entity in the user collection and returns its pk
 func (s *ConnSession) InsertUser(collConf storage.UserCollConfig, insUserData storage.InsertUserData) (storage.JSONCollResult, error) {
-	sql := fmt.Sprintf("insert into %s (%s, %s) values ($1, $2) returning $3;",
-		Sanitize(collConf.Name),
-		Sanitize(collConf.UserUnique),
-		Sanitize(collConf.UserConfirm))
-	return s.RawQuery(sql, insUserData.UserUnique, insUserData.UserConfirm, collConf.Pk)
+	args := []interface{}{insUserData.UserUnique, insUserData.UserConfirm}
+	if isPkGeneratedByApp(collConf) {
+		pk, err := storage.NewPk(collConf.PkType)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, pk)
+	}
+
+	return s.RawQuery(s.userQueriesFor(collConf).insert, args...)
 }
 
+// GetUserPassword returns password hash of the user with the given user unique
 func (s *ConnSession) GetUserPassword(collConf storage.UserCollConfig, userUnique interface{}) (storage.JSONCollResult, error) {
-	sql := fmt.Sprintf("select %s from %s where %s=$1",
-		Sanitize(collConf.UserConfirm),
+	pw, err := s.RawQuery(s.userQueriesFor(collConf).getPassword, userUnique)
+	if err == pgx.ErrNoRows {
+		return nil, storage.ErrUserNotFound
+	}
+	return pw, err
+}
+
+// UpdateUserPassword replaces password hash of the user with the given user unique
+func (s *ConnSession) UpdateUserPassword(collConf storage.UserCollConfig, userUnique interface{}, pwHash string) error {
+	return s.RawExec(s.userQueriesFor(collConf).updatePassword, pwHash, userUnique)
+}
+
+// userQueries represents sql of the queries to the user collection
+type userQueries struct {
+	insert         string
+	getPassword    string
+	updatePassword string
+}
+
+// userQueriesFor returns sql of the queries to the user collection. It's built once per collection,
+// so pgx prepares each query once per connection and then takes it from the statement cache
+func (s *ConnSession) userQueriesFor(collConf storage.UserCollConfig) userQueries {
+	if q, ok := s.userQueries.Load(collConf); ok {
+		return q.(userQueries)
+	}
+
+	q := userQueries{
+		insert: insertUserSQL(collConf),
+		getPassword: fmt.Sprintf("select %s from %s where %s=$1",
+			Sanitize(collConf.UserConfirm),
+			Sanitize(collConf.Name),
+			Sanitize(collConf.UserUnique)),
+		updatePassword: fmt.Sprintf("update %s set %s=$1 where %s=$2;",
+			Sanitize(collConf.Name),
+			Sanitize(collConf.UserConfirm),
+			Sanitize(collConf.UserUnique)),
+	}
+	s.userQueries.Store(collConf, q)
+
+	return q
+}
+
+// insertUserSQL returns sql of the user insert, which returns the pk.
+// Uuid is returned as text, so it's encoded in the response as is
+func insertUserSQL(collConf storage.UserCollConfig) string {
+	returning := Sanitize(collConf.Pk)
+	if collConf.IsPkGenerated() {
+		returning += "::text"
+	}
+
+	if isPkGeneratedByApp(collConf) {
+		return fmt.Sprintf("insert into %s (%s, %s, %s) values ($1, $2, $3) returning %s;",
+			Sanitize(collConf.Name),
+			Sanitize(collConf.UserUnique),
+			Sanitize(collConf.UserConfirm),
+			Sanitize(collConf.Pk),
+			returning)
+	}
+
+	return fmt.Sprintf("insert into %s (%s, %s) values ($1, $2) returning %s;",
 		Sanitize(collConf.Name),
 		Sanitize(collConf.UserUnique),
-	)
-	return s.RawQuery(sql, userUnique)
+		Sanitize(collConf.UserConfirm),
+		returning)
 }
 
 func Sanitize(ident string) string {
@@ -67,3 +67,66 @@ func Test_Session_InsertUser(t *testing.T) {
 	assert.NoError(t, err)
 	fmt.Printf("new id: %v\n", res)
 }
+
+func Test_insertUserSQL(t *testing.T) {
+	collConf := *storage.NewUserCollConfig("users", "id", "username", "password")
+	assert.Equal(t,
+		`insert into "users" ("username", "password") values ($1, $2) returning "id";`,
+		insertUserSQL(collConf))
+
+	collConf.PkType = storage.PkUUIDv4
+	assert.Equal(t,
+		`insert into "users" ("username", "password") values ($1, $2) returning "id"::text;`,
+		insertUserSQL(collConf))
+
+	collConf.PkType = storage.PkULID
+	assert.Equal(t,
+		`insert into "users" ("username", "password", "id") values ($1, $2, $3) returning "id"::text;`,
+		insertUserSQL(collConf))
+}
+
+func Test_Session_InsertUser_PkTypes(t *testing.T) {
+	usersSess := createUsersSess(t)
+	defer usersSess.Close()
+
+	for _, pkType := range []string{storage.PkBigintIdentity, storage.PkUUIDv4, storage.PkUUIDv7, storage.PkULID} {
+		collConf := *storage.NewUserCollConfig("users_"+pkType, "id", "username", "password")
+		collConf.PkType = pkType
+
+		_ = usersSess.RawExec(fmt.Sprintf("drop table if exists %s;", Sanitize(collConf.Name)))
+		assert.NoError(t, usersSess.CreateUserColl(collConf))
+
+		id, err := usersSess.InsertUser(collConf, *storage.NewInsertUserData("hello", "secret"))
+		assert.NoError(t, err)
+
+		pk, err := usersSess.RawQuery(
+			fmt.Sprintf("select %s::text from %s where username = 'hello';", Sanitize("id"), Sanitize(collConf.Name)))
+		assert.NoError(t, err)
+		assert.Equal(t, pk, fmt.Sprint(id), pkType)
+		assert.NoError(t, usersSess.(storage.PkTypeChecker).CheckPkType(collConf), pkType)
+	}
+
+	// the pk of UUIDv7 isn't generated by the database
+	collConf := *storage.NewUserCollConfig("users_"+storage.PkUUIDv7, "id", "username", "password")
+	collConf.PkType = storage.PkUUIDv4
+	assert.Error(t, usersSess.(storage.PkTypeChecker).CheckPkType(collConf))
+
+	collConf.PkType = storage.PkSerial
+	assert.Error(t, usersSess.(storage.PkTypeChecker).CheckPkType(collConf))
+}
+
+func Test_Session_UpdateUserPassword(t *testing.T) {
+	usersSess := createUsersSess(t)
+	defer usersSess.Close()
+
+	collConf := *storage.NewUserCollConfig("users", "id", "username", "password")
+	_, err := usersSess.InsertUser(collConf, *storage.NewInsertUserData("rehash", "old"))
+	assert.NoError(t, err)
+
+	err = usersSess.UpdateUserPassword(collConf, "rehash", "new")
+	assert.NoError(t, err)
+
+	pw, err := usersSess.GetUserPassword(collConf, "rehash")
+	assert.NoError(t, err)
+	assert.Equal(t, "new", pw)
+}
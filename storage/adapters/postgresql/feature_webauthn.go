@@ -0,0 +1,158 @@
+package postgresql
+
+import (
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"gouth/storage"
+	"time"
+)
+
+// CreateWebAuthnCredColl creates collection for the public key credentials
+func (s *ConnSession) CreateWebAuthnCredColl(collConf storage.CollConfig) error {
+	sql := fmt.Sprintf("create table %s %s;", Sanitize(collConf.Name), webauthnCredColumns(collConf))
+	return s.RawExec(sql)
+}
+
+// webauthnCredColumns returns definition of the public key credentials collection columns
+func webauthnCredColumns(collConf storage.CollConfig) string {
+	return fmt.Sprintf(`(%s bytea primary key,
+                       user_unique text not null,
+                       public_key bytea not null,
+                       sign_count bigint not null,
+                       transports text[] not null)`, Sanitize(collConf.Pk))
+}
+
+// InsertWebAuthnCred inserts public key credential in the credential collection
+func (s *ConnSession) InsertWebAuthnCred(collConf storage.CollConfig, cred storage.WebAuthnCredential) error {
+	transports := cred.Transports
+	if transports == nil {
+		transports = []string{}
+	}
+
+	sql := fmt.Sprintf(`insert into %s (%s, user_unique, public_key, sign_count, transports)
+                       values ($1, $2, $3, $4, $5);`,
+		Sanitize(collConf.Name),
+		Sanitize(collConf.Pk))
+	return s.RawExec(sql, cred.Id, cred.UserUnique, cred.PublicKey, int64(cred.SignCount), transports)
+}
+
+// GetWebAuthnCreds returns all public key credentials of the user
+func (s *ConnSession) GetWebAuthnCreds(collConf storage.CollConfig, userUnique interface{}) ([]storage.WebAuthnCredential, error) {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	sql := fmt.Sprintf("select %s, user_unique, public_key, sign_count, transports from %s where user_unique=$1;",
+		Sanitize(collConf.Pk),
+		Sanitize(collConf.Name))
+	rows, err := s.conn.Query(ctx, sql, userUnique)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var creds []storage.WebAuthnCredential
+	for rows.Next() {
+		cred, err := scanWebAuthnCred(rows)
+		if err != nil {
+			return nil, err
+		}
+		creds = append(creds, *cred)
+	}
+
+	return creds, rows.Err()
+}
+
+// GetWebAuthnCred returns public key credential by its id, or nil if it doesn't exist
+func (s *ConnSession) GetWebAuthnCred(collConf storage.CollConfig, id []byte) (*storage.WebAuthnCredential, error) {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	sql := fmt.Sprintf("select %s, user_unique, public_key, sign_count, transports from %s where %s=$1;",
+		Sanitize(collConf.Pk),
+		Sanitize(collConf.Name),
+		Sanitize(collConf.Pk))
+
+	cred, err := scanWebAuthnCred(s.conn.QueryRow(ctx, sql, id))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return cred, err
+}
+
+// UpdateWebAuthnSignCount saves the last signature counter of the credential
+func (s *ConnSession) UpdateWebAuthnSignCount(collConf storage.CollConfig, id []byte, signCount uint32) error {
+	sql := fmt.Sprintf("update %s set sign_count=$1 where %s=$2;",
+		Sanitize(collConf.Name),
+		Sanitize(collConf.Pk))
+	return s.RawExec(sql, int64(signCount), id)
+}
+
+// CreateWebAuthnChallengeColl creates collection for the states of unfinished ceremonies
+func (s *ConnSession) CreateWebAuthnChallengeColl(collConf storage.CollConfig) error {
+	sql := fmt.Sprintf("create table %s %s;", Sanitize(collConf.Name), webauthnChallengeColumns(collConf))
+	return s.RawExec(sql)
+}
+
+// webauthnChallengeColumns returns definition of the ceremony challenges collection columns
+func webauthnChallengeColumns(collConf storage.CollConfig) string {
+	return fmt.Sprintf(`(%s text primary key,
+                       data text not null,
+                       expires_at timestamptz not null)`, Sanitize(collConf.Pk))
+}
+
+// InsertWebAuthnChallenge saves the state of the ceremony until it expires
+func (s *ConnSession) InsertWebAuthnChallenge(collConf storage.CollConfig, id string, data string, expires time.Time) error {
+	sql := fmt.Sprintf("insert into %s (%s, data, expires_at) values ($1, $2, $3);",
+		Sanitize(collConf.Name),
+		Sanitize(collConf.Pk))
+	if err := s.RawExec(sql, id, data, expires); err != nil {
+		return err
+	}
+
+	sql = fmt.Sprintf("delete from %s where expires_at < now();", Sanitize(collConf.Name))
+	return s.RawExec(sql)
+}
+
+// PopWebAuthnChallenge returns the state of the ceremony and removes it,
+// so it can't be used twice. It returns empty string if there is no such unexpired ceremony
+func (s *ConnSession) PopWebAuthnChallenge(collConf storage.CollConfig, id string) (string, error) {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	sql := fmt.Sprintf("delete from %s where %s=$1 returning data, expires_at;",
+		Sanitize(collConf.Name),
+		Sanitize(collConf.Pk))
+
+	var (
+		data    string
+		expires time.Time
+	)
+	err := s.conn.QueryRow(ctx, sql, id).Scan(&data, &expires)
+	if err == pgx.ErrNoRows {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	if time.Now().After(expires) {
+		return "", nil
+	}
+	return data, nil
+}
+
+func scanWebAuthnCred(row pgx.Row) (*storage.WebAuthnCredential, error) {
+	var (
+		cred      storage.WebAuthnCredential
+		userUniq  string
+		signCount int64
+	)
+
+	err := row.Scan(&cred.Id, &userUniq, &cred.PublicKey, &signCount, &cred.Transports)
+	if err != nil {
+		return nil, err
+	}
+	cred.UserUnique = userUniq
+	cred.SignCount = uint32(signCount)
+
+	return &cred, nil
+}
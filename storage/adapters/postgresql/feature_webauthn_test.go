@@ -0,0 +1,68 @@
+package postgresql
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gouth/storage"
+	"testing"
+	"time"
+)
+
+func Test_Session_WebAuthnCreds(t *testing.T) {
+	usersSess := createUsersSess(t)
+	defer usersSess.Close()
+
+	webauthnSess := usersSess.(storage.WebAuthn)
+	collConf := *storage.NewCollConfig("webauthn_credentials_test", "id")
+
+	err := webauthnSess.CreateWebAuthnCredColl(collConf)
+	assert.NoError(t, err)
+	defer usersSess.RawExec("drop table webauthn_credentials_test;")
+
+	cred := storage.WebAuthnCredential{
+		Id:         []byte("cred-1"),
+		UserUnique: "hello",
+		PublicKey:  []byte("key"),
+		SignCount:  1,
+		Transports: []string{"usb", "nfc"},
+	}
+	err = webauthnSess.InsertWebAuthnCred(collConf, cred)
+	assert.NoError(t, err)
+
+	creds, err := webauthnSess.GetWebAuthnCreds(collConf, "hello")
+	assert.NoError(t, err)
+	assert.Equal(t, []storage.WebAuthnCredential{cred}, creds)
+
+	err = webauthnSess.UpdateWebAuthnSignCount(collConf, cred.Id, 5)
+	assert.NoError(t, err)
+
+	got, err := webauthnSess.GetWebAuthnCred(collConf, cred.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(5), got.SignCount)
+
+	got, err = webauthnSess.GetWebAuthnCred(collConf, []byte("other"))
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func Test_Session_WebAuthnChallenges(t *testing.T) {
+	usersSess := createUsersSess(t)
+	defer usersSess.Close()
+
+	webauthnSess := usersSess.(storage.WebAuthn)
+	collConf := *storage.NewCollConfig("webauthn_challenges_test", "id")
+
+	err := webauthnSess.CreateWebAuthnChallengeColl(collConf)
+	assert.NoError(t, err)
+	defer usersSess.RawExec("drop table webauthn_challenges_test;")
+
+	err = webauthnSess.InsertWebAuthnChallenge(collConf, "1", "data", time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+
+	data, err := webauthnSess.PopWebAuthnChallenge(collConf, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "data", data)
+
+	data, err = webauthnSess.PopWebAuthnChallenge(collConf, "1")
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+}
@@ -0,0 +1,122 @@
+package redis
+
+import (
+	"crypto/tls"
+	"fmt"
+	goredis "github.com/go-redis/redis/v8"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// defaultKeyPrefix is the prefix of all keys, so the database can be shared with other apps
+const defaultKeyPrefix = "gouth:"
+
+// ConnConfig represents a parsed Redis connection URL
+type ConnConfig struct {
+	User     string
+	Password string
+	Host     string
+	Port     string
+	Database string
+	Options  map[string]string
+}
+
+// String reassembles Redis connection config into a valid connection url
+func (connConf ConnConfig) String() (string, error) {
+	vv := url.Values{}
+	if connConf.Options != nil {
+		for k, v := range connConf.Options {
+			vv.Set(k, v)
+		}
+	}
+
+	if connConf.AdapterName() == "" ||
+		connConf.Host == "" ||
+		connConf.Port == "" {
+		return "", fmt.Errorf("invalid connection url")
+	}
+
+	u := url.URL{
+		Scheme:   connConf.AdapterName(),
+		Host:     fmt.Sprintf("%s:%s", connConf.Host, connConf.Port),
+		Path:     connConf.Database,
+		RawQuery: vv.Encode(),
+	}
+	if connConf.Password != "" {
+		u.User = url.UserPassword(connConf.User, connConf.Password)
+	} else if connConf.User != "" {
+		u.User = url.User(connConf.User)
+	}
+	return u.String(), nil
+}
+
+// DBName returns the number of the database, that we've connected by this config
+func (connConf ConnConfig) DBName() string {
+	return connConf.Database
+}
+
+// AdapterName return the adapter name, that was used to set up connection
+func (connConf ConnConfig) AdapterName() string {
+	return AdapterName
+}
+
+// clientOptions returns options of the redis client and the prefix of the keys.
+// Known options are key_prefix, tls, pool_size, min_idle_conns, max_conn_age, idle_timeout,
+// dial_timeout, read_timeout and write_timeout
+func (connConf ConnConfig) clientOptions() (*goredis.Options, string, error) {
+	if _, err := connConf.String(); err != nil {
+		return nil, "", err
+	}
+
+	opts := &goredis.Options{
+		Addr:     fmt.Sprintf("%s:%s", connConf.Host, connConf.Port),
+		Username: connConf.User,
+		Password: connConf.Password,
+	}
+
+	if connConf.Database != "" {
+		db, err := strconv.Atoi(connConf.Database)
+		if err != nil || db < 0 {
+			return nil, "", fmt.Errorf("redis: database must be a non-negative number, got %s", connConf.Database)
+		}
+		opts.DB = db
+	}
+
+	var (
+		prefix = defaultKeyPrefix
+		err    error
+	)
+	for k, v := range connConf.Options {
+		switch k {
+		case "key_prefix":
+			prefix = v
+		case "tls":
+			var isTLS bool
+			if isTLS, err = strconv.ParseBool(v); err == nil && isTLS {
+				opts.TLSConfig = &tls.Config{ServerName: connConf.Host}
+			}
+		case "pool_size":
+			opts.PoolSize, err = strconv.Atoi(v)
+		case "min_idle_conns":
+			opts.MinIdleConns, err = strconv.Atoi(v)
+		case "max_conn_age":
+			opts.MaxConnAge, err = time.ParseDuration(v)
+		case "idle_timeout":
+			opts.IdleTimeout, err = time.ParseDuration(v)
+		case "dial_timeout":
+			opts.DialTimeout, err = time.ParseDuration(v)
+		case "read_timeout":
+			opts.ReadTimeout, err = time.ParseDuration(v)
+		case "write_timeout":
+			opts.WriteTimeout, err = time.ParseDuration(v)
+		default:
+			return nil, "", fmt.Errorf("redis: unknown option %s", k)
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("redis: invalid %s: %v", k, err)
+		}
+	}
+
+	return opts, prefix, nil
+}
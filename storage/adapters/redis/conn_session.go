@@ -0,0 +1,121 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	goredis "github.com/go-redis/redis/v8"
+	"gouth/storage"
+	"time"
+)
+
+// ErrUnsupported is returned by the methods of the features, which aren't kept in redis
+var ErrUnsupported = errors.New("redis: the feature isn't supported")
+
+// ConnSession represents a redis database.
+// Redis client holds a pool of connections, so it's safe for concurrent use
+type ConnSession struct {
+	ctx      context.Context
+	client   *goredis.Client
+	connConf storage.ConnConfig
+	// prefix of all keys
+	prefix string
+	// default time limit of one query
+	timeout time.Duration
+	// for abstract queries
+	relInfo map[storage.CollPair]storage.RelInfo
+}
+
+// Open creates redis client and checks the connection
+func (s *ConnSession) Open() error {
+	connConf, ok := s.connConf.(ConnConfig)
+	if !ok {
+		return errors.New("redis: unexpected connection config type")
+	}
+
+	opts, prefix, err := connConf.clientOptions()
+	if err != nil {
+		return err
+	}
+
+	s.client = goredis.NewClient(opts)
+	s.prefix = prefix
+	if err := s.Ping(); err != nil {
+		s.client.Close()
+		return err
+	}
+	return nil
+}
+
+// WithContext returns a copy of the session, which runs queries with the given context
+func (s *ConnSession) WithContext(ctx context.Context) storage.ConnSession {
+	sess := *s
+	sess.ctx = ctx
+	return &sess
+}
+
+// SetQueryTimeout sets the default time limit of one query. 0 means no limit
+func (s *ConnSession) SetQueryTimeout(timeout time.Duration) {
+	s.timeout = timeout
+}
+
+// queryCtx returns context of one query limited by the default query timeout
+func (s *ConnSession) queryCtx() (context.Context, context.CancelFunc) {
+	if s.timeout == 0 {
+		return context.WithCancel(s.ctx)
+	}
+	return context.WithTimeout(s.ctx, s.timeout)
+}
+
+// collsKey returns the key of the hash, which keeps kinds of the created collections
+func (s *ConnSession) collsKey() string {
+	return s.prefix + "colls"
+}
+
+// recordKey returns the key of one record of the collection
+func (s *ConnSession) recordKey(collConf storage.CollConfig, key string) string {
+	return fmt.Sprintf("%s%s:%s", s.prefix, collConf.Name, key)
+}
+
+// createColl registers collection of the kind. Records are created on demand,
+// so the registry only tells the app that collection has been initialized.
+// Collection of the same kind may be registered by another instance started at the same time, it's kept
+func (s *ConnSession) createColl(collConf storage.CollConfig, kind string) error {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	isCreated, err := s.client.HSetNX(ctx, s.collsKey(), collConf.Name, kind).Result()
+	if err != nil {
+		return err
+	}
+	if isCreated {
+		return nil
+	}
+
+	existKind, err := s.client.HGet(ctx, s.collsKey(), collConf.Name).Result()
+	if err != nil {
+		return err
+	}
+	if existKind != kind {
+		return fmt.Errorf("redis: collection %s already exists as %s", collConf.Name, existKind)
+	}
+	return nil
+}
+
+// GetConfig returns the connection config that was used to set up the adapter
+func (s *ConnSession) GetConfig() storage.ConnConfig {
+	return s.connConf
+}
+
+// Ping returns an error if redis could not be reached
+func (s *ConnSession) Ping() error {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	return s.client.Ping(ctx).Err()
+}
+
+// Close closes all connections of the pool
+func (s *ConnSession) Close() error {
+	return s.client.Close()
+}
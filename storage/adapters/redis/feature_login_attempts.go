@@ -0,0 +1,143 @@
+package redis
+
+import (
+	goredis "github.com/go-redis/redis/v8"
+	"gouth/storage"
+	"strconv"
+	"time"
+)
+
+// registerFailureScript increments failures counter, or starts it over if the last failure is out of the window.
+// The record expires when both the window and the lock are over. Times are unix microseconds
+//
+//	KEYS[1] - record key, ARGV[1] - now, ARGV[2] - window
+var registerFailureScript = goredis.NewScript(`
+local now, window = tonumber(ARGV[1]), tonumber(ARGV[2])
+local last = tonumber(redis.call('hget', KEYS[1], 'last_failure') or '0')
+local rawLocked = redis.call('hget', KEYS[1], 'locked_until') or '0'
+local locked = tonumber(rawLocked)
+
+local failures = 1
+if last >= now - window then
+	failures = redis.call('hincrby', KEYS[1], 'failures', 1)
+else
+	redis.call('hset', KEYS[1], 'failures', 1)
+end
+redis.call('hset', KEYS[1], 'last_failure', ARGV[1])
+
+redis.call('pexpire', KEYS[1], math.max(math.ceil(math.max(window, locked - now) / 1000), 1))
+return {failures, rawLocked}
+`)
+
+// lockScript sets the lock of the existing record and prolongs the record until the lock is over
+//
+//	KEYS[1] - record key, ARGV[1] - now, ARGV[2] - locked until
+var lockScript = goredis.NewScript(`
+if redis.call('exists', KEYS[1]) == 0 then
+	return 0
+end
+
+redis.call('hset', KEYS[1], 'locked_until', ARGV[2])
+local ttl = math.ceil((tonumber(ARGV[2]) - tonumber(ARGV[1])) / 1000)
+if ttl > redis.call('pttl', KEYS[1]) then
+	redis.call('pexpire', KEYS[1], ttl)
+end
+return 1
+`)
+
+// forgetFailureScript decrements failures counter of the existing record
+//
+//	KEYS[1] - record key
+var forgetFailureScript = goredis.NewScript(`
+if tonumber(redis.call('hget', KEYS[1], 'failures') or '0') > 0 then
+	redis.call('hincrby', KEYS[1], 'failures', -1)
+end
+return 1
+`)
+
+// CreateLoginAttemptColl registers collection for the failed login attempts
+func (s *ConnSession) CreateLoginAttemptColl(collConf storage.CollConfig) error {
+	return s.createColl(collConf, "login_attempts")
+}
+
+// GetLoginAttempt returns failed login attempts for the key, or nil if there are none
+func (s *ConnSession) GetLoginAttempt(collConf storage.CollConfig, key string) (*storage.LoginAttempt, error) {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	fields, err := s.client.HGetAll(ctx, s.recordKey(collConf, key)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	attempt := &storage.LoginAttempt{Key: key}
+	attempt.Failures, _ = strconv.Atoi(fields["failures"])
+	attempt.LastFailure = fromMicro(fields["last_failure"])
+	attempt.LockedUntil = fromMicro(fields["locked_until"])
+	return attempt, nil
+}
+
+// RegisterLoginFailure increments failures counter for the key.
+// The counter starts over, if the last failure is older than the given window
+func (s *ConnSession) RegisterLoginFailure(collConf storage.CollConfig, key string, window time.Duration) (*storage.LoginAttempt, error) {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	now := time.Now()
+	res, err := registerFailureScript.Run(ctx, s.client,
+		[]string{s.recordKey(collConf, key)},
+		now.UnixNano()/1e3, window.Microseconds(),
+	).Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	failures, _ := res[0].(int64)
+	locked, _ := res[1].(string)
+	return &storage.LoginAttempt{
+		Key:         key,
+		Failures:    int(failures),
+		LastFailure: fromMicro(strconv.FormatInt(now.UnixNano()/1e3, 10)),
+		LockedUntil: fromMicro(locked),
+	}, nil
+}
+
+// ForgetLoginFailure decrements failures counter for the key
+func (s *ConnSession) ForgetLoginFailure(collConf storage.CollConfig, key string) error {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	return forgetFailureScript.Run(ctx, s.client, []string{s.recordKey(collConf, key)}).Err()
+}
+
+// LockLogin forbids login attempts for the key until the given time
+func (s *ConnSession) LockLogin(collConf storage.CollConfig, key string, until time.Time) error {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	return lockScript.Run(ctx, s.client,
+		[]string{s.recordKey(collConf, key)},
+		time.Now().UnixNano()/1e3, until.UnixNano()/1e3,
+	).Err()
+}
+
+// ResetLoginAttempts removes failed login attempts and lock for the key
+func (s *ConnSession) ResetLoginAttempts(collConf storage.CollConfig, key string) error {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	return s.client.Del(ctx, s.recordKey(collConf, key)).Err()
+}
+
+// fromMicro parses unix time in microseconds. Missing time is zero time
+func fromMicro(raw string) time.Time {
+	us, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || us == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, us*1e3)
+}
@@ -0,0 +1,72 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gouth/storage"
+	"testing"
+	"time"
+)
+
+func Test_Session_LoginAttempts(t *testing.T) {
+	sess, srv := openSess(t, "login_attempts")
+	defer sess.Close()
+
+	laSess := sess.(storage.LoginAttempts)
+	collConf := storage.NewCollConfig("login_attempts", "key")
+	assert.NoError(t, laSess.CreateLoginAttemptColl(*collConf))
+
+	attempt, err := laSess.GetLoginAttempt(*collConf, "john")
+	assert.NoError(t, err)
+	assert.Nil(t, attempt)
+
+	for i := 1; i <= 3; i++ {
+		attempt, err = laSess.RegisterLoginFailure(*collConf, "john", time.Minute)
+		assert.NoError(t, err)
+		assert.Equal(t, i, attempt.Failures)
+	}
+	assert.Equal(t, time.Minute, srv.TTL("gouth:login_attempts:john"))
+
+	assert.NoError(t, laSess.ForgetLoginFailure(*collConf, "john"))
+
+	attempt, err = laSess.GetLoginAttempt(*collConf, "john")
+	if assert.NoError(t, err) && assert.NotNil(t, attempt) {
+		assert.Equal(t, 2, attempt.Failures)
+	}
+
+	// failures older than the window aren't counted
+	time.Sleep(time.Millisecond)
+	attempt, err = laSess.RegisterLoginFailure(*collConf, "john", time.Microsecond)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, attempt.Failures)
+
+	until := time.Now().Add(time.Hour).Truncate(time.Microsecond)
+	assert.NoError(t, laSess.LockLogin(*collConf, "john", until))
+	assert.InDelta(t, time.Hour, srv.TTL("gouth:login_attempts:john"), float64(time.Second))
+
+	attempt, err = laSess.GetLoginAttempt(*collConf, "john")
+	if assert.NoError(t, err) && assert.NotNil(t, attempt) {
+		assert.Equal(t, 1, attempt.Failures)
+		assert.True(t, until.Equal(attempt.LockedUntil))
+	}
+
+	// the lock isn't lost with the next failure
+	attempt, err = laSess.RegisterLoginFailure(*collConf, "john", time.Minute)
+	if assert.NoError(t, err) {
+		assert.True(t, until.Equal(attempt.LockedUntil))
+	}
+
+	assert.NoError(t, laSess.ResetLoginAttempts(*collConf, "john"))
+
+	attempt, err = laSess.GetLoginAttempt(*collConf, "john")
+	assert.NoError(t, err)
+	assert.Nil(t, attempt)
+
+	// records expire with the window
+	_, err = laSess.RegisterLoginFailure(*collConf, "jane", time.Minute)
+	assert.NoError(t, err)
+	srv.FastForward(time.Minute)
+
+	attempt, err = laSess.GetLoginAttempt(*collConf, "jane")
+	assert.NoError(t, err)
+	assert.Nil(t, attempt)
+}
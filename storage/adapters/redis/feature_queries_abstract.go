@@ -0,0 +1,12 @@
+package redis
+
+import "gouth/storage"
+
+func (s *ConnSession) RelInfo() map[storage.CollPair]storage.RelInfo {
+	return s.relInfo
+}
+
+// Read isn't supported, as redis keeps no relations
+func (s *ConnSession) Read(string, ...interface{}) (storage.JSONCollResult, error) {
+	return nil, storage.ErrReadUnsupported
+}
@@ -0,0 +1,19 @@
+package redis
+
+import "gouth/storage"
+
+// RawExec runs redis command, e.g. RawExec("set", "key", "value")
+func (s *ConnSession) RawExec(cmd string, args ...interface{}) error {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	return s.client.Do(ctx, append([]interface{}{cmd}, args...)...).Err()
+}
+
+// RawQuery runs redis command and returns its reply, e.g. RawQuery("get", "key")
+func (s *ConnSession) RawQuery(cmd string, args ...interface{}) (storage.JSONCollResult, error) {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	return s.client.Do(ctx, append([]interface{}{cmd}, args...)...).Result()
+}
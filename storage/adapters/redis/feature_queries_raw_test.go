@@ -0,0 +1,22 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_Session_RawQuery(t *testing.T) {
+	sess, srv := openSess(t, "rate_limits")
+	defer sess.Close()
+
+	assert.NoError(t, sess.RawExec("set", "key", "value"))
+	assert.Error(t, sess.RawExec("unknown"))
+
+	res, err := sess.RawQuery("get", "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", res)
+
+	value, err := srv.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+}
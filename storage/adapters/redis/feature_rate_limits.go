@@ -0,0 +1,64 @@
+package redis
+
+import (
+	goredis "github.com/go-redis/redis/v8"
+	"gouth/storage"
+	"strconv"
+	"time"
+)
+
+// takeTokenScript refills the bucket and takes one token from it. The bucket expires when it's full again,
+// as the new bucket is the same. Tokens are returned as string, since lua numbers are converted to integers
+//
+//	KEYS[1] - bucket key, ARGV[1] - now in unix microseconds, ARGV[2] - capacity, ARGV[3] - tokens per second
+var takeTokenScript = goredis.NewScript(`
+local now, capacity, rate = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+local bucket = redis.call('hmget', KEYS[1], 'tokens', 'updated_at')
+
+local tokens = capacity
+if bucket[1] then
+	tokens = math.min(capacity, tonumber(bucket[1]) + (now - tonumber(bucket[2])) / 1e6 * rate)
+end
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('hmset', KEYS[1], 'tokens', tostring(tokens), 'updated_at', ARGV[1])
+if rate > 0 then
+	redis.call('pexpire', KEYS[1], math.ceil((capacity - tokens) / rate * 1000) + 1)
+end
+return {tostring(tokens), allowed}
+`)
+
+// CreateRateLimitColl registers collection for the token buckets
+func (s *ConnSession) CreateRateLimitColl(collConf storage.CollConfig) error {
+	return s.createColl(collConf, "rate_limits")
+}
+
+// TakeRateLimitToken atomically refills the bucket with the given capacity and refill rate
+// (tokens per second) and takes one token from it.
+// It returns the number of tokens left and whether the token was taken
+func (s *ConnSession) TakeRateLimitToken(collConf storage.CollConfig, key string, capacity, rate float64) (float64, bool, error) {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	res, err := takeTokenScript.Run(ctx, s.client,
+		[]string{s.recordKey(collConf, key)},
+		time.Now().UnixNano()/1e3, capacity, rate,
+	).Slice()
+	if err != nil {
+		return 0, false, err
+	}
+
+	rawTokens, _ := res[0].(string)
+	tokens, err := strconv.ParseFloat(rawTokens, 64)
+	if err != nil {
+		return 0, false, err
+	}
+
+	allowed, _ := res[1].(int64)
+	return tokens, allowed == 1, nil
+}
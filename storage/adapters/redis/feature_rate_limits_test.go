@@ -0,0 +1,41 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gouth/storage"
+	"testing"
+	"time"
+)
+
+func Test_Session_TakeRateLimitToken(t *testing.T) {
+	sess, srv := openSess(t, "rate_limits")
+	defer sess.Close()
+
+	rlSess := sess.(storage.RateLimits)
+	collConf := storage.NewCollConfig("rate_limits", "key")
+	assert.NoError(t, rlSess.CreateRateLimitColl(*collConf))
+
+	tokens, allowed, err := rlSess.TakeRateLimitToken(*collConf, "ip", 2, 0.001)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+	assert.InDelta(t, 1, tokens, 0.01)
+
+	_, allowed, err = rlSess.TakeRateLimitToken(*collConf, "ip", 2, 0.001)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+
+	tokens, allowed, err = rlSess.TakeRateLimitToken(*collConf, "ip", 2, 0.001)
+	assert.NoError(t, err)
+	assert.False(t, allowed)
+	assert.InDelta(t, 0, tokens, 0.01)
+
+	// buckets are independent
+	_, allowed, err = rlSess.TakeRateLimitToken(*collConf, "other ip", 2, 0.001)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+
+	// the bucket expires when it's full again
+	assert.True(t, srv.Exists("gouth:rate_limits:ip"))
+	srv.FastForward(2001 * time.Second)
+	assert.False(t, srv.Exists("gouth:rate_limits:ip"))
+}
@@ -0,0 +1,33 @@
+package redis
+
+import (
+	"gouth/storage"
+)
+
+// IsCollExists checks whether the given collection has been created
+func (s *ConnSession) IsCollExists(collConf storage.CollConfig) (bool, error) {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	return s.client.HExists(ctx, s.collsKey(), collConf.Name).Result()
+}
+
+// CreateUserColl isn't supported, users must be kept in a persistent storage
+func (s *ConnSession) CreateUserColl(storage.UserCollConfig) error {
+	return ErrUnsupported
+}
+
+// InsertUser isn't supported, users must be kept in a persistent storage
+func (s *ConnSession) InsertUser(storage.UserCollConfig, storage.InsertUserData) (storage.JSONCollResult, error) {
+	return nil, ErrUnsupported
+}
+
+// GetUserPassword isn't supported, users must be kept in a persistent storage
+func (s *ConnSession) GetUserPassword(storage.UserCollConfig, interface{}) (storage.JSONCollResult, error) {
+	return nil, ErrUnsupported
+}
+
+// UpdateUserPassword isn't supported, users must be kept in a persistent storage
+func (s *ConnSession) UpdateUserPassword(storage.UserCollConfig, interface{}, string) error {
+	return ErrUnsupported
+}
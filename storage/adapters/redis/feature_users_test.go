@@ -0,0 +1,39 @@
+package redis
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gouth/storage"
+	"testing"
+)
+
+func Test_Session_IsCollExists(t *testing.T) {
+	sess, _ := openSess(t, "rate_limits")
+	defer sess.Close()
+
+	collConf := storage.NewCollConfig("rate_limits", "key")
+
+	isExist, err := sess.IsCollExists(*collConf)
+	assert.NoError(t, err)
+	assert.False(t, isExist)
+
+	assert.NoError(t, sess.(storage.RateLimits).CreateRateLimitColl(*collConf))
+	// another instance may create the same collection at the same time
+	assert.NoError(t, sess.(storage.RateLimits).CreateRateLimitColl(*collConf))
+	assert.EqualError(t, sess.(storage.LoginAttempts).CreateLoginAttemptColl(*collConf),
+		"redis: collection rate_limits already exists as rate_limits")
+
+	isExist, err = sess.IsCollExists(*collConf)
+	assert.NoError(t, err)
+	assert.True(t, isExist)
+}
+
+func Test_Session_Users(t *testing.T) {
+	sess, _ := openSess(t, "rate_limits")
+	defer sess.Close()
+
+	collConf := storage.NewUserCollConfig("users", "id", "username", "password")
+
+	assert.Equal(t, ErrUnsupported, sess.CreateUserColl(*collConf))
+	_, err := sess.InsertUser(*collConf, *storage.NewInsertUserData("john", "hash"))
+	assert.Equal(t, ErrUnsupported, err)
+}
@@ -0,0 +1,104 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"gouth/storage"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// AdapterName is the internal name of the adapter
+const AdapterName = "sqlite"
+
+var AdapterFeatures = map[string]bool{"users": true, "sessions": true}
+
+// init initializes package by register adapter
+func init() {
+	storage.RegisterAdapter(AdapterName, &sqliteAdapter{})
+}
+
+// sqliteAdapter represents adapter for sqlite database
+type sqliteAdapter struct {
+}
+
+func (a sqliteAdapter) GetFeatures() map[string]bool {
+	return AdapterFeatures
+}
+
+// OpenWithConfig opens the database file by connection config
+func (a sqliteAdapter) OpenWithConfig(connConf storage.ConnConfig) (storage.ConnSession, error) {
+	sess := &ConnSession{
+		ctx:         context.Background(),
+		connConf:    connConf,
+		userQueries: &sync.Map{},
+	}
+
+	if err := sess.Open(); err != nil {
+		return nil, err
+	}
+
+	return sess, nil
+}
+
+// ParseUrl parses the connection url into ConnConfig struct. The url looks like this:
+//
+//	sqlite://path/to/file.db?journal_mode=wal
+//
+// The path is relative to the working directory. Absolute path starts with the third slash
+func (a sqliteAdapter) ParseUrl(connUrl string) (storage.ConnConfig, error) {
+	connConf := ConnConfig{}
+	if !strings.HasPrefix(connUrl, connConf.AdapterName()+"://") {
+		return nil, fmt.Errorf("expecting sqlite:// connection schema")
+	}
+
+	path := strings.TrimPrefix(connUrl, connConf.AdapterName()+"://")
+	rawQuery := ""
+	if i := strings.IndexByte(path, '?'); i != -1 {
+		path, rawQuery = path[:i], path[i+1:]
+	}
+
+	path, err := url.PathUnescape(path)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return nil, fmt.Errorf("invalid connection url")
+	}
+
+	vv, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	connConf.Path = path
+	connConf.Options = map[string]string{}
+	for k := range vv {
+		connConf.Options[k] = vv.Get(k)
+	}
+
+	return connConf, nil
+}
+
+// NewConfig creates new ConnConfig struct from the raw data, parsed from the config file
+func (a sqliteAdapter) NewConfig(data map[string]interface{}) (storage.ConnConfig, error) {
+	path, ok := data["path"].(string)
+	if !ok {
+		return nil, fmt.Errorf("connection config: missing path statement")
+	} else if path == "" {
+		return nil, fmt.Errorf("connection config: path statement cannot be empty")
+	}
+
+	opts := make(map[string]string)
+	if rawOpts, ok := data["options"].(map[string]interface{}); ok {
+		for key, value := range rawOpts {
+			opts[key] = fmt.Sprintf("%v", value)
+		}
+	}
+
+	return ConnConfig{
+		Path:    path,
+		Options: opts,
+	}, nil
+}
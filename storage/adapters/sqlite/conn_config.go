@@ -0,0 +1,93 @@
+package sqlite
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// MemoryPath is the path of the in-memory database
+const MemoryPath = ":memory:"
+
+// driverOptions maps connection options to the parameters of the sqlite3 driver
+var driverOptions = map[string]string{
+	"journal_mode": "_journal_mode",
+	"busy_timeout": "_busy_timeout",
+	"foreign_keys": "_foreign_keys",
+	"synchronous":  "_synchronous",
+	"txlock":       "_txlock",
+}
+
+// defaultOptions are used if the options aren't set. WAL mode lets readers work
+// concurrently with the writer, busy timeout (in milliseconds) makes writers wait for the lock
+var defaultOptions = map[string]string{
+	"journal_mode": "wal",
+	"busy_timeout": "5000",
+	"foreign_keys": "on",
+}
+
+// ConnConfig represents a parsed SQLite connection URL
+type ConnConfig struct {
+	Path    string
+	Options map[string]string
+}
+
+// String reassembles SQLite connection config into a valid connection url
+func (connConf ConnConfig) String() (string, error) {
+	if connConf.Path == "" {
+		return "", fmt.Errorf("invalid connection url")
+	}
+
+	vv := url.Values{}
+	for k, v := range connConf.Options {
+		vv.Set(k, v)
+	}
+
+	str := connConf.AdapterName() + "://" + connConf.Path
+	if len(vv) != 0 {
+		str += "?" + vv.Encode()
+	}
+	return str, nil
+}
+
+// DBName returns the path of the database file
+func (connConf ConnConfig) DBName() string {
+	return connConf.Path
+}
+
+// AdapterName return the adapter name, that was used to set up connection
+func (connConf ConnConfig) AdapterName() string {
+	return AdapterName
+}
+
+// dsn returns data source name for the sqlite3 driver with the default options applied
+func (connConf ConnConfig) dsn() (string, error) {
+	if connConf.Path == "" {
+		return "", fmt.Errorf("invalid connection url")
+	}
+
+	opts := map[string]string{}
+	for k, v := range defaultOptions {
+		opts[k] = v
+	}
+	// WAL isn't supported by the in-memory database
+	if connConf.Path == MemoryPath {
+		delete(opts, "journal_mode")
+	}
+	for k, v := range connConf.Options {
+		opts[k] = v
+	}
+
+	params := make([]string, 0, len(opts))
+	for k, v := range opts {
+		driverKey, ok := driverOptions[k]
+		if !ok {
+			return "", fmt.Errorf("sqlite: unknown option %s", k)
+		}
+		params = append(params, driverKey+"="+url.QueryEscape(v))
+	}
+	sort.Strings(params)
+
+	return "file:" + connConf.Path + "?" + strings.Join(params, "&"), nil
+}
@@ -0,0 +1,84 @@
+package sqlite
+
+import (
+	"testing"
+)
+
+func Test_ConnConfig_String(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    ConnConfig
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "full config to string",
+			conf: ConnConfig{Path: "data/test.db", Options: map[string]string{"journal_mode": "wal"}},
+			want: "sqlite://data/test.db?journal_mode=wal",
+		},
+		{
+			name: "config without opts to string",
+			conf: ConnConfig{Path: "/var/lib/test.db", Options: map[string]string{}},
+			want: "sqlite:///var/lib/test.db",
+		},
+		{
+			name:    "config without path to string",
+			conf:    ConnConfig{Options: map[string]string{}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.conf.String()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("String() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("String() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ConnConfig_dsn(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    ConnConfig
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "default options",
+			conf: ConnConfig{Path: "test.db"},
+			want: "file:test.db?_busy_timeout=5000&_foreign_keys=on&_journal_mode=wal",
+		},
+		{
+			name: "overridden options",
+			conf: ConnConfig{Path: "test.db", Options: map[string]string{"journal_mode": "delete", "synchronous": "full"}},
+			want: "file:test.db?_busy_timeout=5000&_foreign_keys=on&_journal_mode=delete&_synchronous=full",
+		},
+		{
+			name: "in-memory database without wal",
+			conf: ConnConfig{Path: MemoryPath},
+			want: "file::memory:?_busy_timeout=5000&_foreign_keys=on",
+		},
+		{
+			name:    "unknown option",
+			conf:    ConnConfig{Path: "test.db", Options: map[string]string{"cache": "shared"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.conf.dsn()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("dsn() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("dsn() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
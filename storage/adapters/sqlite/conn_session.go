@@ -0,0 +1,102 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"gouth/storage"
+	"sync"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// ConnSession represents a sqlite database
+type ConnSession struct {
+	ctx      context.Context
+	db       *sql.DB
+	connConf storage.ConnConfig
+	// default time limit of one query
+	timeout time.Duration
+	// for abstract queries
+	relInfo map[storage.CollPair]storage.RelInfo
+	// sql of the user queries by collection
+	userQueries *sync.Map
+}
+
+// Open opens the database file, creating it if it doesn't exist
+func (s *ConnSession) Open() error {
+	connConf, ok := s.connConf.(ConnConfig)
+	if !ok {
+		return errors.New("sqlite: unexpected connection config type")
+	}
+
+	dsn, err := connConf.dsn()
+	if err != nil {
+		return err
+	}
+
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return err
+	}
+
+	// each connection to the in-memory database opens a new empty database
+	if connConf.Path == MemoryPath {
+		db.SetMaxOpenConns(1)
+	}
+
+	s.db = db
+	if err := s.Ping(); err != nil {
+		db.Close()
+		return err
+	}
+	return nil
+}
+
+// WithContext returns a copy of the session, which runs queries with the given context
+func (s *ConnSession) WithContext(ctx context.Context) storage.ConnSession {
+	sess := *s
+	sess.ctx = ctx
+	return &sess
+}
+
+// SetQueryTimeout sets the default time limit of one query. 0 means no limit
+func (s *ConnSession) SetQueryTimeout(timeout time.Duration) {
+	s.timeout = timeout
+}
+
+// queryCtx returns context of one query limited by the default query timeout
+func (s *ConnSession) queryCtx() (context.Context, context.CancelFunc) {
+	if s.timeout == 0 {
+		return context.WithCancel(s.ctx)
+	}
+	return context.WithTimeout(s.ctx, s.timeout)
+}
+
+// GetConfig returns the connection config that was used to set up the adapter
+func (s *ConnSession) GetConfig() storage.ConnConfig {
+	return s.connConf
+}
+
+// Ping returns an error if the database file could not be read
+func (s *ConnSession) Ping() error {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	var o int
+	err := s.db.QueryRowContext(ctx, "select 1").Scan(&o)
+	if err != nil {
+		return err
+	}
+
+	if o != 1 {
+		return errors.New("got invalid data")
+	}
+	return nil
+}
+
+// Close closes the database
+func (s *ConnSession) Close() error {
+	return s.db.Close()
+}
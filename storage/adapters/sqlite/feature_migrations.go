@@ -0,0 +1,108 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gouth/storage"
+)
+
+// UserCollMigrations returns migrations of the user collection ordered by version.
+// The first one keeps the collection created before migrations, so it becomes version 1.
+// It can't be reverted, since the collection may hold the users created before migrations
+func (s *ConnSession) UserCollMigrations(collConf storage.UserCollConfig) []storage.Migration {
+	return []storage.Migration{
+		{
+			Version: 1,
+			Name:    "create_user_collection",
+			Up:      []string{fmt.Sprintf("create table if not exists %s %s", Sanitize(collConf.Name), userCollColumns(collConf))},
+		},
+	}
+}
+
+// CollMigrations returns nil, since the adapter keeps the user collection only
+func (s *ConnSession) CollMigrations(string, storage.CollConfig) []storage.Migration {
+	return nil
+}
+
+// LockSchema calls the function in one immediate transaction, which takes the write lock of the database,
+// so the other connections wait for it up to busy_timeout. All migrations of the run are rolled back on error.
+// Migrations aren't limited by the query timeout
+func (s *ConnSession) LockSchema(versionColl storage.CollConfig, scope string, f func(storage.SchemaTx) error) (err error) {
+	conn, err := s.db.Conn(s.ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(s.ctx, "begin immediate;"); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			// the context may be done, so the transaction is rolled back without it
+			conn.ExecContext(context.Background(), "rollback;")
+		}
+	}()
+
+	if err := f(&schemaTx{ctx: s.ctx, conn: conn, versionColl: versionColl, scope: scope}); err != nil {
+		return err
+	}
+
+	_, err = conn.ExecContext(s.ctx, "commit;")
+	return err
+}
+
+// schemaTx represents schema changes made in the transaction of LockSchema
+type schemaTx struct {
+	ctx         context.Context
+	conn        *sql.Conn
+	versionColl storage.CollConfig
+	scope       string
+}
+
+// SchemaVersion returns the applied version of the scope, 0 if nothing is applied yet
+func (t *schemaTx) SchemaVersion() (int, error) {
+	var isExists bool
+	err := t.conn.QueryRowContext(t.ctx,
+		"select exists (select 1 from sqlite_master where type = 'table' and name = ?);",
+		t.versionColl.Name).Scan(&isExists)
+	if err != nil || !isExists {
+		return 0, err
+	}
+
+	var version int
+	query := fmt.Sprintf("select version from %s where %s = ?;",
+		Sanitize(t.versionColl.Name),
+		Sanitize(t.versionColl.Pk))
+	err = t.conn.QueryRowContext(t.ctx, query, t.scope).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return version, err
+}
+
+// ApplyMigration runs the statements and saves the new version of the scope
+func (t *schemaTx) ApplyMigration(stmts []string, version int) error {
+	query := fmt.Sprintf(`create table if not exists %s
+                         (%s text primary key,
+                         version integer not null,
+                         applied_at timestamp not null default current_timestamp);`,
+		Sanitize(t.versionColl.Name),
+		Sanitize(t.versionColl.Pk))
+	if _, err := t.conn.ExecContext(t.ctx, query); err != nil {
+		return err
+	}
+
+	for _, stmt := range stmts {
+		if _, err := t.conn.ExecContext(t.ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	query = fmt.Sprintf("insert or replace into %s (%s, version) values (?, ?);",
+		Sanitize(t.versionColl.Name),
+		Sanitize(t.versionColl.Pk))
+	_, err := t.conn.ExecContext(t.ctx, query, t.scope, version)
+	return err
+}
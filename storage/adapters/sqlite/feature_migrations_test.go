@@ -0,0 +1,91 @@
+package sqlite
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"gouth/storage"
+	"strings"
+	"testing"
+)
+
+func Test_Session_Migrations(t *testing.T) {
+	usersSess := createUsersSess(t)
+	defer usersSess.Close()
+
+	m := usersSess.(storage.Migrator)
+	versionColl := storage.NewCollConfig("schema_migrations", "scope")
+	collConf := storage.NewUserCollConfig("users", "id", "username", "password")
+	migrations := m.UserCollMigrations(*collConf)
+
+	out := &bytes.Buffer{}
+	from, to, err := storage.Migrate(m, *versionColl, collConf.Name, migrations,
+		storage.MigrateOptions{Target: storage.LatestVersion, DryRun: true, Out: out})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, from)
+	assert.Equal(t, len(migrations), to)
+	assert.True(t, strings.Contains(out.String(), `create table if not exists "users"`))
+
+	// dry run doesn't change anything
+	isExist, err := usersSess.IsCollExists(*versionColl)
+	assert.NoError(t, err)
+	assert.False(t, isExist)
+
+	from, to, err = storage.Migrate(m, *versionColl, collConf.Name, migrations, storage.MigrateOptions{Target: storage.LatestVersion})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, from)
+	assert.Equal(t, len(migrations), to)
+
+	_, err = usersSess.InsertUser(*collConf, *storage.NewInsertUserData("john", "hash"))
+	assert.NoError(t, err)
+
+	// applied migrations are skipped
+	from, to, err = storage.Migrate(m, *versionColl, collConf.Name, migrations, storage.MigrateOptions{Target: storage.LatestVersion})
+	assert.NoError(t, err)
+	assert.Equal(t, len(migrations), from)
+	assert.Equal(t, len(migrations), to)
+
+	// the collection may hold the users created before migrations, so it isn't dropped
+	_, _, err = storage.Migrate(m, *versionColl, collConf.Name, migrations, storage.MigrateOptions{Target: 0})
+	assert.Error(t, err)
+
+	pw, err := usersSess.GetUserPassword(*collConf, "john")
+	assert.NoError(t, err)
+	assert.Equal(t, "hash", pw)
+}
+
+func Test_Session_Migrations_Rollback(t *testing.T) {
+	usersSess := createUsersSess(t)
+	defer usersSess.Close()
+
+	m := usersSess.(storage.Migrator)
+	versionColl := storage.NewCollConfig("schema_migrations", "scope")
+	migrations := []storage.Migration{
+		{Version: 1, Name: "create_items", Up: []string{"create table items (id integer)"}},
+		{Version: 2, Name: "broken", Up: []string{"alter table missing add column name text"}},
+	}
+
+	_, _, err := storage.Migrate(m, *versionColl, "items", migrations, storage.MigrateOptions{Target: storage.LatestVersion})
+	assert.Error(t, err)
+
+	// the whole run is rolled back
+	isExist, err := usersSess.IsCollExists(*storage.NewCollConfig("items", "id"))
+	assert.NoError(t, err)
+	assert.False(t, isExist)
+}
+
+func Test_Session_Migrations_VersionPk(t *testing.T) {
+	usersSess := createUsersSess(t)
+	defer usersSess.Close()
+
+	m := usersSess.(storage.Migrator)
+	versionColl := storage.NewCollConfig("versions", "coll_name")
+	collConf := storage.NewUserCollConfig("users", "id", "username", "password")
+
+	_, _, err := storage.Migrate(m, *versionColl, collConf.Name, m.UserCollMigrations(*collConf),
+		storage.MigrateOptions{Target: storage.LatestVersion})
+	assert.NoError(t, err)
+
+	version, err := usersSess.RawQuery("select version from versions where coll_name = ?;", "users")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, version)
+}
@@ -0,0 +1,12 @@
+package sqlite
+
+import "gouth/storage"
+
+func (s *ConnSession) RelInfo() map[storage.CollPair]storage.RelInfo {
+	return s.relInfo
+}
+
+// Read isn't supported, as the bundled sqlite is built without json functions
+func (s *ConnSession) Read(string, ...interface{}) (storage.JSONCollResult, error) {
+	return nil, storage.ErrReadUnsupported
+}
@@ -0,0 +1,27 @@
+package sqlite
+
+import "gouth/storage"
+
+// RawExec executes the given sql query with no returning results
+func (s *ConnSession) RawExec(sql string, args ...interface{}) error {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, sql, args...)
+	return err
+}
+
+// RawQuery executes the given sql query and returns results
+func (s *ConnSession) RawQuery(sql string, args ...interface{}) (storage.JSONCollResult, error) {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	var res interface{}
+
+	err := s.db.QueryRowContext(ctx, sql, args...).Scan(&res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
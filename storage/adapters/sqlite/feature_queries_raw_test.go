@@ -0,0 +1,56 @@
+package sqlite
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"gouth/storage"
+	"testing"
+)
+
+func Test_Session_RawExec(t *testing.T) {
+	usersSess := createUsersSess(t)
+	defer usersSess.Close()
+
+	err := usersSess.RawExec("create table test (id integer primary key);")
+	assert.NoError(t, err)
+
+	err = usersSess.RawExec("drop table test;")
+	assert.NoError(t, err)
+}
+
+func Test_Session_RawQuery(t *testing.T) {
+	usersSess := createUsersSess(t)
+	defer usersSess.Close()
+
+	res, err := usersSess.RawQuery("select 1;")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, res)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = usersSess.WithContext(ctx).RawQuery("select 1;")
+	assert.Error(t, err)
+}
+
+func Test_Session_InMemory(t *testing.T) {
+	rawConnData := storage.RawStorageConfig{
+		"connection_config": map[string]interface{}{
+			"adapter": "sqlite",
+			"path":    MemoryPath,
+		},
+	}
+
+	usersSess, err := storage.Open(rawConnData, []string{"users", "sessions"})
+	if err != nil {
+		t.Fatalf("open connection by config: %v", err)
+	}
+	defer usersSess.Close()
+
+	collConf := storage.NewUserCollConfig("users", "id", "username", "password")
+	assert.NoError(t, usersSess.CreateUserColl(*collConf))
+
+	// all queries use the same connection, so the table is still there
+	isExist, err := usersSess.IsCollExists(collConf.ToCollConfig())
+	assert.NoError(t, err)
+	assert.True(t, isExist)
+}
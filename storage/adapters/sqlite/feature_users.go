@@ -0,0 +1,149 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"gouth/storage"
+	"strings"
+)
+
+// IsCollExists checks whether the given collection exists
+func (s *ConnSession) IsCollExists(collConf storage.CollConfig) (bool, error) {
+	sql := "select exists (select 1 from sqlite_master where type = 'table' and name = ?);"
+	res, err := s.RawQuery(sql, collConf.Name)
+	if err != nil {
+		return false, err
+	}
+
+	return res.(int64) == 1, nil
+}
+
+// CreateUserColl creates user collection with traits passed by UserCollectionConfig
+func (s *ConnSession) CreateUserColl(collConf storage.UserCollConfig) error {
+	sql := fmt.Sprintf("create table %s %s;", Sanitize(collConf.Name), userCollColumns(collConf))
+	return s.RawExec(sql)
+}
+
+// userCollColumns returns definition of the user collection columns
+func userCollColumns(collConf storage.UserCollConfig) string {
+	pkType := pkDataType(collConf) + " primary key"
+	if !collConf.IsPkGenerated() {
+		pkType += " autoincrement"
+	}
+
+	return fmt.Sprintf(`(%s %s,
+                       %s text not null unique,
+                       %s text not null)`,
+		Sanitize(collConf.Pk),
+		pkType,
+		Sanitize(collConf.UserUnique),
+		Sanitize(collConf.UserConfirm))
+}
+
+// pkDataType returns type of the pk column. Keys generated by the app are text
+func pkDataType(collConf storage.UserCollConfig) string {
+	if collConf.IsPkGenerated() {
+		return "text"
+	}
+	return "integer"
+}
+
+// CheckPkType returns an error, if the pk column of the user collection doesn't fit the configured pk type
+func (s *ConnSession) CheckPkType(collConf storage.UserCollConfig) error {
+	sql := "select type from pragma_table_info(?) where name = ?;"
+	res, err := s.RawQuery(sql, collConf.Name, collConf.Pk)
+	if err != nil {
+		return err
+	}
+
+	columnType := fmt.Sprintf("%s", res)
+	if !strings.EqualFold(columnType, pkDataType(collConf)) {
+		return storage.NewPkTypeError(collConf, columnType)
+	}
+	return nil
+}
+
+// InsertUser inserts user entity in the user collection and returns its pk.
+// SQLite can't generate uuid and ulid, so they are generated by the app
+func (s *ConnSession) InsertUser(collConf storage.UserCollConfig, insUserData storage.InsertUserData) (storage.JSONCollResult, error) {
+	ctx, cancel := s.queryCtx()
+	defer cancel()
+
+	if collConf.IsPkGenerated() {
+		pk, err := storage.NewPk(collConf.PkType)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = s.db.ExecContext(ctx, s.userQueriesFor(collConf).insert, insUserData.UserUnique, insUserData.UserConfirm, pk)
+		if err != nil {
+			return nil, err
+		}
+		return pk, nil
+	}
+
+	res, err := s.db.ExecContext(ctx, s.userQueriesFor(collConf).insert, insUserData.UserUnique, insUserData.UserConfirm)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.LastInsertId()
+}
+
+// GetUserPassword returns password hash of the user with the given user unique
+func (s *ConnSession) GetUserPassword(collConf storage.UserCollConfig, userUnique interface{}) (storage.JSONCollResult, error) {
+	pw, err := s.RawQuery(s.userQueriesFor(collConf).getPassword, userUnique)
+	if err == sql.ErrNoRows {
+		return nil, storage.ErrUserNotFound
+	}
+	return pw, err
+}
+
+// UpdateUserPassword replaces password hash of the user with the given user unique
+func (s *ConnSession) UpdateUserPassword(collConf storage.UserCollConfig, userUnique interface{}, pwHash string) error {
+	return s.RawExec(s.userQueriesFor(collConf).updatePassword, pwHash, userUnique)
+}
+
+// userQueries represents sql of the queries to the user collection
+type userQueries struct {
+	insert         string
+	getPassword    string
+	updatePassword string
+}
+
+// userQueriesFor returns sql of the queries to the user collection. It's built once per collection
+func (s *ConnSession) userQueriesFor(collConf storage.UserCollConfig) userQueries {
+	if q, ok := s.userQueries.Load(collConf); ok {
+		return q.(userQueries)
+	}
+
+	q := userQueries{
+		insert: fmt.Sprintf("insert into %s (%s, %s) values (?, ?);",
+			Sanitize(collConf.Name),
+			Sanitize(collConf.UserUnique),
+			Sanitize(collConf.UserConfirm)),
+		getPassword: fmt.Sprintf("select %s from %s where %s=?;",
+			Sanitize(collConf.UserConfirm),
+			Sanitize(collConf.Name),
+			Sanitize(collConf.UserUnique)),
+		updatePassword: fmt.Sprintf("update %s set %s=? where %s=?;",
+			Sanitize(collConf.Name),
+			Sanitize(collConf.UserConfirm),
+			Sanitize(collConf.UserUnique)),
+	}
+	if collConf.IsPkGenerated() {
+		q.insert = fmt.Sprintf("insert into %s (%s, %s, %s) values (?, ?, ?);",
+			Sanitize(collConf.Name),
+			Sanitize(collConf.UserUnique),
+			Sanitize(collConf.UserConfirm),
+			Sanitize(collConf.Pk))
+	}
+	s.userQueries.Store(collConf, q)
+
+	return q
+}
+
+// Sanitize quotes the identifier, so it can be safely used in sql
+func Sanitize(ident string) string {
+	return `"` + strings.ReplaceAll(ident, `"`, `""`) + `"`
+}
@@ -0,0 +1,117 @@
+package sqlite
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gouth/storage"
+	"path/filepath"
+	"testing"
+)
+
+func createUsersSess(t *testing.T) storage.ConnSession {
+	rawConnData := storage.RawStorageConfig{
+		"connection_url": "sqlite://" + filepath.Join(t.TempDir(), "test.db"),
+	}
+
+	features := []string{"users"}
+	usersSess, err := storage.Open(rawConnData, features)
+	if err != nil {
+		t.Fatalf("open connection by url: %v", err)
+	}
+
+	return usersSess
+}
+
+func Test_Session_IsCollExists(t *testing.T) {
+	usersSess := createUsersSess(t)
+	defer usersSess.Close()
+
+	collConf := storage.NewUserCollConfig("users", "id", "username", "password")
+
+	isExist, err := usersSess.IsCollExists(collConf.ToCollConfig())
+	assert.NoError(t, err)
+	assert.False(t, isExist)
+
+	assert.NoError(t, usersSess.CreateUserColl(*collConf))
+
+	isExist, err = usersSess.IsCollExists(collConf.ToCollConfig())
+	assert.NoError(t, err)
+	assert.True(t, isExist)
+}
+
+func Test_Session_InsertUser(t *testing.T) {
+	usersSess := createUsersSess(t)
+	defer usersSess.Close()
+
+	collConf := storage.NewUserCollConfig("users", "id", "username", "password")
+	assert.NoError(t, usersSess.CreateUserColl(*collConf))
+
+	id, err := usersSess.InsertUser(*collConf, *storage.NewInsertUserData("john", "hash"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, id)
+
+	id, err = usersSess.InsertUser(*collConf, *storage.NewInsertUserData("jane", "hash"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, id)
+
+	_, err = usersSess.InsertUser(*collConf, *storage.NewInsertUserData("john", "other hash"))
+	assert.Error(t, err)
+
+	pw, err := usersSess.GetUserPassword(*collConf, "john")
+	assert.NoError(t, err)
+	assert.Equal(t, "hash", pw)
+
+	_, err = usersSess.GetUserPassword(*collConf, "nobody")
+	assert.Equal(t, storage.ErrUserNotFound, err)
+}
+
+func Test_Session_InsertUser_GeneratedPk(t *testing.T) {
+	usersSess := createUsersSess(t)
+	defer usersSess.Close()
+
+	collConf := storage.NewUserCollConfig("users", "id", "username", "password")
+	collConf.PkType = storage.PkULID
+	assert.NoError(t, usersSess.CreateUserColl(*collConf))
+
+	id, err := usersSess.InsertUser(*collConf, *storage.NewInsertUserData("john", "hash"))
+	assert.NoError(t, err)
+	assert.Len(t, id, 26)
+
+	pk, err := usersSess.RawQuery("select id from users where username = ?;", "john")
+	assert.NoError(t, err)
+	assert.EqualValues(t, id, pk)
+}
+
+func Test_Session_CheckPkType(t *testing.T) {
+	usersSess := createUsersSess(t)
+	defer usersSess.Close()
+
+	collConf := storage.NewUserCollConfig("users", "id", "username", "password")
+	assert.NoError(t, usersSess.CreateUserColl(*collConf))
+
+	checker := usersSess.(storage.PkTypeChecker)
+	assert.NoError(t, checker.CheckPkType(*collConf))
+
+	collConf.PkType = storage.PkULID
+	assert.Error(t, checker.CheckPkType(*collConf))
+
+	collConf.Name = "ulid_users"
+	assert.NoError(t, usersSess.CreateUserColl(*collConf))
+	assert.NoError(t, checker.CheckPkType(*collConf))
+}
+
+func Test_Session_UpdateUserPassword(t *testing.T) {
+	usersSess := createUsersSess(t)
+	defer usersSess.Close()
+
+	collConf := storage.NewUserCollConfig("users", "id", "username", "password")
+	assert.NoError(t, usersSess.CreateUserColl(*collConf))
+
+	_, err := usersSess.InsertUser(*collConf, *storage.NewInsertUserData("john", "old hash"))
+	assert.NoError(t, err)
+
+	assert.NoError(t, usersSess.UpdateUserPassword(*collConf, "john", "new hash"))
+
+	pw, err := usersSess.GetUserPassword(*collConf, "john")
+	assert.NoError(t, err)
+	assert.Equal(t, "new hash", pw)
+}
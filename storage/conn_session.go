@@ -1,9 +1,21 @@
 package storage
 
+import (
+	"context"
+	"time"
+)
+
 // ConnSession is an interface that defines methods for database session
 type ConnSession interface {
 	Application
 
+	// WithContext returns a copy of the session, which runs queries with the given context.
+	// The default query timeout applies to each query on top of it
+	WithContext(context.Context) ConnSession
+
+	// SetQueryTimeout sets the default time limit of one query. 0 means no limit
+	SetQueryTimeout(time.Duration)
+
 	// ConnConfig returns the connection config that was used to set up the adapter
 	GetConfig() ConnConfig
 
@@ -19,8 +31,8 @@ type ConnSession interface {
 	// RawQuery executes the given sql query and returns results
 	RawQuery(string, ...interface{}) (JSONCollResult, error)
 
-	// Read
-	Read(string) (JSONCollResult, error)
+	// Read executes the abstract query with the given arguments and returns results
+	Read(string, ...interface{}) (JSONCollResult, error)
 
 	// Close terminates the currently active connection to the DBMS
 	Close() error
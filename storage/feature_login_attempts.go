@@ -0,0 +1,33 @@
+package storage
+
+import "time"
+
+// LoginAttempt represents failed login attempts made for one key (account or source ip)
+type LoginAttempt struct {
+	Key         string
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
+
+type LoginAttempts interface {
+	// CreateLoginAttemptColl creates collection for the failed login attempts
+	CreateLoginAttemptColl(CollConfig) error
+
+	// GetLoginAttempt returns failed login attempts for the key, or nil if there are none
+	GetLoginAttempt(CollConfig, string) (*LoginAttempt, error)
+
+	// RegisterLoginFailure increments failures counter for the key.
+	// The counter starts over, if the last failure is older than the given window
+	RegisterLoginFailure(CollConfig, string, time.Duration) (*LoginAttempt, error)
+
+	// ForgetLoginFailure decrements failures counter for the key.
+	// Failures are counted before the credentials are checked, so the successful attempt is forgotten
+	ForgetLoginFailure(CollConfig, string) error
+
+	// LockLogin forbids login attempts for the key until the given time
+	LockLogin(CollConfig, string, time.Time) error
+
+	// ResetLoginAttempts removes failed login attempts and lock for the key
+	ResetLoginAttempts(CollConfig, string) error
+}
@@ -0,0 +1,52 @@
+package storage
+
+import "time"
+
+// RecoveryCode represents one hashed single-use recovery code of the user.
+// Lookup is the not secret part of the code, so only the matching code is compared with the slow hash
+type RecoveryCode struct {
+	Id     interface{}
+	Lookup string
+	Hash   string
+	Used   bool
+}
+
+// TrustedDevice represents browser, where user has passed MFA and asked to be remembered
+type TrustedDevice struct {
+	Id         string
+	UserUnique interface{}
+	Name       string
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+}
+
+type MFA interface {
+	// CreateRecoveryCodesColl creates collection for the hashed recovery codes
+	CreateRecoveryCodesColl(CollConfig) error
+
+	// SetRecoveryCodes replaces all recovery codes of the user with the given ones, their lookups and hashes are stored
+	SetRecoveryCodes(CollConfig, interface{}, []RecoveryCode) error
+
+	// GetRecoveryCodes returns all recovery codes of the user, including used ones
+	GetRecoveryCodes(CollConfig, interface{}) ([]RecoveryCode, error)
+
+	// UseRecoveryCode marks recovery code with the given id as used.
+	// It returns false if the code has already been used
+	UseRecoveryCode(CollConfig, interface{}) (bool, error)
+
+	// CreateTrustedDeviceColl creates collection for the trusted devices
+	CreateTrustedDeviceColl(CollConfig) error
+
+	// InsertTrustedDevice inserts trusted device in the device collection
+	InsertTrustedDevice(CollConfig, TrustedDevice) error
+
+	// GetTrustedDevice returns unexpired trusted device by its id, or nil if it doesn't exist
+	GetTrustedDevice(CollConfig, string) (*TrustedDevice, error)
+
+	// GetTrustedDevices returns all unexpired trusted devices of the user
+	GetTrustedDevices(CollConfig, interface{}) ([]TrustedDevice, error)
+
+	// DeleteTrustedDevice revokes trusted device of the user.
+	// It returns false if there is no such device
+	DeleteTrustedDevice(CollConfig, interface{}, string) (bool, error)
+}
@@ -0,0 +1,44 @@
+package storage
+
+// Kinds of the collections managed by the app besides the user collection
+const (
+	RecoveryCodesColl      = "recovery_codes"
+	TrustedDevicesColl     = "trusted_devices"
+	LoginAttemptsColl      = "login_attempts"
+	RateLimitsColl         = "rate_limits"
+	WebAuthnCredsColl      = "webauthn_credentials"
+	WebAuthnChallengesColl = "webauthn_challenges"
+)
+
+// Migration represents one numbered change of the collections managed by the app.
+// Up statements apply the change and Down statements revert it.
+// Migration without Down statements can't be reverted, e.g. it takes over the existing collection
+type Migration struct {
+	Version int
+	Name    string
+	Up      []string
+	Down    []string
+}
+
+// SchemaTx represents changes of the schema made under the schema lock
+type SchemaTx interface {
+	// SchemaVersion returns the applied version of the scope, 0 if nothing is applied yet
+	SchemaVersion() (int, error)
+
+	// ApplyMigration runs the statements and saves the new version of the scope.
+	// The schema version collection is created on the first call
+	ApplyMigration([]string, int) error
+}
+
+type Migrator interface {
+	// UserCollMigrations returns migrations of the user collection ordered by version
+	UserCollMigrations(UserCollConfig) []Migration
+
+	// CollMigrations returns migrations of the collection of the given kind ordered by version,
+	// or nil if the adapter doesn't keep collections of the kind
+	CollMigrations(string, CollConfig) []Migration
+
+	// LockSchema calls the function holding the lock of the scope in the schema version collection,
+	// so concurrent instances don't apply the same migrations
+	LockSchema(CollConfig, string, func(SchemaTx) error) error
+}
@@ -1,14 +1,25 @@
 package storage
 
+import "errors"
+
+// ErrReadUnsupported is returned by Read of the adapters, which can't run abstract queries
+var ErrReadUnsupported = errors.New("storage: abstract queries aren't supported by the adapter")
+
+// CollPair represents the direction of relationship from one collection to another
 type CollPair struct {
-	from, to string
+	From, To string
 }
 
 type RelInfo struct {
-	// isO2M says about relationship between tables. One-to-Many or Many-to-One
-	isO2M      bool
-	fromFields []string
-	toFields   []string
+	// IsO2M says about relationship between tables. One-to-Many or Many-to-One
+	IsO2M      bool
+	FromFields []string
+	ToFields   []string
 }
 
 type JSONCollResult = interface{}
+
+type RelInfoRefresher interface {
+	// RefreshRelInfo discovers relations of the collections again, e.g. after the schema is changed
+	RefreshRelInfo() error
+}
@@ -0,0 +1,11 @@
+package storage
+
+type RateLimits interface {
+	// CreateRateLimitColl creates collection for the token buckets
+	CreateRateLimitColl(CollConfig) error
+
+	// TakeRateLimitToken atomically refills the bucket with the given capacity and refill rate
+	// (tokens per second) and takes one token from it.
+	// It returns the number of tokens left and whether the token was taken
+	TakeRateLimitToken(CollConfig, string, float64, float64) (float64, bool, error)
+}
@@ -1,5 +1,10 @@
 package storage
 
+import "errors"
+
+// ErrUserNotFound is returned by GetUserPassword, if there is no user with the given user unique
+var ErrUserNotFound = errors.New("storage: user isn't found")
+
 type CollConfig struct {
 	Name string `yaml:"name"`
 	Pk   string `yaml:"pk,omitempty"`
@@ -9,6 +14,7 @@ type UserCollConfig struct {
 	StorageName string `yaml:"storage"`
 	Name        string `yaml:"name"`
 	Pk          string `yaml:"pk,omitempty"`
+	PkType      string `yaml:"pk_type,omitempty"`
 	UserUnique  string `yaml:"user_unique"`
 	UserConfirm string `yaml:"user_confirm"`
 }
@@ -25,10 +31,14 @@ type Application interface {
 	// CreateUserColl creates user collection with traits passed by UserCollectionConfig
 	CreateUserColl(UserCollConfig) error
 
-	// InsertUser inserts user entity in the user collection
+	// InsertUser inserts user entity in the user collection and returns its pk
 	InsertUser(UserCollConfig, InsertUserData) (JSONCollResult, error)
 
+	// GetUserPassword returns password hash of the user with the given user unique, or ErrUserNotFound
 	GetUserPassword(UserCollConfig, interface{}) (JSONCollResult, error)
+
+	// UpdateUserPassword replaces password hash of the user with the given user unique
+	UpdateUserPassword(UserCollConfig, interface{}, string) error
 }
 
 func NewCollConfig(name string, pk string) *CollConfig {
@@ -0,0 +1,39 @@
+package storage
+
+import "time"
+
+// WebAuthnCredential represents public key credential registered by the user
+type WebAuthnCredential struct {
+	Id         []byte
+	UserUnique interface{}
+	PublicKey  []byte
+	SignCount  uint32
+	Transports []string
+}
+
+type WebAuthn interface {
+	// CreateWebAuthnCredColl creates collection for the public key credentials
+	CreateWebAuthnCredColl(CollConfig) error
+
+	// InsertWebAuthnCred inserts public key credential in the credential collection
+	InsertWebAuthnCred(CollConfig, WebAuthnCredential) error
+
+	// GetWebAuthnCreds returns all public key credentials of the user
+	GetWebAuthnCreds(CollConfig, interface{}) ([]WebAuthnCredential, error)
+
+	// GetWebAuthnCred returns public key credential by its id, or nil if it doesn't exist
+	GetWebAuthnCred(CollConfig, []byte) (*WebAuthnCredential, error)
+
+	// UpdateWebAuthnSignCount saves the last signature counter of the credential
+	UpdateWebAuthnSignCount(CollConfig, []byte, uint32) error
+
+	// CreateWebAuthnChallengeColl creates collection for the states of unfinished ceremonies
+	CreateWebAuthnChallengeColl(CollConfig) error
+
+	// InsertWebAuthnChallenge saves the state of the ceremony until it expires
+	InsertWebAuthnChallenge(CollConfig, string, string, time.Time) error
+
+	// PopWebAuthnChallenge returns the state of the ceremony and removes it,
+	// so it can't be used twice. It returns empty string if there is no such unexpired ceremony
+	PopWebAuthnChallenge(CollConfig, string) (string, error)
+}
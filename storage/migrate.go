@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// LatestVersion is the migration target, which means the last known migration
+const LatestVersion = -1
+
+// MigrateOptions represents settings of one migration run
+type MigrateOptions struct {
+	// Target is the version to migrate up or down to
+	Target int
+	// DryRun prints the statements to Out instead of running them
+	DryRun bool
+	Out    io.Writer
+}
+
+// Migrate brings the scope from its applied version to the target version, holding the schema lock.
+// Migrations must be numbered from 1 without gaps. Nothing is reverted, if any migration on the way down
+// can't be reverted. It returns the versions before and after the run,
+// in dry run the latter is the version, which would be applied
+func Migrate(m Migrator, versionColl CollConfig, scope string, migrations []Migration, opts MigrateOptions) (int, int, error) {
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			return 0, 0, fmt.Errorf("migrations: expected version %d, got %d", i+1, migration.Version)
+		}
+	}
+
+	target := opts.Target
+	if target == LatestVersion {
+		target = len(migrations)
+	}
+	if target < 0 || target > len(migrations) {
+		return 0, 0, fmt.Errorf("migrations: unknown target version %d", opts.Target)
+	}
+
+	var from, to int
+	err := m.LockSchema(versionColl, scope, func(tx SchemaTx) error {
+		current, err := tx.SchemaVersion()
+		if err != nil {
+			return err
+		}
+		if current > len(migrations) {
+			return fmt.Errorf("migrations: %s has version %d, which is newer than known migrations", scope, current)
+		}
+
+		if target < current {
+			for _, migration := range migrations[target:current] {
+				if migration.Down == nil {
+					return fmt.Errorf("migrations: %d %s of %s can't be reverted", migration.Version, migration.Name, scope)
+				}
+			}
+		}
+
+		from, to = current, current
+		if opts.DryRun && current != target {
+			fmt.Fprintf(opts.Out, "-- %s: %d -> %d\n", scope, current, target)
+		}
+
+		for to != target {
+			var (
+				migration Migration
+				stmts     []string
+				version   int
+				dir       string
+			)
+			if to < target {
+				migration = migrations[to]
+				stmts, version, dir = migration.Up, migration.Version, "up"
+			} else {
+				migration = migrations[to-1]
+				stmts, version, dir = migration.Down, migration.Version-1, "down"
+			}
+
+			if opts.DryRun {
+				fmt.Fprintf(opts.Out, "-- %d %s (%s)\n", migration.Version, migration.Name, dir)
+				for _, stmt := range stmts {
+					fmt.Fprintf(opts.Out, "%s;\n", strings.TrimSuffix(strings.TrimSpace(stmt), ";"))
+				}
+			} else if err := tx.ApplyMigration(stmts, version); err != nil {
+				return fmt.Errorf("migrations: %d %s (%s): %v", migration.Version, migration.Name, dir, err)
+			}
+
+			to = version
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return from, to, nil
+}
@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// fakeMigrator keeps the applied version and records the applied statements
+type fakeMigrator struct {
+	version int
+	applied []string
+	failOn  string
+}
+
+func (m *fakeMigrator) UserCollMigrations(UserCollConfig) []Migration {
+	return nil
+}
+
+func (m *fakeMigrator) CollMigrations(string, CollConfig) []Migration {
+	return nil
+}
+
+func (m *fakeMigrator) LockSchema(_ CollConfig, _ string, f func(SchemaTx) error) error {
+	return f(m)
+}
+
+func (m *fakeMigrator) SchemaVersion() (int, error) {
+	return m.version, nil
+}
+
+func (m *fakeMigrator) ApplyMigration(stmts []string, version int) error {
+	for _, stmt := range stmts {
+		if stmt == m.failOn {
+			return errors.New("failed")
+		}
+		m.applied = append(m.applied, stmt)
+	}
+	m.version = version
+	return nil
+}
+
+func Test_Migrate(t *testing.T) {
+	migrations := []Migration{
+		{Version: 1, Name: "first", Up: []string{"up 1"}, Down: []string{"down 1"}},
+		{Version: 2, Name: "second", Up: []string{"up 2"}, Down: []string{"down 2"}},
+		{Version: 3, Name: "third", Up: []string{"up 3;"}, Down: []string{"down 3"}},
+	}
+	versionColl := CollConfig{Name: "schema_migrations"}
+
+	tests := []struct {
+		name    string
+		version int
+		target  int
+		failOn  string
+		wantTo  int
+		applied []string
+		wantErr bool
+	}{
+		{name: "up to latest", version: 0, target: LatestVersion, wantTo: 3, applied: []string{"up 1", "up 2", "up 3;"}},
+		{name: "up from applied", version: 1, target: 2, wantTo: 2, applied: []string{"up 2"}},
+		{name: "down", version: 3, target: 1, wantTo: 1, applied: []string{"down 3", "down 2"}},
+		{name: "nothing to apply", version: 2, target: 2, wantTo: 2},
+		{name: "stops on error", version: 0, target: LatestVersion, failOn: "up 2", wantErr: true, applied: []string{"up 1"}},
+		{name: "unknown target", version: 0, target: 4, wantErr: true},
+		{name: "newer version", version: 5, target: LatestVersion, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &fakeMigrator{version: tt.version, failOn: tt.failOn}
+			from, to, err := Migrate(m, versionColl, "users", migrations, MigrateOptions{Target: tt.target})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Migrate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.Equal(t, tt.applied, m.applied)
+			if !tt.wantErr {
+				assert.Equal(t, tt.version, from)
+				assert.Equal(t, tt.wantTo, to)
+				assert.Equal(t, tt.wantTo, m.version)
+			}
+		})
+	}
+}
+
+func Test_Migrate_DryRun(t *testing.T) {
+	migrations := []Migration{
+		{Version: 1, Name: "first", Up: []string{"up 1"}, Down: []string{"down 1"}},
+		{Version: 2, Name: "second", Up: []string{"up 2;"}, Down: []string{"down 2"}},
+	}
+
+	m := &fakeMigrator{}
+	out := &bytes.Buffer{}
+	from, to, err := Migrate(m, CollConfig{Name: "schema_migrations"}, "users", migrations,
+		MigrateOptions{Target: LatestVersion, DryRun: true, Out: out})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, from)
+	assert.Equal(t, 2, to)
+	assert.Empty(t, m.applied)
+	assert.Equal(t, 0, m.version)
+	assert.Equal(t, "-- users: 0 -> 2\n-- 1 first (up)\nup 1;\n-- 2 second (up)\nup 2;\n", out.String())
+
+	_, _, err = Migrate(m, CollConfig{}, "users", []Migration{{Version: 2}}, MigrateOptions{Target: LatestVersion})
+	assert.Error(t, err)
+}
+
+func Test_Migrate_Irreversible(t *testing.T) {
+	migrations := []Migration{
+		{Version: 1, Name: "create", Up: []string{"up 1"}},
+		{Version: 2, Name: "alter", Up: []string{"up 2"}, Down: []string{"down 2"}},
+	}
+
+	m := &fakeMigrator{version: 2}
+	_, _, err := Migrate(m, CollConfig{Name: "schema_migrations"}, "users", migrations, MigrateOptions{Target: 0})
+	assert.EqualError(t, err, "migrations: 1 create of users can't be reverted")
+	assert.Empty(t, m.applied)
+	assert.Equal(t, 2, m.version)
+
+	_, to, err := Migrate(m, CollConfig{Name: "schema_migrations"}, "users", migrations, MigrateOptions{Target: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, to)
+}
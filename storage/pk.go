@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// Types of the primary key of the user collection. Serial is used when the type isn't set
+const (
+	PkSerial         = "serial"
+	PkBigintIdentity = "bigint_identity"
+	PkUUIDv4         = "uuid_v4"
+	PkUUIDv7         = "uuid_v7"
+	PkULID           = "ulid"
+)
+
+// crockford is the alphabet of ULID, it excludes I, L, O and U
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ValidatePkType checks whether the given primary key type is known
+func ValidatePkType(pkType string) error {
+	switch pkType {
+	case "", PkSerial, PkBigintIdentity, PkUUIDv4, PkUUIDv7, PkULID:
+		return nil
+	}
+	return fmt.Errorf("unknown pk type %q", pkType)
+}
+
+// PkTypeChecker is implemented by the adapters, which can inspect the pk column of the existing user collection
+type PkTypeChecker interface {
+	// CheckPkType returns an error, if the pk column of the user collection doesn't fit the configured pk type.
+	// The first migration doesn't change the existing collection, so the pk type can't be changed by the config
+	CheckPkType(UserCollConfig) error
+}
+
+// NewPkTypeError returns error of the pk column, which has the given type instead of the one needed by the config
+func NewPkTypeError(collConf UserCollConfig, columnType string) error {
+	pkType := collConf.PkType
+	if pkType == "" {
+		pkType = PkSerial
+	}
+	return fmt.Errorf("user collection %s: pk column is %s, which doesn't fit pk type %s", collConf.Name, columnType, pkType)
+}
+
+// IsPkGenerated checks whether the pk is a string, which can be generated on the app side.
+// Pks of the other types are sequences of the database
+func (conf UserCollConfig) IsPkGenerated() bool {
+	switch conf.PkType {
+	case PkUUIDv4, PkUUIDv7, PkULID:
+		return true
+	}
+	return false
+}
+
+// NewPk generates the string primary key of the given type
+func NewPk(pkType string) (string, error) {
+	switch pkType {
+	case PkUUIDv4:
+		return newUUIDv4()
+	case PkUUIDv7:
+		return newUUIDv7(time.Now())
+	case PkULID:
+		return newULID(time.Now())
+	}
+	return "", fmt.Errorf("pk of type %q can't be generated", pkType)
+}
+
+// newUUIDv4 returns random UUID, see RFC 9562
+func newUUIDv4() (string, error) {
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		return "", err
+	}
+
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return formatUUID(u), nil
+}
+
+// newUUIDv7 returns UUID, which starts with milliseconds of the given time,
+// so keys inserted later are sorted after the earlier ones. See RFC 9562
+func newUUIDv7(t time.Time) (string, error) {
+	var u [16]byte
+	if _, err := rand.Read(u[6:]); err != nil {
+		return "", err
+	}
+
+	putMillis(u[:6], t)
+	u[6] = u[6]&0x0f | 0x70
+	u[8] = u[8]&0x3f | 0x80
+	return formatUUID(u), nil
+}
+
+// newULID returns ULID, 48 bits of milliseconds of the given time and 80 random bits
+// encoded in 26 characters of Crockford's base32
+func newULID(t time.Time) (string, error) {
+	var u [16]byte
+	if _, err := rand.Read(u[6:]); err != nil {
+		return "", err
+	}
+	putMillis(u[:6], t)
+
+	// 26 characters hold 130 bits, so the first one takes 2 leading zero bits
+	var s [26]byte
+	for i := range s {
+		var v byte
+		for j := 0; j < 5; j++ {
+			if b := i*5 - 2 + j; b >= 0 && u[b/8]&(0x80>>uint(b%8)) != 0 {
+				v |= 0x10 >> uint(j)
+			}
+		}
+		s[i] = crockford[v]
+	}
+
+	return string(s[:]), nil
+}
+
+// putMillis writes unix milliseconds of the given time in 6 bytes big-endian
+func putMillis(b []byte, t time.Time) {
+	ms := uint64(t.UnixNano() / int64(time.Millisecond))
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+}
+
+func formatUUID(u [16]byte) string {
+	s := hex.EncodeToString(u[:])
+	return s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func Test_NewPk(t *testing.T) {
+	uuidV4 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	uuidV7 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	ulid := regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+
+	pk, err := NewPk(PkUUIDv4)
+	assert.NoError(t, err)
+	assert.Regexp(t, uuidV4, pk)
+
+	pk, err = NewPk(PkUUIDv7)
+	assert.NoError(t, err)
+	assert.Regexp(t, uuidV7, pk)
+
+	pk, err = NewPk(PkULID)
+	assert.NoError(t, err)
+	assert.Regexp(t, ulid, pk)
+
+	_, err = NewPk(PkSerial)
+	assert.Error(t, err)
+}
+
+func Test_newUUIDv7(t *testing.T) {
+	ts := time.Unix(0, 0x017f22e279b0*int64(time.Millisecond))
+
+	pk, err := newUUIDv7(ts)
+	assert.NoError(t, err)
+	assert.Equal(t, "017f22e2-79b0-7", pk[:15])
+
+	later, err := newUUIDv7(ts.Add(time.Millisecond))
+	assert.NoError(t, err)
+	assert.True(t, pk < later)
+}
+
+func Test_newULID(t *testing.T) {
+	// example of the ULID spec
+	ts := time.Unix(0, 1469918176385*int64(time.Millisecond))
+
+	pk, err := newULID(ts)
+	assert.NoError(t, err)
+	assert.Equal(t, "01ARYZ6S41", pk[:10])
+
+	later, err := newULID(ts.Add(time.Millisecond))
+	assert.NoError(t, err)
+	assert.True(t, pk < later)
+}
+
+func Test_ValidatePkType(t *testing.T) {
+	for _, pkType := range []string{"", PkSerial, PkBigintIdentity, PkUUIDv4, PkUUIDv7, PkULID} {
+		assert.NoError(t, ValidatePkType(pkType))
+	}
+	assert.Error(t, ValidatePkType("uuid"))
+}
@@ -0,0 +1,290 @@
+package storage
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Query represents an abstract read query of the collection. It looks like this:
+//
+//	users(id = $1) { username, email, orgs { name }, posts(published = true) { id, content } }
+//
+// Nested collections are joined along the known relations, so each user gets the object
+// of its organization (many-to-one) and the array of its posts (one-to-many).
+// Filters are combined with "and", values are 'strings', numbers, true, false, null
+// and $n parameters, which refer to the arguments of Read
+type Query struct {
+	Coll    string
+	Fields  []string
+	Filters []Filter
+	Rels    []*Query
+}
+
+// Filter represents condition on the field of the collection.
+// Op is one of =, !=, <, <=, >, >=
+type Filter struct {
+	Field string
+	Op    string
+	Value interface{}
+}
+
+// Param represents positional argument of the query, $1 is Param(1)
+type Param int
+
+// ParseQuery parses the abstract query
+func ParseQuery(query string) (*Query, error) {
+	p := &queryParser{src: query}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	q, err := p.coll()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf("unexpected %q", p.tok.text)
+	}
+	return q, nil
+}
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokParam
+	tokOp
+	tokPunct
+)
+
+type token struct {
+	kind tokKind
+	text string
+	pos  int
+}
+
+// queryParser is the recursive descent parser of the abstract queries
+type queryParser struct {
+	src string
+	pos int
+	tok token
+}
+
+func (p *queryParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("query: %s at %d", fmt.Sprintf(format, args...), p.tok.pos)
+}
+
+// coll parses collection with optional filters and required list of fields
+func (p *queryParser) coll() (*Query, error) {
+	if p.tok.kind != tokIdent {
+		return nil, p.errorf("expected collection name")
+	}
+
+	q := &Query{Coll: p.tok.text}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	if p.tok.text == "(" {
+		filters, err := p.filters()
+		if err != nil {
+			return nil, err
+		}
+		q.Filters = filters
+	}
+
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	for {
+		if p.tok.kind != tokIdent {
+			return nil, p.errorf("expected field name")
+		}
+
+		// field is the nested collection if it's followed by filters or fields
+		name := p.tok
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+
+		if p.tok.text == "(" || p.tok.text == "{" {
+			p.tok, p.pos = name, name.pos+len(name.text)
+			rel, err := p.coll()
+			if err != nil {
+				return nil, err
+			}
+			q.Rels = append(q.Rels, rel)
+		} else {
+			q.Fields = append(q.Fields, name.text)
+		}
+
+		if p.tok.text == "}" {
+			break
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+
+	return q, p.next()
+}
+
+// filters parses list of conditions in parentheses
+func (p *queryParser) filters() ([]Filter, error) {
+	var filters []Filter
+	for {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+
+		if p.tok.kind != tokIdent {
+			return nil, p.errorf("expected field name")
+		}
+		f := Filter{Field: p.tok.text}
+
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokOp {
+			return nil, p.errorf("expected operator")
+		}
+		f.Op = p.tok.text
+
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		f.Value = value
+
+		if f.Value == nil && f.Op != "=" && f.Op != "!=" {
+			return nil, p.errorf("null can only be compared by = and !=")
+		}
+		filters = append(filters, f)
+
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.tok.text == ")" {
+			return filters, p.next()
+		}
+		if p.tok.text != "," {
+			return nil, p.errorf("expected , or )")
+		}
+	}
+}
+
+// value converts the current token into the value of the filter
+func (p *queryParser) value() (interface{}, error) {
+	switch p.tok.kind {
+	case tokString:
+		return p.tok.text, nil
+	case tokNumber:
+		if n, err := strconv.ParseInt(p.tok.text, 10, 64); err == nil {
+			return n, nil
+		}
+		return strconv.ParseFloat(p.tok.text, 64)
+	case tokParam:
+		n, err := strconv.Atoi(p.tok.text)
+		if err != nil || n < 1 {
+			return nil, p.errorf("invalid parameter $%s", p.tok.text)
+		}
+		return Param(n), nil
+	case tokIdent:
+		switch p.tok.text {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+	}
+	return nil, p.errorf("expected value")
+}
+
+// expect checks that the current token is the given punctuation and moves to the next one
+func (p *queryParser) expect(punct string) error {
+	if p.tok.kind != tokPunct || p.tok.text != punct {
+		return p.errorf("expected %s", punct)
+	}
+	return p.next()
+}
+
+// next reads the next token
+func (p *queryParser) next() error {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+
+	start := p.pos
+	p.tok = token{pos: start}
+	if p.pos == len(p.src) {
+		p.tok.kind = tokEOF
+		return nil
+	}
+
+	c := p.src[p.pos]
+	switch {
+	case c == '_' || unicode.IsLetter(rune(c)):
+		for p.pos < len(p.src) && isIdentChar(p.src[p.pos]) {
+			p.pos++
+		}
+		p.tok.kind, p.tok.text = tokIdent, p.src[start:p.pos]
+	case c == '-' || unicode.IsDigit(rune(c)):
+		p.pos++
+		for p.pos < len(p.src) && (unicode.IsDigit(rune(p.src[p.pos])) || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		p.tok.kind, p.tok.text = tokNumber, p.src[start:p.pos]
+	case c == '$':
+		p.pos++
+		for p.pos < len(p.src) && unicode.IsDigit(rune(p.src[p.pos])) {
+			p.pos++
+		}
+		p.tok.kind, p.tok.text = tokParam, p.src[start+1:p.pos]
+	case c == '\'':
+		// quotes are escaped by doubling, as in sql
+		var sb strings.Builder
+		for p.pos++; ; p.pos++ {
+			if p.pos == len(p.src) {
+				return p.errorf("unterminated string")
+			}
+			if p.src[p.pos] == '\'' {
+				if p.pos+1 < len(p.src) && p.src[p.pos+1] == '\'' {
+					p.pos++
+				} else {
+					break
+				}
+			}
+			sb.WriteByte(p.src[p.pos])
+		}
+		p.pos++
+		p.tok.kind, p.tok.text = tokString, sb.String()
+	case strings.HasPrefix(p.src[p.pos:], "!=") || strings.HasPrefix(p.src[p.pos:], "<=") || strings.HasPrefix(p.src[p.pos:], ">="):
+		p.pos += 2
+		p.tok.kind, p.tok.text = tokOp, p.src[start:p.pos]
+	case c == '=' || c == '<' || c == '>':
+		p.pos++
+		p.tok.kind, p.tok.text = tokOp, p.src[start:p.pos]
+	case strings.IndexByte("(){},", c) != -1:
+		p.pos++
+		p.tok.kind, p.tok.text = tokPunct, p.src[start:p.pos]
+	default:
+		return p.errorf("unexpected %q", c)
+	}
+	return nil
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))
+}
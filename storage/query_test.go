@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_ParseQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    *Query
+		wantErr bool
+	}{
+		{
+			name:  "fields",
+			query: "users { username, email }",
+			want:  &Query{Coll: "users", Fields: []string{"username", "email"}},
+		},
+		{
+			name:  "filters",
+			query: "users(id = $1, name != 'O''Brien', age >= 18, score < -1.5, active = true, deleted_at = null) { username }",
+			want: &Query{
+				Coll:   "users",
+				Fields: []string{"username"},
+				Filters: []Filter{
+					{Field: "id", Op: "=", Value: Param(1)},
+					{Field: "name", Op: "!=", Value: "O'Brien"},
+					{Field: "age", Op: ">=", Value: int64(18)},
+					{Field: "score", Op: "<", Value: -1.5},
+					{Field: "active", Op: "=", Value: true},
+					{Field: "deleted_at", Op: "=", Value: nil},
+				},
+			},
+		},
+		{
+			name:  "relations",
+			query: "users(id = $1) { username, orgs { name }, posts(published = true) { id, comments { text } } }",
+			want: &Query{
+				Coll:    "users",
+				Fields:  []string{"username"},
+				Filters: []Filter{{Field: "id", Op: "=", Value: Param(1)}},
+				Rels: []*Query{
+					{Coll: "orgs", Fields: []string{"name"}},
+					{
+						Coll:    "posts",
+						Fields:  []string{"id"},
+						Filters: []Filter{{Field: "published", Op: "=", Value: true}},
+						Rels:    []*Query{{Coll: "comments", Fields: []string{"text"}}},
+					},
+				},
+			},
+		},
+		{name: "missing fields", query: "users", wantErr: true},
+		{name: "empty fields", query: "users {}", wantErr: true},
+		{name: "trailing comma", query: "users { id, }", wantErr: true},
+		{name: "unclosed braces", query: "users { id", wantErr: true},
+		{name: "trailing tokens", query: "users { id } orgs", wantErr: true},
+		{name: "missing operator", query: "users(id 1) { id }", wantErr: true},
+		{name: "unterminated string", query: "users(name = 'john) { id }", wantErr: true},
+		{name: "invalid parameter", query: "users(id = $0) { id }", wantErr: true},
+		{name: "ordered null", query: "users(id > null) { id }", wantErr: true},
+		{name: "unexpected character", query: "users; { id }", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseQuery(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -3,10 +3,32 @@ package storage
 import (
 	"errors"
 	"strings"
+	"time"
 )
 
-// Open attempts to establish a connection with a database
+// Open attempts to establish a connection with a database.
+// Query timeout is set by query_timeout key in milliseconds
 func Open(data RawStorageConfig, features []string) (ConnSession, error) {
+	var timeout time.Duration
+	if rawTimeout, ok := data["query_timeout"]; ok {
+		ms, ok := rawTimeout.(int)
+		if !ok || ms < 0 {
+			return nil, errors.New("query_timeout must be a non-negative number of milliseconds")
+		}
+		timeout = time.Duration(ms) * time.Millisecond
+	}
+
+	sess, err := open(data, features)
+	if err != nil {
+		return nil, err
+	}
+
+	sess.SetQueryTimeout(timeout)
+	return sess, nil
+}
+
+// open attempts to establish a connection with a database by the connection config or url
+func open(data RawStorageConfig, features []string) (ConnSession, error) {
 	if connConf, ok := data["connection_config"].(map[string]interface{}); ok {
 		adapterName, ok := connConf["adapter"].(string)
 		if !ok {
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gouth/storage"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// listTrustedDevicesHandler returns all unexpired trusted devices of the authenticated user.
+// The recovery code passed as the second factor isn't used up, since nothing is logged in with it
+func listTrustedDevicesHandler(app AppConfig) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var authData interface{}
+
+		if err := c.BindJSON(&authData); err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusBadRequest,
+				gin.H{"error": "invalid json"})
+			return
+		}
+
+		userUnique, ok := authenticateWith(c, app, authData, mfaKeepCode)
+		if !ok {
+			return
+		}
+
+		devicesConf := app.Main.AuthN.MFA.TrustedDevices
+		mfaStorage := app.storageFor(c.Request.Context(), "mfa").(storage.MFA)
+		devices, err := mfaStorage.GetTrustedDevices(*devicesConf.Coll, userUnique)
+		if err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusInternalServerError,
+				gin.H{"error": err.Error()})
+			return
+		}
+
+		res := make([]gin.H, len(devices))
+		for i, device := range devices {
+			res[i] = gin.H{
+				"id":         device.Id,
+				"name":       device.Name,
+				"created_at": device.CreatedAt,
+				"expires_at": device.ExpiresAt,
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"devices": res})
+	}
+}
+
+// revokeTrustedDeviceHandler revokes trusted device of the authenticated user,
+// so the second factor is required on it again. The recovery code passed as the second factor isn't used up
+func revokeTrustedDeviceHandler(app AppConfig) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var authData interface{}
+
+		if err := c.BindJSON(&authData); err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusBadRequest,
+				gin.H{"error": "invalid json"})
+			return
+		}
+
+		userUnique, ok := authenticateWith(c, app, authData, mfaKeepCode)
+		if !ok {
+			return
+		}
+
+		devicesConf := app.Main.AuthN.MFA.TrustedDevices
+		mfaStorage := app.storageFor(c.Request.Context(), "mfa").(storage.MFA)
+		isDeleted, err := mfaStorage.DeleteTrustedDevice(*devicesConf.Coll, userUnique, c.Param("id"))
+		if err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusInternalServerError,
+				gin.H{"error": err.Error()})
+			return
+		}
+
+		if !isDeleted {
+			c.AbortWithStatusJSON(
+				http.StatusNotFound,
+				gin.H{"error": "device not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"id": c.Param("id")})
+	}
+}
+
+// isDeviceTrusted checks whether the request is sent from the device, that user has marked as trusted
+func isDeviceTrusted(c *gin.Context, app AppConfig, userUnique interface{}) (bool, error) {
+	devicesConf := app.Main.AuthN.MFA.TrustedDevices
+	if !devicesConf.IsEnabled {
+		return false, nil
+	}
+
+	cookie, err := c.Cookie(devicesConf.CookieName)
+	if err != nil {
+		return false, nil
+	}
+
+	id, ok := verifyDeviceCookie(devicesConf.Secret, cookie)
+	if !ok {
+		return false, nil
+	}
+
+	mfaStorage := app.storageFor(c.Request.Context(), "mfa").(storage.MFA)
+	device, err := mfaStorage.GetTrustedDevice(*devicesConf.Coll, id)
+	if err != nil || device == nil {
+		return false, err
+	}
+
+	return fmt.Sprint(device.UserUnique) == fmt.Sprint(userUnique), nil
+}
+
+// rememberDevice marks the device as trusted, if user has asked for it.
+// It stores device record and sets signed device cookie
+func rememberDevice(c *gin.Context, app AppConfig, userUnique interface{}, authData interface{}) error {
+	devicesConf := app.Main.AuthN.MFA.TrustedDevices
+	if !devicesConf.IsEnabled {
+		return nil
+	}
+
+	rawRemember, err := GetJSONPath(devicesConf.Remember, authData)
+	if err != nil {
+		return nil
+	}
+	if remember, ok := rawRemember.(bool); !ok || !remember {
+		return nil
+	}
+
+	rawId := make([]byte, 16)
+	if _, err := rand.Read(rawId); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	lifetime := time.Duration(devicesConf.Lifetime) * 24 * time.Hour
+	device := storage.TrustedDevice{
+		Id:         base64.RawURLEncoding.EncodeToString(rawId),
+		UserUnique: userUnique,
+		Name:       c.Request.UserAgent(),
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(lifetime),
+	}
+
+	mfaStorage := app.storageFor(c.Request.Context(), "mfa").(storage.MFA)
+	if err := mfaStorage.InsertTrustedDevice(*devicesConf.Coll, device); err != nil {
+		return err
+	}
+
+	cookieConf := app.Main.AuthZ.CookieConf
+	c.SetCookie(
+		devicesConf.CookieName,
+		signDeviceCookie(devicesConf.Secret, device.Id),
+		int(lifetime.Seconds()),
+		cookieConf.Path,
+		cookieConf.Domain,
+		cookieConf.IsSecure,
+		true,
+	)
+
+	return nil
+}
+
+// signDeviceCookie returns cookie value containing the device id and its signature
+func signDeviceCookie(secret, id string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(id))
+	return id + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verifyDeviceCookie checks the signature of the device cookie and returns the device id
+func verifyDeviceCookie(secret, cookie string) (string, bool) {
+	i := strings.LastIndex(cookie, ".")
+	if i == -1 {
+		return "", false
+	}
+
+	id := cookie[:i]
+	if !hmac.Equal([]byte(signDeviceCookie(secret, id)), []byte(cookie)) {
+		return "", false
+	}
+
+	return id, true
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gouth/storage"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_verifyDeviceCookie(t *testing.T) {
+	cookie := signDeviceCookie("secret", "device-1")
+
+	tests := []struct {
+		name   string
+		secret string
+		cookie string
+		want   string
+		wantOk bool
+	}{
+		{name: "valid cookie", secret: "secret", cookie: cookie, want: "device-1", wantOk: true},
+		{name: "other secret", secret: "other", cookie: cookie, want: "", wantOk: false},
+		{name: "without signature", secret: "secret", cookie: "device-1", want: "", wantOk: false},
+		{name: "tampered id", secret: "secret", cookie: "device-2" + cookie[len("device-1"):], want: "", wantOk: false},
+		{name: "empty cookie", secret: "secret", cookie: "", want: "", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := verifyDeviceCookie(tt.secret, tt.cookie)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_trustedDevicesHandlers_KeepCode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := newLockoutTestApp(t)
+
+	devicesConf := app.Main.AuthN.MFA.TrustedDevices
+	mfaStorage := app.StorageByFeature["mfa"].(storage.MFA)
+	assert.NoError(t, mfaStorage.CreateTrustedDeviceColl(*devicesConf.Coll))
+
+	r := gin.New()
+	r.POST("/mfa/trusted_devices", listTrustedDevicesHandler(app))
+	r.POST("/mfa/trusted_devices/:id/revoke", revokeTrustedDeviceHandler(app))
+
+	codes, err := issueRecoveryCodes(context.Background(), app, app.Hash.Hasher, "jane")
+	assert.NoError(t, err)
+	body := `{"name": "jane", "passwd": "secret", "recovery_code": "` + codes[0] + `"}`
+
+	for path, want := range map[string]int{
+		"/mfa/trusted_devices":                http.StatusOK,
+		"/mfa/trusted_devices/unknown/revoke": http.StatusNotFound,
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		assert.Equal(t, want, w.Code, path)
+	}
+
+	// managing the devices doesn't use up the passed code
+	stored, err := mfaStorage.GetRecoveryCodes(*app.Main.AuthN.MFA.RecoveryCodes.Coll, "jane")
+	assert.NoError(t, err)
+	for _, code := range stored {
+		assert.False(t, code.Used)
+	}
+}
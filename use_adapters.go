@@ -1,5 +1,15 @@
 package main
 
 import _ "gouth/storage/adapters/postgresql"
+import _ "gouth/storage/adapters/sqlite"
+import _ "gouth/storage/adapters/mysql"
+import _ "gouth/storage/adapters/memory"
+import _ "gouth/storage/adapters/redis"
 import _ "gouth/pwhash/adapters/argon2"
 import _ "gouth/pwhash/adapters/pbkdf2"
+import _ "gouth/pwhash/adapters/bcrypt"
+import _ "gouth/pwhash/adapters/scrypt"
+import _ "gouth/pwhash/adapters/django"
+import _ "gouth/pwhash/adapters/werkzeug"
+import _ "gouth/pwhash/adapters/aspnet"
+import _ "gouth/pwhash/adapters/phc"
@@ -0,0 +1,423 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gouth/storage"
+	"gouth/webauthn"
+	"net/http"
+)
+
+// webauthnSession is the state of the ceremony kept in the storage between its begin and finish steps
+type webauthnSession struct {
+	UserUnique interface{}          `json:"user_unique,omitempty"`
+	Data       webauthn.SessionData `json:"data"`
+}
+
+// webauthnRegisterBeginHandler starts registration of a new passkey for the authenticated user
+func webauthnRegisterBeginHandler(app AppConfig) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var authData interface{}
+
+		if err := c.BindJSON(&authData); err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusBadRequest,
+				gin.H{"error": "invalid json"})
+			return
+		}
+
+		userUnique, ok := authenticate(c, app, authData)
+		if !ok {
+			return
+		}
+
+		creds, err := getWebAuthnCreds(c.Request.Context(), app, userUnique)
+		if err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusInternalServerError,
+				gin.H{"error": err.Error()})
+			return
+		}
+
+		user := webauthn.User{
+			Handle:      webauthnUserHandle(userUnique),
+			Name:        fmt.Sprint(userUnique),
+			DisplayName: fmt.Sprint(userUnique),
+		}
+		opts, data, err := app.WebAuthn.RP.BeginRegistration(user, toWebAuthnCreds(creds))
+		if err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusInternalServerError,
+				gin.H{"error": err.Error()})
+			return
+		}
+
+		sessionId, err := saveWebAuthnSession(c.Request.Context(), app, webauthnSession{UserUnique: userUnique, Data: *data})
+		if err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusInternalServerError,
+				gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"options": opts, "session": sessionId})
+	}
+}
+
+// webauthnRegisterFinishHandler verifies the new passkey and stores it.
+// If it's the first second factor of the user, recovery codes are issued as well
+func webauthnRegisterFinishHandler(app AppConfig) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var regData interface{}
+
+		if err := c.BindJSON(&regData); err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusBadRequest,
+				gin.H{"error": "invalid json"})
+			return
+		}
+
+		sess, body, err := getWebAuthnCeremony(c.Request.Context(), app, regData)
+		if err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusInternalServerError,
+				gin.H{"error": err.Error()})
+			return
+		}
+		if sess == nil || sess.UserUnique == nil {
+			c.AbortWithStatusJSON(
+				http.StatusBadRequest,
+				gin.H{"error": "invalid webauthn session"})
+			return
+		}
+
+		cred, err := app.WebAuthn.RP.FinishRegistration(sess.Data, body)
+		if err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusBadRequest,
+				gin.H{"error": err.Error()})
+			return
+		}
+
+		var isEnrolled bool
+		if app.Main.AuthN.MFA.isEnabled() {
+			if isEnrolled, err = isMFAEnrolled(c.Request.Context(), app, sess.UserUnique); err != nil {
+				c.AbortWithStatusJSON(
+					http.StatusInternalServerError,
+					gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		webauthnStorage := app.storageFor(c.Request.Context(), "webauthn").(storage.WebAuthn)
+		err = webauthnStorage.InsertWebAuthnCred(*app.WebAuthn.CredColl, storage.WebAuthnCredential{
+			Id:         cred.ID,
+			UserUnique: sess.UserUnique,
+			PublicKey:  cred.PublicKey,
+			SignCount:  cred.SignCount,
+			Transports: cred.Transports,
+		})
+		if err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusInternalServerError,
+				gin.H{"error": err.Error()})
+			return
+		}
+
+		res := gin.H{"credential_id": base64.RawURLEncoding.EncodeToString(cred.ID)}
+
+		if app.Main.AuthN.MFA.isEnabled() && !isEnrolled {
+			h := app.hasherFor(c.Request.Context())
+
+			codes, err := issueRecoveryCodes(c.Request.Context(), app, h, sess.UserUnique)
+			if err != nil {
+				abortHashError(c, err)
+				return
+			}
+			res["recovery_codes"] = codes
+		}
+
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+// webauthnLoginBeginHandler starts passwordless login or WebAuthn second factor check.
+// If user_unique isn't passed, any discoverable passkey can be used, and the login is passwordless,
+// so user verification is required
+func webauthnLoginBeginHandler(app AppConfig) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var authData interface{}
+
+		if err := c.BindJSON(&authData); err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusBadRequest,
+				gin.H{"error": "invalid json"})
+			return
+		}
+
+		var (
+			creds []storage.WebAuthnCredential
+			sess  webauthnSession
+		)
+
+		userUnique, err := GetJSONPath(app.Main.AuthN.PasswdBased.UserUnique, authData)
+		if err == nil {
+			sess.UserUnique = userUnique
+			if creds, err = getWebAuthnCreds(c.Request.Context(), app, userUnique); err != nil {
+				c.AbortWithStatusJSON(
+					http.StatusInternalServerError,
+					gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		begin := app.WebAuthn.RP.BeginLogin
+		if sess.UserUnique == nil {
+			begin = app.WebAuthn.RP.BeginPasswordlessLogin
+		}
+
+		opts, data, err := begin(toWebAuthnCreds(creds))
+		if err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusInternalServerError,
+				gin.H{"error": err.Error()})
+			return
+		}
+		sess.Data = *data
+
+		sessionId, err := saveWebAuthnSession(c.Request.Context(), app, sess)
+		if err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusInternalServerError,
+				gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"options": opts, "session": sessionId})
+	}
+}
+
+// webauthnLoginFinishHandler authenticates user by passkey without password.
+// The passkey is the only factor, so user verification is required regardless of the configured requirement.
+// Failed assertions are counted by the lockout as failed logins of the source ip
+// and of the account, if the ceremony was started for it
+func webauthnLoginFinishHandler(app AppConfig) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var authData interface{}
+
+		if err := c.BindJSON(&authData); err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusBadRequest,
+				gin.H{"error": "invalid json"})
+			return
+		}
+
+		sess, body, err := getWebAuthnCeremony(c.Request.Context(), app, authData)
+		if err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusInternalServerError,
+				gin.H{"error": err.Error()})
+			return
+		}
+
+		var userUnique interface{}
+		if sess != nil {
+			userUnique = sess.UserUnique
+		}
+
+		failures, ok := reserveLoginAttempt(c, app, userUnique)
+		if !ok {
+			return
+		}
+
+		if sess == nil {
+			abortLoginFailure(c, app, userUnique, failures)
+			return
+		}
+
+		sess.Data.UserVerification = webauthn.VerificationRequired
+		cred, isValid, err := verifyWebAuthnLogin(c.Request.Context(), app, sess, body)
+		if err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusInternalServerError,
+				gin.H{"error": err.Error()})
+			return
+		}
+
+		if !isValid {
+			abortLoginFailure(c, app, userUnique, failures)
+			return
+		}
+
+		if err := resetLoginAttempts(c, app, cred.UserUnique); err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusInternalServerError,
+				gin.H{"error": err.Error()})
+			return
+		}
+
+		token := issueToken(nil)
+		c.JSON(http.StatusOK, gin.H{"token": token})
+	}
+}
+
+// finishWebAuthnLogin verifies WebAuthn assertion passed with the authentication data
+// and returns the used credential. It returns false if verification fails
+func finishWebAuthnLogin(ctx context.Context, app AppConfig, authData interface{}) (*storage.WebAuthnCredential, bool, error) {
+	sess, body, err := getWebAuthnCeremony(ctx, app, authData)
+	if err != nil {
+		return nil, false, err
+	}
+	if sess == nil {
+		return nil, false, nil
+	}
+
+	return verifyWebAuthnLogin(ctx, app, sess, body)
+}
+
+// verifyWebAuthnLogin verifies WebAuthn assertion of the ceremony and returns the used credential.
+// It returns false if verification fails
+func verifyWebAuthnLogin(ctx context.Context, app AppConfig, sess *webauthnSession, body []byte) (*storage.WebAuthnCredential, bool, error) {
+	webauthnStorage := app.storageFor(ctx, "webauthn").(storage.WebAuthn)
+
+	var (
+		stored    *storage.WebAuthnCredential
+		lookupErr error
+	)
+	lookup := func(id []byte, userHandle []byte) (*webauthn.Credential, error) {
+		stored, lookupErr = webauthnStorage.GetWebAuthnCred(*app.WebAuthn.CredColl, id)
+		if lookupErr != nil || stored == nil {
+			return nil, webauthn.ErrUnknownCredential
+		}
+
+		if sess.UserUnique != nil && fmt.Sprint(sess.UserUnique) != fmt.Sprint(stored.UserUnique) {
+			return nil, webauthn.ErrUnknownCredential
+		}
+		if len(userHandle) != 0 && !bytes.Equal(userHandle, webauthnUserHandle(stored.UserUnique)) {
+			return nil, webauthn.ErrUnknownCredential
+		}
+
+		cred := toWebAuthnCreds([]storage.WebAuthnCredential{*stored})[0]
+		return &cred, nil
+	}
+
+	cred, err := app.WebAuthn.RP.FinishLogin(sess.Data, body, lookup)
+	if lookupErr != nil {
+		return nil, false, lookupErr
+	}
+	if err != nil {
+		return nil, false, nil
+	}
+
+	err = webauthnStorage.UpdateWebAuthnSignCount(*app.WebAuthn.CredColl, cred.ID, cred.SignCount)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return stored, true, nil
+}
+
+// getWebAuthnCeremony returns the state of the ceremony and serialized credential passed by the client.
+// It returns nil state if the ceremony is unknown or expired
+func getWebAuthnCeremony(ctx context.Context, app AppConfig, data interface{}) (*webauthnSession, []byte, error) {
+	rawSessionId, err := GetJSONPath(app.WebAuthn.Session, data)
+	if err != nil {
+		return nil, nil, nil
+	}
+	sessionId, ok := rawSessionId.(string)
+	if !ok {
+		return nil, nil, nil
+	}
+
+	rawCred, err := GetJSONPath(app.WebAuthn.Credential, data)
+	if err != nil {
+		return nil, nil, nil
+	}
+	body, err := json.Marshal(rawCred)
+	if err != nil {
+		return nil, nil, nil
+	}
+
+	webauthnStorage := app.storageFor(ctx, "webauthn").(storage.WebAuthn)
+	rawSess, err := webauthnStorage.PopWebAuthnChallenge(*app.WebAuthn.ChallengeColl, sessionId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if rawSess == "" {
+		return nil, nil, nil
+	}
+
+	var sess webauthnSession
+	if err := json.Unmarshal([]byte(rawSess), &sess); err != nil {
+		return nil, nil, err
+	}
+
+	return &sess, body, nil
+}
+
+// saveWebAuthnSession stores the state of the ceremony and returns its id
+func saveWebAuthnSession(ctx context.Context, app AppConfig, sess webauthnSession) (string, error) {
+	rawId := make([]byte, 16)
+	if _, err := rand.Read(rawId); err != nil {
+		return "", err
+	}
+	id := base64.RawURLEncoding.EncodeToString(rawId)
+
+	data, err := json.Marshal(sess)
+	if err != nil {
+		return "", err
+	}
+
+	webauthnStorage := app.storageFor(ctx, "webauthn").(storage.WebAuthn)
+	err = webauthnStorage.InsertWebAuthnChallenge(*app.WebAuthn.ChallengeColl, id, string(data), sess.Data.Expires)
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// getWebAuthnCreds returns public key credentials of the user, or nothing if WebAuthn is disabled
+func getWebAuthnCreds(ctx context.Context, app AppConfig, userUnique interface{}) ([]storage.WebAuthnCredential, error) {
+	if !app.WebAuthn.isEnabled() {
+		return nil, nil
+	}
+
+	webauthnStorage := app.storageFor(ctx, "webauthn").(storage.WebAuthn)
+	return webauthnStorage.GetWebAuthnCreds(*app.WebAuthn.CredColl, userUnique)
+}
+
+// isWebAuthnPassed checks whether WebAuthn assertion is passed with the authentication data
+func isWebAuthnPassed(app AppConfig, authData interface{}) bool {
+	if !app.WebAuthn.isEnabled() {
+		return false
+	}
+
+	_, err := GetJSONPath(app.WebAuthn.Credential, authData)
+	return err == nil
+}
+
+// webauthnUserHandle returns opaque user handle, that doesn't reveal user_unique to the authenticator
+func webauthnUserHandle(userUnique interface{}) []byte {
+	handle := sha256.Sum256([]byte(fmt.Sprint(userUnique)))
+	return handle[:]
+}
+
+func toWebAuthnCreds(creds []storage.WebAuthnCredential) []webauthn.Credential {
+	res := make([]webauthn.Credential, len(creds))
+	for i, cred := range creds {
+		res[i] = webauthn.Credential{
+			ID:         cred.Id,
+			PublicKey:  cred.PublicKey,
+			SignCount:  cred.SignCount,
+			Transports: cred.Transports,
+		}
+	}
+	return res
+}
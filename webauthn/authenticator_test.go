@@ -0,0 +1,151 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// softAuthenticator is a software authenticator, that emulates the client side of the ceremonies
+type softAuthenticator struct {
+	rpID      string
+	origin    string
+	flags     byte
+	attFmt    string
+	signCount uint32
+	keys      map[string]*ecdsa.PrivateKey
+	handles   map[string][]byte
+}
+
+func newSoftAuthenticator(rpID, origin string) *softAuthenticator {
+	return &softAuthenticator{
+		rpID:    rpID,
+		origin:  origin,
+		flags:   flagUserPresent | flagUserVerified,
+		attFmt:  "none",
+		keys:    map[string]*ecdsa.PrivateKey{},
+		handles: map[string][]byte{},
+	}
+}
+
+// create emulates navigator.credentials.create() and returns its serialized result
+func (a *softAuthenticator) create(opts *CreationOptions) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	credID := make([]byte, 16)
+	if _, err := rand.Read(credID); err != nil {
+		panic(err)
+	}
+	a.keys[string(credID)] = key
+	a.handles[string(credID)] = opts.User.ID
+
+	cosePubKey, err := cbor.Marshal(map[int64]interface{}{
+		1:  ktyEC2,
+		3:  AlgES256,
+		-1: crvP256,
+		-2: padTo32(key.X.Bytes()),
+		-3: padTo32(key.Y.Bytes()),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	authData := a.authData(a.flags | flagAttestedCredData)
+	authData = append(authData, make([]byte, 16)...)
+	authData = append(authData, byte(len(credID)>>8), byte(len(credID)))
+	authData = append(authData, credID...)
+	authData = append(authData, cosePubKey...)
+
+	clientDataJSON := a.clientData("webauthn.create", opts.Challenge)
+
+	attStmt := map[string]interface{}{}
+	if a.attFmt == "packed" {
+		clientDataHash := sha256.Sum256(clientDataJSON)
+		attStmt["alg"] = AlgES256
+		attStmt["sig"] = sign(key, append(append([]byte{}, authData...), clientDataHash[:]...))
+	}
+
+	attObj, err := cbor.Marshal(map[string]interface{}{
+		"fmt":      a.attFmt,
+		"attStmt":  attStmt,
+		"authData": authData,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"id":    base64.RawURLEncoding.EncodeToString(credID),
+		"rawId": Base64URL(credID),
+		"type":  "public-key",
+		"response": map[string]interface{}{
+			"clientDataJSON":    Base64URL(clientDataJSON),
+			"attestationObject": Base64URL(attObj),
+			"transports":        []string{"internal"},
+		},
+	})
+	return body, credID
+}
+
+// get emulates navigator.credentials.get() with the given credential and returns its serialized result
+func (a *softAuthenticator) get(opts *RequestOptions, credID []byte) []byte {
+	a.signCount++
+
+	authData := a.authData(a.flags)
+	clientDataJSON := a.clientData("webauthn.get", opts.Challenge)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	sig := sign(a.keys[string(credID)], append(append([]byte{}, authData...), clientDataHash[:]...))
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"id":    base64.RawURLEncoding.EncodeToString(credID),
+		"rawId": Base64URL(credID),
+		"type":  "public-key",
+		"response": map[string]interface{}{
+			"clientDataJSON":    Base64URL(clientDataJSON),
+			"authenticatorData": Base64URL(authData),
+			"signature":         Base64URL(sig),
+			"userHandle":        Base64URL(a.handles[string(credID)]),
+		},
+	})
+	return body
+}
+
+func (a *softAuthenticator) authData(flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+
+	counter := make([]byte, 4)
+	binary.BigEndian.PutUint32(counter, a.signCount)
+	return append(data, counter...)
+}
+
+func (a *softAuthenticator) clientData(typ string, challenge []byte) []byte {
+	data, _ := json.Marshal(map[string]string{
+		"type":      typ,
+		"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":    a.origin,
+	})
+	return data
+}
+
+func sign(key *ecdsa.PrivateKey, data []byte) []byte {
+	digest := sha256.Sum256(data)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		panic(err)
+	}
+	return sig
+}
+
+func padTo32(b []byte) []byte {
+	return append(make([]byte, 32-len(b)), b...)
+}
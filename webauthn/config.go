@@ -0,0 +1,56 @@
+package webauthn
+
+import (
+	"errors"
+	"time"
+)
+
+// Config represents relying party settings
+type Config struct {
+	// Relying party identifier. It's a valid domain string, e.g. "example.com"
+	RPID string
+
+	// Human-palatable name of the relying party
+	RPName string
+
+	// Origins which are allowed to perform ceremonies, e.g. "https://login.example.com"
+	Origins []string
+
+	// Time given to the user to complete a ceremony
+	Timeout time.Duration
+
+	// User verification requirement (required, preferred, discouraged)
+	UserVerification string
+}
+
+// DefaultTimeout is used if ceremony timeout isn't set
+const DefaultTimeout = 5 * time.Minute
+
+// validate checks whether the config is complete and fills defaults
+func (conf *Config) validate() error {
+	if conf.RPID == "" {
+		return errors.New("webauthn: rp id can't be empty")
+	}
+
+	if len(conf.Origins) == 0 {
+		return errors.New("webauthn: at least one origin is required")
+	}
+
+	if conf.RPName == "" {
+		conf.RPName = conf.RPID
+	}
+
+	if conf.Timeout == 0 {
+		conf.Timeout = DefaultTimeout
+	}
+
+	switch conf.UserVerification {
+	case "":
+		conf.UserVerification = VerificationPreferred
+	case VerificationRequired, VerificationPreferred, VerificationDiscouraged:
+	default:
+		return errors.New("webauthn: unknown user verification requirement " + conf.UserVerification)
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"github.com/fxamacker/cbor/v2"
+	"math/big"
+)
+
+// COSE algorithm identifiers supported by the relying party
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+// COSE key types and curves
+const (
+	ktyOKP = 1
+	ktyEC2 = 2
+	ktyRSA = 3
+
+	crvP256    = 1
+	crvEd25519 = 6
+)
+
+var ErrUnsupportedKey = errors.New("webauthn: unsupported credential public key")
+
+// supportedAlgs lists algorithms in the order of preference
+var supportedAlgs = []int64{AlgES256, AlgEdDSA, AlgRS256}
+
+// publicKey represents parsed COSE_Key
+type publicKey struct {
+	alg int64
+	key crypto.PublicKey
+}
+
+// parsePublicKey decodes COSE encoded public key
+func parsePublicKey(data []byte) (*publicKey, error) {
+	var m map[int64]interface{}
+	if err := cbor.Unmarshal(data, &m); err != nil {
+		return nil, ErrUnsupportedKey
+	}
+
+	kty, _ := toInt64(m[1])
+	alg, _ := toInt64(m[3])
+
+	switch {
+	case kty == ktyEC2 && alg == AlgES256:
+		crv, _ := toInt64(m[-1])
+		x, _ := m[-2].([]byte)
+		y, _ := m[-3].([]byte)
+		if crv != crvP256 || len(x) != 32 || len(y) != 32 {
+			return nil, ErrUnsupportedKey
+		}
+
+		key := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, ErrUnsupportedKey
+		}
+		return &publicKey{alg: alg, key: key}, nil
+	case kty == ktyOKP && alg == AlgEdDSA:
+		crv, _ := toInt64(m[-1])
+		x, _ := m[-2].([]byte)
+		if crv != crvEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, ErrUnsupportedKey
+		}
+		return &publicKey{alg: alg, key: ed25519.PublicKey(x)}, nil
+	case kty == ktyRSA && alg == AlgRS256:
+		n, _ := m[-1].([]byte)
+		e, _ := m[-2].([]byte)
+		if len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return nil, ErrUnsupportedKey
+		}
+		return &publicKey{alg: alg, key: &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}}, nil
+	}
+
+	return nil, ErrUnsupportedKey
+}
+
+// verify checks the signature of the data
+func (k *publicKey) verify(data, sig []byte) error {
+	return verifySignature(k.alg, k.key, data, sig)
+}
+
+// verifySignature checks the signature made by the given algorithm
+func verifySignature(alg int64, key crypto.PublicKey, data, sig []byte) error {
+	var isValid bool
+
+	switch alg {
+	case AlgES256:
+		k, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return ErrUnsupportedKey
+		}
+		digest := sha256.Sum256(data)
+		isValid = ecdsa.VerifyASN1(k, digest[:], sig)
+	case AlgEdDSA:
+		k, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return ErrUnsupportedKey
+		}
+		isValid = ed25519.Verify(k, data, sig)
+	case AlgRS256:
+		k, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrUnsupportedKey
+		}
+		digest := sha256.Sum256(data)
+		isValid = rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig) == nil
+	default:
+		return fmt.Errorf("webauthn: algorithm %d isn't supported", alg)
+	}
+
+	if !isValid {
+		return errors.New("webauthn: invalid signature")
+	}
+	return nil
+}
+
+// toInt64 converts CBOR decoded integer into int64
+func toInt64(v interface{}) (int64, bool) {
+	switch i := v.(type) {
+	case int64:
+		return i, true
+	case uint64:
+		return int64(i), true
+	}
+	return 0, false
+}
@@ -0,0 +1,211 @@
+package webauthn
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"github.com/fxamacker/cbor/v2"
+	"strings"
+)
+
+// User verification requirements
+const (
+	VerificationRequired    = "required"
+	VerificationPreferred   = "preferred"
+	VerificationDiscouraged = "discouraged"
+)
+
+// Authenticator data flags
+const (
+	flagUserPresent      = 0x01
+	flagUserVerified     = 0x04
+	flagAttestedCredData = 0x40
+	flagExtensionData    = 0x80
+)
+
+var (
+	ErrInvalidResponse = errors.New("webauthn: the credential response is not in the correct format")
+	ErrInvalidAuthData = errors.New("webauthn: the authenticator data is not in the correct format")
+)
+
+// Base64URL is a byte slice which is encoded as unpadded base64url string in json
+type Base64URL []byte
+
+// MarshalJSON encodes the data as unpadded base64url string
+func (b Base64URL) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+// UnmarshalJSON decodes base64url string with or without padding
+func (b *Base64URL) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return err
+	}
+
+	*b = decoded
+	return nil
+}
+
+// RPEntity describes the relying party
+type RPEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// UserEntity describes the user account
+type UserEntity struct {
+	ID          Base64URL `json:"id"`
+	Name        string    `json:"name"`
+	DisplayName string    `json:"displayName"`
+}
+
+// CredentialParameter describes credential type and signature algorithm
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+// CredentialDescriptor identifies a specific public key credential
+type CredentialDescriptor struct {
+	Type       string    `json:"type"`
+	ID         Base64URL `json:"id"`
+	Transports []string  `json:"transports,omitempty"`
+}
+
+// AuthenticatorSelection describes requirements for the authenticator
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptions are passed to navigator.credentials.create() on the client side
+type CreationOptions struct {
+	Challenge              Base64URL              `json:"challenge"`
+	RP                     RPEntity               `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials,omitempty"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions are passed to navigator.credentials.get() on the client side
+type RequestOptions struct {
+	Challenge        Base64URL              `json:"challenge"`
+	RPID             string                 `json:"rpId"`
+	Timeout          int64                  `json:"timeout"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials,omitempty"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// attestationResponse represents serialized result of navigator.credentials.create()
+type attestationResponse struct {
+	ID       string    `json:"id"`
+	RawID    Base64URL `json:"rawId"`
+	Type     string    `json:"type"`
+	Response struct {
+		ClientDataJSON    Base64URL `json:"clientDataJSON"`
+		AttestationObject Base64URL `json:"attestationObject"`
+		Transports        []string  `json:"transports"`
+	} `json:"response"`
+}
+
+// assertionResponse represents serialized result of navigator.credentials.get()
+type assertionResponse struct {
+	ID       string    `json:"id"`
+	RawID    Base64URL `json:"rawId"`
+	Type     string    `json:"type"`
+	Response struct {
+		ClientDataJSON    Base64URL `json:"clientDataJSON"`
+		AuthenticatorData Base64URL `json:"authenticatorData"`
+		Signature         Base64URL `json:"signature"`
+		UserHandle        Base64URL `json:"userHandle"`
+	} `json:"response"`
+}
+
+// clientData represents CollectedClientData dictionary
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// attestationObject represents CBOR encoded attestation object
+type attestationObject struct {
+	Fmt      string          `cbor:"fmt"`
+	AttStmt  cbor.RawMessage `cbor:"attStmt"`
+	AuthData []byte          `cbor:"authData"`
+}
+
+// authData represents parsed authenticator data
+type authData struct {
+	RPIDHash  []byte
+	Flags     byte
+	SignCount uint32
+
+	// following fields are present only if flagAttestedCredData is set
+	AAGUID       []byte
+	CredentialID []byte
+	PublicKey    []byte
+}
+
+// parseAuthData parses binary authenticator data structure
+func parseAuthData(data []byte) (*authData, error) {
+	if len(data) < 37 {
+		return nil, ErrInvalidAuthData
+	}
+
+	ad := &authData{
+		RPIDHash:  data[:32],
+		Flags:     data[32],
+		SignCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+
+	rest := data[37:]
+	if ad.Flags&flagAttestedCredData != 0 {
+		if len(rest) < 18 {
+			return nil, ErrInvalidAuthData
+		}
+		ad.AAGUID = rest[:16]
+
+		idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if len(rest) < idLen {
+			return nil, ErrInvalidAuthData
+		}
+		ad.CredentialID = rest[:idLen]
+		rest = rest[idLen:]
+
+		dec := cbor.NewDecoder(bytes.NewReader(rest))
+		var pubKey cbor.RawMessage
+		if err := dec.Decode(&pubKey); err != nil {
+			return nil, ErrInvalidAuthData
+		}
+		ad.PublicKey = pubKey
+		rest = rest[dec.NumBytesRead():]
+	}
+
+	if ad.Flags&flagExtensionData != 0 {
+		var ext cbor.RawMessage
+		dec := cbor.NewDecoder(bytes.NewReader(rest))
+		if err := dec.Decode(&ext); err != nil {
+			return nil, ErrInvalidAuthData
+		}
+		rest = rest[dec.NumBytesRead():]
+	}
+
+	if len(rest) != 0 {
+		return nil, ErrInvalidAuthData
+	}
+
+	return ad, nil
+}
@@ -0,0 +1,377 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fxamacker/cbor/v2"
+	"time"
+)
+
+// challengeLen is the length of the random challenge in bytes
+const challengeLen = 32
+
+var (
+	ErrSessionExpired    = errors.New("webauthn: ceremony session has expired")
+	ErrCredentialCloned  = errors.New("webauthn: signature counter hasn't increased, credential may be cloned")
+	ErrUnknownCredential = errors.New("webauthn: credential isn't allowed for this ceremony")
+)
+
+// WebAuthn performs registration and authentication ceremonies for one relying party
+type WebAuthn struct {
+	conf *Config
+}
+
+// User represents account the credential is registered for
+type User struct {
+	// Opaque identifier of the account, at most 64 bytes
+	Handle      []byte
+	Name        string
+	DisplayName string
+}
+
+// Credential represents public key credential registered by the user
+type Credential struct {
+	ID         []byte
+	PublicKey  []byte
+	SignCount  uint32
+	Transports []string
+}
+
+// SessionData holds the state of the ceremony between its begin and finish steps
+type SessionData struct {
+	Challenge          Base64URL   `json:"challenge"`
+	UserHandle         Base64URL   `json:"user_handle,omitempty"`
+	AllowedCredentials []Base64URL `json:"allowed_credentials,omitempty"`
+	UserVerification   string      `json:"user_verification"`
+	Expires            time.Time   `json:"expires"`
+}
+
+// CredentialLookup returns stored credential by its id and the user handle passed by the authenticator
+type CredentialLookup func(id []byte, userHandle []byte) (*Credential, error)
+
+// New returns WebAuthn relying party with the given settings
+func New(conf Config) (*WebAuthn, error) {
+	if err := conf.validate(); err != nil {
+		return nil, err
+	}
+
+	return &WebAuthn{conf: &conf}, nil
+}
+
+// BeginRegistration returns options for navigator.credentials.create() and the ceremony state.
+// Credentials from exclude are listed so the same authenticator isn't registered twice
+func (w *WebAuthn) BeginRegistration(user User, exclude []Credential) (*CreationOptions, *SessionData, error) {
+	challenge, err := newChallenge()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	params := make([]CredentialParameter, len(supportedAlgs))
+	for i, alg := range supportedAlgs {
+		params[i] = CredentialParameter{Type: "public-key", Alg: alg}
+	}
+
+	opts := &CreationOptions{
+		Challenge: challenge,
+		RP:        RPEntity{ID: w.conf.RPID, Name: w.conf.RPName},
+		User: UserEntity{
+			ID:          user.Handle,
+			Name:        user.Name,
+			DisplayName: user.DisplayName,
+		},
+		PubKeyCredParams:   params,
+		Timeout:            w.conf.Timeout.Milliseconds(),
+		ExcludeCredentials: descriptors(exclude),
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: w.conf.UserVerification,
+		},
+		Attestation: "none",
+	}
+
+	session := &SessionData{
+		Challenge:        challenge,
+		UserHandle:       user.Handle,
+		UserVerification: w.conf.UserVerification,
+		Expires:          time.Now().Add(w.conf.Timeout),
+	}
+
+	return opts, session, nil
+}
+
+// FinishRegistration verifies the serialized result of navigator.credentials.create()
+// and returns the new credential
+func (w *WebAuthn) FinishRegistration(session SessionData, body []byte) (*Credential, error) {
+	var resp attestationResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, ErrInvalidResponse
+	}
+
+	if resp.Type != "public-key" {
+		return nil, ErrInvalidResponse
+	}
+
+	clientDataHash, err := w.verifyClientData(session, resp.Response.ClientDataJSON, "webauthn.create")
+	if err != nil {
+		return nil, err
+	}
+
+	var attObj attestationObject
+	if err := cbor.Unmarshal(resp.Response.AttestationObject, &attObj); err != nil {
+		return nil, ErrInvalidResponse
+	}
+
+	ad, err := w.verifyAuthData(session, attObj.AuthData)
+	if err != nil {
+		return nil, err
+	}
+
+	if ad.Flags&flagAttestedCredData == 0 {
+		return nil, errors.New("webauthn: attested credential data is missing")
+	}
+
+	if !bytes.Equal(ad.CredentialID, resp.RawID) {
+		return nil, errors.New("webauthn: credential id mismatch")
+	}
+
+	pubKey, err := parsePublicKey(ad.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifyAttestation(attObj, pubKey, clientDataHash); err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		ID:         ad.CredentialID,
+		PublicKey:  ad.PublicKey,
+		SignCount:  ad.SignCount,
+		Transports: resp.Response.Transports,
+	}, nil
+}
+
+// BeginLogin returns options for navigator.credentials.get() and the ceremony state.
+// If allowed is empty, any discoverable credential (passkey) of the relying party may be used
+func (w *WebAuthn) BeginLogin(allowed []Credential) (*RequestOptions, *SessionData, error) {
+	return w.beginLogin(allowed, w.conf.UserVerification)
+}
+
+// BeginPasswordlessLogin is BeginLogin, which requires user verification regardless of the configured requirement,
+// since the passkey is the only factor of the login
+func (w *WebAuthn) BeginPasswordlessLogin(allowed []Credential) (*RequestOptions, *SessionData, error) {
+	return w.beginLogin(allowed, VerificationRequired)
+}
+
+func (w *WebAuthn) beginLogin(allowed []Credential, userVerification string) (*RequestOptions, *SessionData, error) {
+	challenge, err := newChallenge()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts := &RequestOptions{
+		Challenge:        challenge,
+		RPID:             w.conf.RPID,
+		Timeout:          w.conf.Timeout.Milliseconds(),
+		AllowCredentials: descriptors(allowed),
+		UserVerification: userVerification,
+	}
+
+	session := &SessionData{
+		Challenge:        challenge,
+		UserVerification: userVerification,
+		Expires:          time.Now().Add(w.conf.Timeout),
+	}
+	for _, cred := range allowed {
+		session.AllowedCredentials = append(session.AllowedCredentials, cred.ID)
+	}
+
+	return opts, session, nil
+}
+
+// FinishLogin verifies the serialized result of navigator.credentials.get().
+// It returns the used credential with the updated signature counter
+func (w *WebAuthn) FinishLogin(session SessionData, body []byte, lookup CredentialLookup) (*Credential, error) {
+	var resp assertionResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, ErrInvalidResponse
+	}
+
+	if resp.Type != "public-key" {
+		return nil, ErrInvalidResponse
+	}
+
+	if len(session.AllowedCredentials) != 0 {
+		isAllowed := false
+		for _, id := range session.AllowedCredentials {
+			if bytes.Equal(id, resp.RawID) {
+				isAllowed = true
+				break
+			}
+		}
+		if !isAllowed {
+			return nil, ErrUnknownCredential
+		}
+	}
+
+	cred, err := lookup(resp.RawID, resp.Response.UserHandle)
+	if err != nil {
+		return nil, err
+	}
+	if cred == nil {
+		return nil, ErrUnknownCredential
+	}
+
+	clientDataHash, err := w.verifyClientData(session, resp.Response.ClientDataJSON, "webauthn.get")
+	if err != nil {
+		return nil, err
+	}
+
+	ad, err := w.verifyAuthData(session, resp.Response.AuthenticatorData)
+	if err != nil {
+		return nil, err
+	}
+
+	pubKey, err := parsePublicKey(cred.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	signed := append(append([]byte{}, resp.Response.AuthenticatorData...), clientDataHash...)
+	if err := pubKey.verify(signed, resp.Response.Signature); err != nil {
+		return nil, err
+	}
+
+	if (ad.SignCount != 0 || cred.SignCount != 0) && ad.SignCount <= cred.SignCount {
+		return nil, ErrCredentialCloned
+	}
+
+	updated := *cred
+	updated.SignCount = ad.SignCount
+	return &updated, nil
+}
+
+// verifyClientData checks the collected client data and returns its hash
+func (w *WebAuthn) verifyClientData(session SessionData, data []byte, ceremony string) ([]byte, error) {
+	if time.Now().After(session.Expires) {
+		return nil, ErrSessionExpired
+	}
+
+	var cd clientData
+	if err := json.Unmarshal(data, &cd); err != nil {
+		return nil, ErrInvalidResponse
+	}
+
+	if cd.Type != ceremony {
+		return nil, fmt.Errorf("webauthn: unexpected client data type %s", cd.Type)
+	}
+
+	challenge := base64.RawURLEncoding.EncodeToString(session.Challenge)
+	if subtle.ConstantTimeCompare([]byte(cd.Challenge), []byte(challenge)) != 1 {
+		return nil, errors.New("webauthn: challenge mismatch")
+	}
+
+	isAllowedOrigin := false
+	for _, origin := range w.conf.Origins {
+		if cd.Origin == origin {
+			isAllowedOrigin = true
+			break
+		}
+	}
+	if !isAllowedOrigin {
+		return nil, fmt.Errorf("webauthn: origin %s isn't allowed", cd.Origin)
+	}
+
+	hash := sha256.Sum256(data)
+	return hash[:], nil
+}
+
+// verifyAuthData checks relying party id hash and user presence flags of the authenticator data
+func (w *WebAuthn) verifyAuthData(session SessionData, data []byte) (*authData, error) {
+	ad, err := parseAuthData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	rpIDHash := sha256.Sum256([]byte(w.conf.RPID))
+	if !bytes.Equal(ad.RPIDHash, rpIDHash[:]) {
+		return nil, errors.New("webauthn: rp id hash mismatch")
+	}
+
+	if ad.Flags&flagUserPresent == 0 {
+		return nil, errors.New("webauthn: user presence is required")
+	}
+
+	if session.UserVerification == VerificationRequired && ad.Flags&flagUserVerified == 0 {
+		return nil, errors.New("webauthn: user verification is required")
+	}
+
+	return ad, nil
+}
+
+// verifyAttestation checks the attestation statement. Supported formats are "none" and "packed".
+// Attestation certificates aren't checked against trust anchors, since attestation isn't requested
+func verifyAttestation(attObj attestationObject, pubKey *publicKey, clientDataHash []byte) error {
+	switch attObj.Fmt {
+	case "none":
+		var stmt map[string]interface{}
+		if err := cbor.Unmarshal(attObj.AttStmt, &stmt); err != nil || len(stmt) != 0 {
+			return errors.New("webauthn: attestation statement must be empty for none format")
+		}
+		return nil
+	case "packed":
+		var stmt struct {
+			Alg int64    `cbor:"alg"`
+			Sig []byte   `cbor:"sig"`
+			X5c [][]byte `cbor:"x5c"`
+		}
+		if err := cbor.Unmarshal(attObj.AttStmt, &stmt); err != nil {
+			return ErrInvalidResponse
+		}
+
+		signed := append(append([]byte{}, attObj.AuthData...), clientDataHash...)
+		if len(stmt.X5c) == 0 {
+			if stmt.Alg != pubKey.alg {
+				return errors.New("webauthn: attestation algorithm mismatch")
+			}
+			return pubKey.verify(signed, stmt.Sig)
+		}
+
+		cert, err := x509.ParseCertificate(stmt.X5c[0])
+		if err != nil {
+			return err
+		}
+		return verifySignature(stmt.Alg, cert.PublicKey, signed, stmt.Sig)
+	}
+
+	return fmt.Errorf("webauthn: attestation format %s isn't supported", attObj.Fmt)
+}
+
+// newChallenge returns cryptographically random challenge
+func newChallenge() (Base64URL, error) {
+	challenge := make([]byte, challengeLen)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// descriptors converts credentials into the list of descriptors for the client
+func descriptors(creds []Credential) []CredentialDescriptor {
+	var res []CredentialDescriptor
+	for _, cred := range creds {
+		res = append(res, CredentialDescriptor{
+			Type:       "public-key",
+			ID:         cred.ID,
+			Transports: cred.Transports,
+		})
+	}
+	return res
+}
@@ -0,0 +1,237 @@
+package webauthn
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func newTestWebAuthn(t *testing.T, userVerification string) *WebAuthn {
+	w, err := New(Config{
+		RPID:             "example.com",
+		Origins:          []string{"https://login.example.com"},
+		UserVerification: userVerification,
+	})
+	if err != nil {
+		t.Fatalf("create webauthn: %v", err)
+	}
+	return w
+}
+
+func register(t *testing.T, w *WebAuthn, a *softAuthenticator) *Credential {
+	opts, session, err := w.BeginRegistration(User{Handle: []byte("user-1"), Name: "john"}, nil)
+	assert.NoError(t, err)
+
+	body, _ := a.create(opts)
+	cred, err := w.FinishRegistration(*session, body)
+	if err != nil {
+		t.Fatalf("finish registration: %v", err)
+	}
+	return cred
+}
+
+func Test_New(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    Config
+		wantErr bool
+	}{
+		{
+			name:    "valid config",
+			conf:    Config{RPID: "example.com", Origins: []string{"https://example.com"}},
+			wantErr: false,
+		},
+		{
+			name:    "without rp id",
+			conf:    Config{Origins: []string{"https://example.com"}},
+			wantErr: true,
+		},
+		{
+			name:    "without origins",
+			conf:    Config{RPID: "example.com"},
+			wantErr: true,
+		},
+		{
+			name: "unknown user verification",
+			conf: Config{
+				RPID:             "example.com",
+				Origins:          []string{"https://example.com"},
+				UserVerification: "always",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.conf)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_WebAuthn_Registration(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(a *softAuthenticator, session *SessionData)
+		wantErr bool
+	}{
+		{
+			name:    "none attestation",
+			modify:  func(a *softAuthenticator, session *SessionData) {},
+			wantErr: false,
+		},
+		{
+			name:    "packed self attestation",
+			modify:  func(a *softAuthenticator, session *SessionData) { a.attFmt = "packed" },
+			wantErr: false,
+		},
+		{
+			name:    "unsupported attestation",
+			modify:  func(a *softAuthenticator, session *SessionData) { a.attFmt = "tpm" },
+			wantErr: true,
+		},
+		{
+			name:    "foreign origin",
+			modify:  func(a *softAuthenticator, session *SessionData) { a.origin = "https://evil.com" },
+			wantErr: true,
+		},
+		{
+			name:    "foreign rp id",
+			modify:  func(a *softAuthenticator, session *SessionData) { a.rpID = "evil.com" },
+			wantErr: true,
+		},
+		{
+			name:    "without user presence",
+			modify:  func(a *softAuthenticator, session *SessionData) { a.flags = 0 },
+			wantErr: true,
+		},
+		{
+			name: "other challenge",
+			modify: func(a *softAuthenticator, session *SessionData) {
+				session.Challenge = []byte("other challenge")
+			},
+			wantErr: true,
+		},
+		{
+			name: "expired session",
+			modify: func(a *softAuthenticator, session *SessionData) {
+				session.Expires = time.Now().Add(-time.Second)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestWebAuthn(t, VerificationPreferred)
+			a := newSoftAuthenticator("example.com", "https://login.example.com")
+
+			opts, session, err := w.BeginRegistration(User{Handle: []byte("user-1"), Name: "john"}, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, "example.com", opts.RP.ID)
+			assert.Equal(t, session.Challenge, opts.Challenge)
+
+			tt.modify(a, session)
+			body, credID := a.create(opts)
+
+			cred, err := w.FinishRegistration(*session, body)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FinishRegistration() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr {
+				assert.Equal(t, credID, cred.ID)
+				assert.NotEmpty(t, cred.PublicKey)
+				assert.Equal(t, []string{"internal"}, cred.Transports)
+			}
+		})
+	}
+}
+
+func Test_WebAuthn_Login(t *testing.T) {
+	w := newTestWebAuthn(t, VerificationRequired)
+	a := newSoftAuthenticator("example.com", "https://login.example.com")
+	cred := register(t, w, a)
+
+	lookup := func(id []byte, userHandle []byte) (*Credential, error) {
+		assert.Equal(t, []byte("user-1"), userHandle)
+		return cred, nil
+	}
+
+	opts, session, err := w.BeginLogin([]Credential{*cred})
+	assert.NoError(t, err)
+	assert.Len(t, opts.AllowCredentials, 1)
+
+	updated, err := w.FinishLogin(*session, a.get(opts, cred.ID), lookup)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), updated.SignCount)
+	cred = updated
+
+	// discoverable credential
+	opts, session, err = w.BeginLogin(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, opts.AllowCredentials)
+
+	updated, err = w.FinishLogin(*session, a.get(opts, cred.ID), lookup)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), updated.SignCount)
+
+	// signature counter hasn't increased
+	opts, session, _ = w.BeginLogin(nil)
+	a.signCount = 0
+	_, err = w.FinishLogin(*session, a.get(opts, cred.ID), func(id []byte, userHandle []byte) (*Credential, error) {
+		return updated, nil
+	})
+	assert.Equal(t, ErrCredentialCloned, err)
+
+	// credential isn't allowed
+	opts, session, _ = w.BeginLogin([]Credential{{ID: []byte("other")}})
+	_, err = w.FinishLogin(*session, a.get(opts, cred.ID), lookup)
+	assert.Equal(t, ErrUnknownCredential, err)
+
+	// user verification is required
+	opts, session, _ = w.BeginLogin(nil)
+	a.flags = flagUserPresent
+	_, err = w.FinishLogin(*session, a.get(opts, cred.ID), lookup)
+	assert.Error(t, err)
+
+	// signature made by other key
+	other := newSoftAuthenticator("example.com", "https://login.example.com")
+	otherCred := register(t, w, other)
+	other.keys[string(cred.ID)] = other.keys[string(otherCred.ID)]
+	other.handles[string(cred.ID)] = []byte("user-1")
+	other.signCount = 10
+	opts, session, _ = w.BeginLogin(nil)
+	_, err = w.FinishLogin(*session, other.get(opts, cred.ID), lookup)
+	assert.Error(t, err)
+}
+
+func Test_WebAuthn_PasswordlessLogin(t *testing.T) {
+	w := newTestWebAuthn(t, VerificationPreferred)
+	a := newSoftAuthenticator("example.com", "https://login.example.com")
+	cred := register(t, w, a)
+
+	lookup := func(id []byte, userHandle []byte) (*Credential, error) {
+		return cred, nil
+	}
+
+	// the second factor doesn't need user verification
+	a.flags = flagUserPresent
+	opts, session, err := w.BeginLogin(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, VerificationPreferred, opts.UserVerification)
+	cred, err = w.FinishLogin(*session, a.get(opts, cred.ID), lookup)
+	assert.NoError(t, err)
+
+	opts, session, err = w.BeginPasswordlessLogin(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, VerificationRequired, opts.UserVerification)
+	_, err = w.FinishLogin(*session, a.get(opts, cred.ID), lookup)
+	assert.Error(t, err)
+
+	a.flags = flagUserPresent | flagUserVerified
+	opts, session, _ = w.BeginPasswordlessLogin(nil)
+	_, err = w.FinishLogin(*session, a.get(opts, cred.ID), lookup)
+	assert.NoError(t, err)
+}